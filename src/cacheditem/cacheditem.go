@@ -12,6 +12,7 @@ type CachedItem struct {
 	Key          string             `bson:"key"`           // The cache key
 	Value        string             `bson:"value"`         // The cached value
 	ExpiresAtSec int64              `bson:"expires_at"`    // Expiration time for the cache item in seconds since epoch
+	FetchedAtSec int64              `bson:"fetched_at"`    // Time the cached content was downloaded in seconds since epoch
 }
 
 type CachedItemRepository interface {
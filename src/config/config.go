@@ -3,12 +3,66 @@ package config
 import (
 	"os"
 	"time"
+
+	"github.com/nedvisol/go-connectdots/util"
 )
 
+const GRAPHDB_NEO4J = "neo4j"
+const GRAPHDB_MEMORY = "memory"
+const GRAPHDB_SQLITE = "sqlite"
+
+// graph write modes, the default writes the graph without logging
+const WRITE_MODE_DRYRUN = "dryrun" // log the writes without executing them
+const WRITE_MODE_AUDIT = "audit"   // execute the writes and log them
+
 type GraphDbConfig struct {
-	Uri      string
-	Username string
-	Password string
+	Type       string // one of GRAPHDB_NEO4J, GRAPHDB_MEMORY, GRAPHDB_SQLITE
+	Uri        string
+	Username   string
+	Password   string
+	SqlitePath string
+	// QueryTimeout bounds every graph db call, on top of the deadline of the caller's context
+	QueryTimeout time.Duration
+	// MaxRetryTime bounds the retries of a neo4j transaction failing with a transient error
+	MaxRetryTime time.Duration
+	// MigrateOnStart applies pending graph migrations before the schema and the processors start,
+	// with MigrateDryRun the pending migrations are only listed and the processors start anyway
+	MigrateOnStart bool
+	MigrateDryRun  bool
+	// Temporal keeps the history of attribute values instead of overwriting them
+	Temporal bool
+	// WriteMode is empty, WRITE_MODE_DRYRUN or WRITE_MODE_AUDIT. Writes are logged as JSON Lines
+	// to AuditLogPath, or to stdout when it is empty.
+	WriteMode    string
+	AuditLogPath string
+	// ChangeLogPath receives the created, modified and unchanged events of every write as JSON Lines,
+	// empty to only publish them in process
+	ChangeLogPath string
+}
+
+// EntityResConfig tunes the entity resolution of nodes coming from different sources.
+// Matches scoring AutoLinkThreshold or more are linked, ReviewThreshold or more are queued for review.
+type EntityResConfig struct {
+	CrosswalkPath string
+	// LegislatorsPath is a legislators-current.json file of the unitedstates/congress-legislators
+	// project seeding the crosswalk with the LIS, FEC and govtrack ids of members, ignored if missing
+	LegislatorsPath   string
+	AutoLinkThreshold float64
+	ReviewThreshold   float64
+}
+
+// CongressGovConfig tunes the crawl of api.congress.gov.
+// MaxPages limits the pages followed per list endpoint, e.g. "bill", endpoints missing from it
+// follow DefaultMaxPages pages. 0 follows every page.
+type CongressGovConfig struct {
+	MaxPages        map[string]int
+	DefaultMaxPages int
+	// Incremental requests only the members and bills updated since the last complete sync,
+	// bypassing the cache
+	Incremental bool
+	// CommitteeMembershipPath is a committee-membership-current.json file of the
+	// unitedstates/congress-legislators project giving the committee seats of members, ignored if missing
+	CommitteeMembershipPath string
 }
 
 type Config struct {
@@ -17,7 +71,15 @@ type Config struct {
 	MongoUrl         string
 	MongoDb          string
 	CongressGovToken string
+	CongressGov      *CongressGovConfig
+	Scope            *CrawlScopeConfig
 	GraphDb          *GraphDbConfig
+	EntityRes        *EntityResConfig
+	RunId            string // identifies this run in the provenance of everything it writes
+	HealthAddr       string // address of the /healthz endpoint, empty to disable it
+	// the stores are pinged StartupRetries times, StartupRetryDelay apart and doubling, before giving up
+	StartupRetries    int
+	StartupRetryDelay time.Duration
 }
 
 func NewConfig() *Config {
@@ -26,15 +88,42 @@ func NewConfig() *Config {
 		panic(err)
 	}
 	return &Config{
-		CacheDir:         "../.tmp/cache",
-		CacheTtl:         time.Hour * 240,
-		MongoUrl:         "mongodb://nedlinux:27017",
-		MongoDb:          "go_connectdots",
-		CongressGovToken: string(congressApiToken),
+		RunId:             util.NewRunId(),
+		CacheDir:          "../.tmp/cache",
+		CacheTtl:          time.Hour * 240,
+		MongoUrl:          "mongodb://nedlinux:27017",
+		MongoDb:           "go_connectdots",
+		CongressGovToken:  string(congressApiToken),
+		HealthAddr:        ":8081",
+		StartupRetries:    5,
+		StartupRetryDelay: time.Second * 2,
+		CongressGov: &CongressGovConfig{
+			MaxPages: map[string]int{
+				"bill": 100,
+			},
+			DefaultMaxPages:         20,
+			CommitteeMembershipPath: "../.tmp/committee-membership-current.json",
+		},
+		Scope: &CrawlScopeConfig{
+			LatestCongresses: 3,
+		},
+		EntityRes: &EntityResConfig{
+			CrosswalkPath:     "../.tmp/crosswalk.json",
+			LegislatorsPath:   "../.tmp/legislators-current.json",
+			AutoLinkThreshold: 0.92,
+			ReviewThreshold:   0.75,
+		},
 		GraphDb: &GraphDbConfig{
-			Uri:      "neo4j://nedlinux:7687",
-			Username: "neo4j",
-			Password: "neo4jpassword",
+			Type:           GRAPHDB_NEO4J,
+			Uri:            "neo4j://nedlinux:7687",
+			Username:       "neo4j",
+			Password:       "neo4jpassword",
+			SqlitePath:     "../.tmp/graph.sqlite",
+			QueryTimeout:   time.Second * 30,
+			MaxRetryTime:   time.Second * 30,
+			MigrateOnStart: true,
+			AuditLogPath:   "../.tmp/graph-audit.jsonl",
+			ChangeLogPath:  "../.tmp/graph-changes.jsonl",
 		},
 	}
 }
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const CHAMBER_HOUSE = "house"
+const CHAMBER_SENATE = "senate"
+
+// CrawlScopeConfig limits what the processors crawl, empty lists and nil dates do not limit anything
+type CrawlScopeConfig struct {
+	// Congresses lists the congress numbers crawled, when empty the LatestCongresses most recent ones
+	// are crawled, or every congress if LatestCongresses is 0
+	Congresses       []int
+	LatestCongresses int
+	BillTypes        []string // e.g. hr, s, hjres
+	Chambers         []string // CHAMBER_HOUSE, CHAMBER_SENATE
+	// HistoricalMembers crawls every member of congress instead of the current ones
+	HistoricalMembers bool
+	// FromDate and ToDate bound the update date of bills and the date of their actions, inclusive
+	FromDate *time.Time
+	ToDate   *time.Time
+}
+
+// ChamberOf normalizes the chamber names of the sources, e.g. House, House of Representatives
+// and U.S. House of Representatives all give CHAMBER_HOUSE
+func ChamberOf(name string) string {
+	name = strings.ToLower(name)
+	switch {
+	case strings.Contains(name, CHAMBER_HOUSE):
+		return CHAMBER_HOUSE
+	case strings.Contains(name, CHAMBER_SENATE):
+		return CHAMBER_SENATE
+	}
+	return name
+}
+
+// HasCongress tells whether congress is one of Congresses, always true when Congresses is empty
+func (s *CrawlScopeConfig) HasCongress(congress int) bool {
+	if len(s.Congresses) == 0 {
+		return true
+	}
+	for _, scoped := range s.Congresses {
+		if scoped == congress {
+			return true
+		}
+	}
+	return false
+}
+
+// HasBillType tells whether billType is one of BillTypes, regardless of case
+func (s *CrawlScopeConfig) HasBillType(billType string) bool {
+	if len(s.BillTypes) == 0 {
+		return true
+	}
+	for _, scoped := range s.BillTypes {
+		if strings.EqualFold(scoped, billType) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasChamber tells whether the chamber named chamber is one of Chambers, see ChamberOf
+func (s *CrawlScopeConfig) HasChamber(chamber string) bool {
+	if len(s.Chambers) == 0 {
+		return true
+	}
+	for _, scoped := range s.Chambers {
+		if ChamberOf(scoped) == ChamberOf(chamber) {
+			return true
+		}
+	}
+	return false
+}
+
+// InWindow tells whether date, YYYY-MM-DD or RFC 3339, falls between FromDate and ToDate.
+// Dates which do not parse are in the window.
+func (s *CrawlScopeConfig) InWindow(date string) bool {
+	if s.FromDate == nil && s.ToDate == nil {
+		return true
+	}
+	if len(date) < len(time.DateOnly) {
+		return true
+	}
+	day, err := time.Parse(time.DateOnly, date[:len(time.DateOnly)])
+	if err != nil {
+		return true
+	}
+	return (s.FromDate == nil || !day.Before(*s.FromDate)) && (s.ToDate == nil || !day.After(*s.ToDate))
+}
+
+// ParseCongresses parses a list of congress numbers and ranges, e.g. 110,117-118
+func ParseCongresses(value string) ([]int, error) {
+	congresses := make([]int, 0)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		first, last, isRange := strings.Cut(item, "-")
+		from, err := strconv.Atoi(first)
+		if err != nil {
+			return nil, fmt.Errorf("invalid congress %s", item)
+		}
+		to := from
+		if isRange {
+			if to, err = strconv.Atoi(last); err != nil || to < from {
+				return nil, fmt.Errorf("invalid congress range %s", item)
+			}
+		}
+		for congress := from; congress <= to; congress++ {
+			congresses = append(congresses, congress)
+		}
+	}
+	sort.Ints(congresses)
+	return congresses, nil
+}
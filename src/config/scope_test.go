@@ -0,0 +1,107 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseCongresses(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    []int
+		wantErr bool
+	}{
+		{"118", []int{118}, false},
+		{"110-113", []int{110, 111, 112, 113}, false},
+		{"118, 110-111", []int{110, 111, 118}, false},
+		{"117-117", []int{117}, false},
+		{"", []int{}, false},
+		{" , ", []int{}, false},
+		{"abc", nil, true},
+		{"118-110", nil, true},
+		{"110-", nil, true},
+		{"-118", nil, true},
+		{"110-x", nil, true},
+	}
+	for _, test := range tests {
+		got, err := ParseCongresses(test.value)
+		if (err != nil) != test.wantErr {
+			t.Errorf("ParseCongresses(%q) error %v, want error %t", test.value, err, test.wantErr)
+			continue
+		}
+		if !test.wantErr && !reflect.DeepEqual(got, test.want) {
+			t.Errorf("ParseCongresses(%q) = %v, want %v", test.value, got, test.want)
+		}
+	}
+}
+
+func TestHasCongress(t *testing.T) {
+	congresses, _ := ParseCongresses("110-118")
+	tests := []struct {
+		name     string
+		scope    *CrawlScopeConfig
+		congress int
+		want     bool
+	}{
+		{"empty scope has every congress", &CrawlScopeConfig{}, 93, true},
+		{"first of the range", &CrawlScopeConfig{Congresses: congresses}, 110, true},
+		{"last of the range", &CrawlScopeConfig{Congresses: congresses}, 118, true},
+		{"before the range", &CrawlScopeConfig{Congresses: congresses}, 109, false},
+		{"after the range", &CrawlScopeConfig{Congresses: congresses}, 119, false},
+		{"latest congresses only limit the list", &CrawlScopeConfig{LatestCongresses: 2}, 100, true},
+	}
+	for _, test := range tests {
+		if got := test.scope.HasCongress(test.congress); got != test.want {
+			t.Errorf("%s: HasCongress(%d) = %t, want %t", test.name, test.congress, got, test.want)
+		}
+	}
+}
+
+func TestHasChamber(t *testing.T) {
+	tests := []struct {
+		chambers []string
+		chamber  string
+		want     bool
+	}{
+		{nil, "Senate", true},
+		{[]string{CHAMBER_HOUSE}, "House", true},
+		{[]string{CHAMBER_HOUSE}, "U.S. House of Representatives", true},
+		{[]string{CHAMBER_HOUSE}, "Senate", false},
+		{[]string{"Senate"}, CHAMBER_SENATE, true},
+		{[]string{CHAMBER_SENATE}, "Joint", false},
+	}
+	for _, test := range tests {
+		scope := &CrawlScopeConfig{Chambers: test.chambers}
+		if got := scope.HasChamber(test.chamber); got != test.want {
+			t.Errorf("%v HasChamber(%q) = %t, want %t", test.chambers, test.chamber, got, test.want)
+		}
+	}
+}
+
+func TestInWindow(t *testing.T) {
+	from := time.Date(2023, 1, 3, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		scope *CrawlScopeConfig
+		date  string
+		want  bool
+	}{
+		{"no window", &CrawlScopeConfig{}, "1990-01-01", true},
+		{"first day", &CrawlScopeConfig{FromDate: &from, ToDate: &to}, "2023-01-03", true},
+		{"last day", &CrawlScopeConfig{FromDate: &from, ToDate: &to}, "2023-12-31", true},
+		{"last day with a time", &CrawlScopeConfig{FromDate: &from, ToDate: &to}, "2023-12-31T23:59:59Z", true},
+		{"before", &CrawlScopeConfig{FromDate: &from, ToDate: &to}, "2023-01-02", false},
+		{"after", &CrawlScopeConfig{FromDate: &from, ToDate: &to}, "2024-01-01T00:00:00Z", false},
+		{"open end", &CrawlScopeConfig{FromDate: &from}, "2030-01-01", true},
+		{"open start", &CrawlScopeConfig{ToDate: &to}, "1990-01-01", true},
+		{"invalid date", &CrawlScopeConfig{FromDate: &from, ToDate: &to}, "Jan 1", true},
+		{"empty date", &CrawlScopeConfig{FromDate: &from, ToDate: &to}, "", true},
+	}
+	for _, test := range tests {
+		if got := test.scope.InWindow(test.date); got != test.want {
+			t.Errorf("%s: InWindow(%q) = %t, want %t", test.name, test.date, got, test.want)
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
 	"strings"
 	"sync"
@@ -43,6 +44,31 @@ type downloadQueue struct {
 
 type DownloadCallback func(ctx context.Context, data []byte)
 
+type downloadInfoKey struct{}
+
+// DownloadInfo describes the download whose content a DownloadCallback receives
+type DownloadInfo struct {
+	Url       string // request url without the api_key parameter
+	FetchedAt time.Time
+	FromCache bool
+}
+
+// DownloadInfoFromContext returns the DownloadInfo passed to a DownloadCallback, or nil outside of a callback
+func DownloadInfoFromContext(ctx context.Context) *DownloadInfo {
+	info, _ := ctx.Value(downloadInfoKey{}).(*DownloadInfo)
+	return info
+}
+
+func redactUrl(requestUrl *url.URL) string {
+	redacted := *requestUrl
+	query := redacted.Query()
+	if query.Has("api_key") {
+		query.Del("api_key")
+		redacted.RawQuery = query.Encode()
+	}
+	return redacted.String()
+}
+
 type DownloadCacheOption struct {
 	Ttl time.Duration
 }
@@ -53,6 +79,13 @@ func NewDownloadCacheOption(ttl time.Duration) *DownloadCacheOption {
 	}
 }
 
+// DownloadNoCacheOption downloads from the host even when the content is cached, and leaves the cache as is
+type DownloadNoCacheOption struct{}
+
+func NewDownloadNoCacheOption() *DownloadNoCacheOption {
+	return &DownloadNoCacheOption{}
+}
+
 func (dm *DownloadManager) getHashKey(request *http.Request) string {
 	val := fmt.Sprintf("%s %s", request.Method, request.URL)
 	// Compute the SHA-512 hash
@@ -72,18 +105,29 @@ func (dm *DownloadManager) processDownload(
 	opts ...interface{},
 ) {
 	host := request.Host
-	logger.Printf("Q=%d for %s, processing %s\n", dm.downloadQueue.queuesCount[host], host, request.URL.String())
+	dm.downloadQueue.mutex.Lock()
+	queued := dm.downloadQueue.queuesCount[host]
+	dm.downloadQueue.mutex.Unlock()
+	logger.Printf("Q=%d for %s, processing %s\n", queued, host, request.URL.String())
 
 	//extract options
 	ttl := dm.options.Config.CacheTtl
+	noCache := false
 
 	for _, opt := range opts {
 		switch optVal := opt.(type) {
 		case *DownloadCacheOption:
 			ttl = optVal.Ttl
+		case *DownloadNoCacheOption:
+			noCache = true
 		}
 	}
 
+	if noCache {
+		dm.downloadFromHost(ctx, request, callback)
+		return
+	}
+
 	//check for cache
 	requestHashKey := dm.getHashKey(request)
 	cacheFilePath := fmt.Sprintf("%s/%s", dm.options.CacheDir, requestHashKey)
@@ -100,9 +144,17 @@ func (dm *DownloadManager) processDownload(
 			if err != nil {
 				logger.Printf("cannot open file for %s - %s", request.URL.String(), cacheFilePath)
 			} else {
+				info := &DownloadInfo{
+					Url:       redactUrl(request.URL),
+					FetchedAt: time.Unix(cachedItem.FetchedAtSec, 0),
+					FromCache: true,
+				}
+				if cachedItem.FetchedAtSec == 0 {
+					info.FetchedAt = time.Time{}
+				}
 				go func() {
 					logger.Printf("returned from cache %s", request.URL.String())
-					callback(ctx, data)
+					callback(context.WithValue(ctx, downloadInfoKey{}, info), data)
 				}()
 				return
 			}
@@ -118,6 +170,28 @@ func (dm *DownloadManager) processDownload(
 	}
 	// download from host and return content
 
+	body, fetchedAt := dm.fetch(request)
+	os.WriteFile(cacheFilePath, body, 0600)
+	err = dm.options.CachedItemRepo.Create(ctx, &cacheditem.CachedItem{
+		Key:          requestHashKey,
+		ExpiresAtSec: fetchedAt.Add(ttl).Unix(),
+		FetchedAtSec: fetchedAt.Unix(),
+	})
+	logger.Printf("downloaded %s - cache updated %s", request.URL.String(), cacheFilePath)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	info := &DownloadInfo{
+		Url:       redactUrl(request.URL),
+		FetchedAt: fetchedAt,
+	}
+	go func() {
+		callback(context.WithValue(ctx, downloadInfoKey{}, info), body)
+	}()
+}
+
+// fetch downloads the content of request from the host
+func (dm *DownloadManager) fetch(request *http.Request) ([]byte, time.Time) {
 	resp, err := dm.client.Do(request)
 	if err != nil {
 		panic("http request error")
@@ -128,18 +202,19 @@ func (dm *DownloadManager) processDownload(
 		panic("http read from body error")
 
 	}
+	return body, time.Now()
+}
 
-	os.WriteFile(cacheFilePath, body, 0600)
-	err = dm.options.CachedItemRepo.Create(ctx, &cacheditem.CachedItem{
-		Key:          requestHashKey,
-		ExpiresAtSec: time.Now().Add(ttl).Unix(),
-	})
-	logger.Printf("downloaded %s - cache updated %s", request.URL.String(), cacheFilePath)
-	if err != nil {
-		logger.Fatal(err)
+// downloadFromHost downloads the content of request bypassing the cache
+func (dm *DownloadManager) downloadFromHost(ctx context.Context, request *http.Request, callback DownloadCallback) {
+	body, fetchedAt := dm.fetch(request)
+	logger.Printf("downloaded %s - not cached", request.URL.String())
+	info := &DownloadInfo{
+		Url:       redactUrl(request.URL),
+		FetchedAt: fetchedAt,
 	}
 	go func() {
-		callback(ctx, body)
+		callback(context.WithValue(ctx, downloadInfoKey{}, info), body)
 	}()
 }
 
@@ -157,11 +232,12 @@ func (dm *DownloadManager) Download(ctx context.Context, request *http.Request,
 		dq.queuesCount[host]++
 	}
 	sem := dq.queues[host]
+	queued := dq.queuesCount[host]
 	dq.mutex.Unlock()
 
 	dq.wg.Add(1)
-	logger.Printf("Q=%d for %s, added %s\n", dq.queuesCount[host], host, request.URL.String())
-	go dm.processRequest(ctx, request, callback, sem, opts)
+	logger.Printf("Q=%d for %s, added %s\n", queued, host, request.URL.String())
+	go dm.processRequest(ctx, request, callback, sem, opts...)
 
 }
 
@@ -180,11 +256,13 @@ func (dm *DownloadManager) processRequest(
 	sem <- struct{}{}
 	defer func() {
 		<-sem
-		dm.downloadQueue.queuesCount[request.Host]--
+		dq.mutex.Lock()
+		dq.queuesCount[request.Host]--
+		dq.mutex.Unlock()
 	}() // Release slot after the download
 
 	// Perform the download
-	dm.processDownload(ctx, request, callback, opts)
+	dm.processDownload(ctx, request, callback, opts...)
 }
 
 // Wait waits for all downloads to complete
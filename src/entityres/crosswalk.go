@@ -0,0 +1,255 @@
+package entityres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/nedvisol/go-connectdots/config"
+	"github.com/nedvisol/go-connectdots/ids"
+	"go.uber.org/fx"
+)
+
+// identifier systems of the crosswalk
+const SYSTEM_BIOGUIDE = "bioguide"
+const SYSTEM_LIS = "lis"
+const SYSTEM_FEC = "fec"
+const SYSTEM_GOVTRACK = "govtrack"
+
+// CrosswalkEntry is a canonical entity, the graph node Label and Id, with its identifiers in other systems
+type CrosswalkEntry struct {
+	Label string            `json:"label"`
+	Id    string            `json:"id"`
+	Ids   map[string]string `json:"ids"`
+}
+
+type crosswalkFile struct {
+	Entities []*CrosswalkEntry `json:"entities"`
+}
+
+// Crosswalk maps the identifiers other systems give an entity to its canonical node
+type Crosswalk struct {
+	mu       sync.RWMutex
+	entities map[string]*CrosswalkEntry // by label and id
+	external map[string]*CrosswalkEntry // by system and external id
+}
+
+func entityKey(label string, id string) string {
+	return label + "/" + id
+}
+
+func externalKey(system string, externalId string) string {
+	return system + "/" + externalId
+}
+
+// Add records externalId as the identifier of the canonical node label/id in system,
+// replacing the entity it was recorded for before
+func (c *Crosswalk) Add(label string, id string, system string, externalId string) {
+	if externalId == "" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.add(label, id, system, externalId)
+}
+
+// add is Add with the lock held
+func (c *Crosswalk) add(label string, id string, system string, externalId string) {
+	key := entityKey(label, id)
+	entry, found := c.entities[key]
+	if !found {
+		entry = &CrosswalkEntry{Label: label, Id: id, Ids: make(map[string]string)}
+		c.entities[key] = entry
+	}
+	if previous, found := c.external[externalKey(system, externalId)]; found && previous != entry {
+		delete(previous.Ids, system)
+	}
+	if old, found := entry.Ids[system]; found && old != externalId {
+		delete(c.external, externalKey(system, old))
+	}
+	entry.Ids[system] = externalId
+	c.external[externalKey(system, externalId)] = entry
+}
+
+// Lookup returns the canonical entity identified by externalId in system, or nil
+func (c *Crosswalk) Lookup(system string, externalId string) *CrosswalkEntry {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.external[externalKey(system, externalId)]
+}
+
+// Get returns the canonical entity of node label/id, or nil if it has no identifiers recorded
+func (c *Crosswalk) Get(label string, id string) *CrosswalkEntry {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.entities[entityKey(label, id)]
+}
+
+// Entities returns the canonical entities with the given label, sorted by id
+func (c *Crosswalk) Entities(label string) []*CrosswalkEntry {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	entries := make([]*CrosswalkEntry, 0)
+	for _, entry := range c.entities {
+		if entry.Label == label {
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Id < entries[j].Id
+	})
+	return entries
+}
+
+// Move reassigns the identifiers of the entity from label/fromId to label/toId, used when merging nodes
+func (c *Crosswalk) Move(label string, fromId string, toId string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	from, found := c.entities[entityKey(label, fromId)]
+	if !found {
+		return
+	}
+	delete(c.entities, entityKey(label, fromId))
+	for system, externalId := range from.Ids {
+		c.add(label, toId, system, externalId)
+	}
+}
+
+// Write writes the crosswalk as JSON
+func (c *Crosswalk) Write(w io.Writer) error {
+	c.mu.RLock()
+	file := &crosswalkFile{Entities: make([]*CrosswalkEntry, 0, len(c.entities))}
+	for _, entry := range c.entities {
+		file.Entities = append(file.Entities, entry)
+	}
+	c.mu.RUnlock()
+	sort.Slice(file.Entities, func(i, j int) bool {
+		return entityKey(file.Entities[i].Label, file.Entities[i].Id) < entityKey(file.Entities[j].Label, file.Entities[j].Id)
+	})
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(file)
+}
+
+// Read adds the entities of a crosswalk written by Write
+func (c *Crosswalk) Read(r io.Reader) error {
+	var file crosswalkFile
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return err
+	}
+	for _, entry := range file.Entities {
+		for system, externalId := range entry.Ids {
+			c.Add(entry.Label, entry.Id, system, externalId)
+		}
+	}
+	return nil
+}
+
+// legislator is the part of a unitedstates/congress-legislators JSON record the crosswalk uses
+type legislator struct {
+	Id struct {
+		Bioguide string   `json:"bioguide"`
+		Lis      string   `json:"lis"`
+		Govtrack int      `json:"govtrack"`
+		Fec      []string `json:"fec"`
+	} `json:"id"`
+}
+
+// ReadLegislators adds the ids of the legislators-current.json or legislators-historical.json
+// files of the unitedstates/congress-legislators project, personId gives the canonical Person node
+// id of a bioguide id
+func (c *Crosswalk) ReadLegislators(r io.Reader, personId func(bioguideId string) string) error {
+	var legislators []*legislator
+	if err := json.NewDecoder(r).Decode(&legislators); err != nil {
+		return err
+	}
+	for _, legislator := range legislators {
+		if legislator.Id.Bioguide == "" {
+			continue
+		}
+		id := personId(legislator.Id.Bioguide)
+		c.Add("Person", id, SYSTEM_BIOGUIDE, legislator.Id.Bioguide)
+		c.Add("Person", id, SYSTEM_LIS, legislator.Id.Lis)
+		if legislator.Id.Govtrack != 0 {
+			c.Add("Person", id, SYSTEM_GOVTRACK, fmt.Sprintf("%d", legislator.Id.Govtrack))
+		}
+		// candidates get a new FEC id per office they run for, the latest is kept
+		if len(legislator.Id.Fec) > 0 {
+			c.Add("Person", id, SYSTEM_FEC, legislator.Id.Fec[len(legislator.Id.Fec)-1])
+		}
+	}
+	return nil
+}
+
+// Load adds the entities of the crosswalk file at path, a missing file is an empty crosswalk
+func (c *Crosswalk) Load(path string) error {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return c.Read(file)
+}
+
+// Save writes the crosswalk to path
+func (c *Crosswalk) Save(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := c.Write(file); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+func OpenCrosswalk() *Crosswalk {
+	return &Crosswalk{
+		entities: make(map[string]*CrosswalkEntry),
+		external: make(map[string]*CrosswalkEntry),
+	}
+}
+
+// LoadLegislators adds the ids of the congress-legislators file at path, see ReadLegislators.
+// A missing file adds nothing.
+func (c *Crosswalk) LoadLegislators(path string, personId func(bioguideId string) string) error {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return c.ReadLegislators(file, personId)
+}
+
+// NewCrosswalk loads the crosswalk at config.EntityRes.CrosswalkPath, adds the legislators of
+// config.EntityRes.LegislatorsPath and saves it back when the app stops
+func NewCrosswalk(lifecycle fx.Lifecycle, cfg *config.Config) (*Crosswalk, error) {
+	crosswalk := OpenCrosswalk()
+	if err := crosswalk.Load(cfg.EntityRes.CrosswalkPath); err != nil {
+		return nil, fmt.Errorf("loading crosswalk %s: %w", cfg.EntityRes.CrosswalkPath, err)
+	}
+	if err := crosswalk.LoadLegislators(cfg.EntityRes.LegislatorsPath, ids.Member); err != nil {
+		return nil, fmt.Errorf("loading legislators %s: %w", cfg.EntityRes.LegislatorsPath, err)
+	}
+
+	lifecycle.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			fmt.Println("Application is stopping. saving crosswalk")
+			return crosswalk.Save(cfg.EntityRes.CrosswalkPath)
+		},
+	})
+	return crosswalk, nil
+}
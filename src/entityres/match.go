@@ -0,0 +1,176 @@
+package entityres
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// match methods, from the most to the least certain
+const METHOD_CROSSWALK = "crosswalk"
+const METHOD_EXACT = "exact"
+const METHOD_FUZZY = "fuzzy"
+
+// weights of the fuzzy score, the name similarity dominates
+const NAME_WEIGHT = 0.7
+const STATE_WEIGHT = 0.2
+const PARTY_WEIGHT = 0.1
+
+// EXACT_CONFIDENCE is the confidence of an identical name, state and party, below a crosswalk match
+// since namesakes exist
+const EXACT_CONFIDENCE = 0.95
+
+var stateCodes = map[string]string{
+	"alabama": "AL", "alaska": "AK", "arizona": "AZ", "arkansas": "AR", "california": "CA",
+	"colorado": "CO", "connecticut": "CT", "delaware": "DE", "florida": "FL", "georgia": "GA",
+	"hawaii": "HI", "idaho": "ID", "illinois": "IL", "indiana": "IN", "iowa": "IA",
+	"kansas": "KS", "kentucky": "KY", "louisiana": "LA", "maine": "ME", "maryland": "MD",
+	"massachusetts": "MA", "michigan": "MI", "minnesota": "MN", "mississippi": "MS", "missouri": "MO",
+	"montana": "MT", "nebraska": "NE", "nevada": "NV", "new hampshire": "NH", "new jersey": "NJ",
+	"new mexico": "NM", "new york": "NY", "north carolina": "NC", "north dakota": "ND", "ohio": "OH",
+	"oklahoma": "OK", "oregon": "OR", "pennsylvania": "PA", "rhode island": "RI", "south carolina": "SC",
+	"south dakota": "SD", "tennessee": "TN", "texas": "TX", "utah": "UT", "vermont": "VT",
+	"virginia": "VA", "washington": "WA", "west virginia": "WV", "wisconsin": "WI", "wyoming": "WY",
+	"district of columbia": "DC", "puerto rico": "PR", "guam": "GU", "american samoa": "AS",
+	"virgin islands": "VI", "northern mariana islands": "MP",
+}
+
+// StateCode returns the postal code of a state given by name or code, e.g. Oregon and OR both give OR
+func StateCode(state string) string {
+	state = strings.TrimSpace(state)
+	if code, found := stateCodes[strings.ToLower(state)]; found {
+		return code
+	}
+	return strings.ToUpper(state)
+}
+
+// PartyCode returns the one letter code of a party, e.g. Democratic and D both give D
+func PartyCode(party string) string {
+	party = strings.TrimSpace(party)
+	if party == "" {
+		return ""
+	}
+	return strings.ToUpper(party[:1])
+}
+
+// name suffixes and titles left out of name comparisons
+var ignoredNameTokens = map[string]bool{
+	"jr": true, "sr": true, "ii": true, "iii": true, "iv": true,
+	"mr": true, "mrs": true, "ms": true, "dr": true, "sen": true, "rep": true, "hon": true,
+}
+
+// NormalizeName lowercases name, strips accents and punctuation and drops suffixes and titles,
+// e.g. "Blumenauer, Earl Jr." gives "blumenauer earl"
+func NormalizeName(name string) string {
+	decomposed := norm.NFD.String(name)
+	var b strings.Builder
+	for _, r := range decomposed {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// accent of the previous letter
+		case unicode.IsLetter(r):
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			b.WriteRune(' ')
+		}
+	}
+	tokens := make([]string, 0)
+	for _, token := range strings.Fields(b.String()) {
+		if !ignoredNameTokens[token] {
+			tokens = append(tokens, token)
+		}
+	}
+	return strings.Join(tokens, " ")
+}
+
+// JaroWinkler returns the Jaro-Winkler similarity of a and b, 1 for identical strings
+func JaroWinkler(a string, b string) float64 {
+	s1, s2 := []rune(a), []rune(b)
+	if len(s1) == 0 && len(s2) == 0 {
+		return 1
+	}
+	if len(s1) == 0 || len(s2) == 0 {
+		return 0
+	}
+
+	window := max(len(s1), len(s2))/2 - 1
+	if window < 0 {
+		window = 0
+	}
+	matched1 := make([]bool, len(s1))
+	matched2 := make([]bool, len(s2))
+	matches := 0
+	for i := range s1 {
+		for j := max(0, i-window); j < min(len(s2), i+window+1); j++ {
+			if !matched2[j] && s1[i] == s2[j] {
+				matched1[i], matched2[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+
+	transpositions := 0
+	j := 0
+	for i := range s1 {
+		if !matched1[i] {
+			continue
+		}
+		for !matched2[j] {
+			j++
+		}
+		if s1[i] != s2[j] {
+			transpositions++
+		}
+		j++
+	}
+	m := float64(matches)
+	jaro := (m/float64(len(s1)) + m/float64(len(s2)) + (m-float64(transpositions)/2)/m) / 3
+
+	prefix := 0
+	for prefix < min(4, len(s1), len(s2)) && s1[prefix] == s2[prefix] {
+		prefix++
+	}
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
+
+// nameSimilarity compares names regardless of the order of their parts, "Last, First" and
+// "First Last" are the same name
+func nameSimilarity(a string, b string) float64 {
+	return max(JaroWinkler(sortedTokens(a), sortedTokens(b)), JaroWinkler(a, b))
+}
+
+func sortedTokens(name string) string {
+	tokens := strings.Fields(name)
+	for i := 1; i < len(tokens); i++ {
+		for j := i; j > 0 && tokens[j] < tokens[j-1]; j-- {
+			tokens[j], tokens[j-1] = tokens[j-1], tokens[j]
+		}
+	}
+	return strings.Join(tokens, " ")
+}
+
+// Score returns the confidence that candidate and the canonical entity described by name, state
+// and party are the same, with the method that decided it
+func Score(candidate *Candidate, name string, state string, party string) (float64, string) {
+	candidateName := NormalizeName(candidate.Name)
+	name = NormalizeName(name)
+	sameState := candidate.State != "" && StateCode(candidate.State) == StateCode(state)
+	sameParty := candidate.Party != "" && PartyCode(candidate.Party) == PartyCode(party)
+
+	if candidateName != "" && sortedTokens(candidateName) == sortedTokens(name) && sameState && sameParty {
+		return EXACT_CONFIDENCE, METHOD_EXACT
+	}
+	score := NAME_WEIGHT * nameSimilarity(candidateName, name)
+	if sameState {
+		score += STATE_WEIGHT
+	}
+	if sameParty {
+		score += PARTY_WEIGHT
+	}
+	return score, METHOD_FUZZY
+}
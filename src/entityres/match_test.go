@@ -0,0 +1,30 @@
+package entityres
+
+import (
+	"math"
+	"testing"
+)
+
+func TestScore(t *testing.T) {
+	wyden := &Candidate{Name: "Ron Wyden", State: "OR", Party: "D"}
+	tests := []struct {
+		name       string
+		candidate  *Candidate
+		canonical  [3]string // name, state and party
+		confidence float64
+		method     string
+	}{
+		{"same name state and party", wyden, [3]string{"Wyden, Ron", "Oregon", "Democratic"}, EXACT_CONFIDENCE, METHOD_EXACT},
+		{"suffix and accents ignored", &Candidate{Name: "José Serrano Jr.", State: "NY", Party: "D"}, [3]string{"Jose Serrano", "NY", "D"}, EXACT_CONFIDENCE, METHOD_EXACT},
+		{"other state", wyden, [3]string{"Ron Wyden", "WA", "D"}, NAME_WEIGHT + PARTY_WEIGHT, METHOD_FUZZY},
+		{"no state or party", &Candidate{Name: "Ron Wyden"}, [3]string{"Ron Wyden", "OR", "D"}, NAME_WEIGHT, METHOD_FUZZY},
+		{"nickname", wyden, [3]string{"Ronald Wyden", "OR", "D"}, 0.92287, METHOD_FUZZY},
+		{"other person", wyden, [3]string{"Jeff Merkley", "OR", "D"}, 0.62407, METHOD_FUZZY},
+	}
+	for _, test := range tests {
+		confidence, method := Score(test.candidate, test.canonical[0], test.canonical[1], test.canonical[2])
+		if math.Abs(confidence-test.confidence) > 1e-5 || method != test.method {
+			t.Errorf("%s: Score = %f by %s, want %f by %s", test.name, confidence, method, test.confidence, test.method)
+		}
+	}
+}
@@ -0,0 +1,363 @@
+package entityres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nedvisol/go-connectdots/config"
+	"github.com/nedvisol/go-connectdots/graphdb"
+)
+
+const SAME_AS_LABEL = "SAME_AS"
+const REVIEW_LABEL = "MatchReview"
+
+// review statuses
+const REVIEW_PENDING = "pending"
+const REVIEW_ACCEPTED = "accepted"
+const REVIEW_REJECTED = "rejected"
+
+// match decisions
+const DECISION_LINKED = "linked"
+const DECISION_REVIEW = "review"
+const DECISION_NONE = "none"
+
+// Candidate is an entity as identified by another source. Node is the node the source wrote for it,
+// if any, which gets linked to the canonical node by a SAME_AS edge.
+type Candidate struct {
+	Label      string
+	System     string
+	ExternalId string
+	Name       string
+	State      string
+	Party      string
+	Node       *graphdb.NodeInfo
+}
+
+// Match is the best canonical entity found for a candidate, Canonical is nil when none scored
+// at least the review threshold
+type Match struct {
+	Candidate  *Candidate
+	Canonical  *graphdb.NodeInfo
+	Confidence float64
+	Method     string
+	Decision   string
+}
+
+// Resolver matches candidates to the canonical entities of the crosswalk
+type Resolver struct {
+	svc       graphdb.GraphDbService
+	crosswalk *Crosswalk
+	autoLink  float64
+	review    float64
+}
+
+// canonicalNodes returns the stored nodes of the canonical entities with the given label
+func (r *Resolver) canonicalNodes(ctx context.Context, label string) ([]*graphdb.NodeInfo, error) {
+	nodes := make([]*graphdb.NodeInfo, 0)
+	for _, entry := range r.crosswalk.Entities(label) {
+		node, err := r.svc.GetNode(ctx, &graphdb.NodeInfo{Label: entry.Label, Id: entry.Id})
+		if err != nil {
+			return nil, err
+		}
+		if node != nil {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes, nil
+}
+
+func stringAttr(attrs *map[string]interface{}, name string) string {
+	if attrs == nil {
+		return ""
+	}
+	value, _ := (*attrs)[name].(string)
+	return value
+}
+
+// nodeName is the name of a Person node, or the name attribute of other nodes
+func nodeName(node *graphdb.NodeInfo) string {
+	if name := stringAttr(node.Attrs, "name"); name != "" {
+		return name
+	}
+	return stringAttr(node.Attrs, "first") + " " + stringAttr(node.Attrs, "last")
+}
+
+func (r *Resolver) decide(match *Match) {
+	switch {
+	case match.Confidence >= r.autoLink:
+		match.Decision = DECISION_LINKED
+	case match.Confidence >= r.review:
+		match.Decision = DECISION_REVIEW
+	default:
+		match.Decision = DECISION_NONE
+		match.Canonical = nil
+	}
+}
+
+// Resolve finds the best canonical entity of every candidate, by crosswalk identifier first and
+// by name, state and party otherwise. Nothing is written.
+func (r *Resolver) Resolve(ctx context.Context, candidates ...*Candidate) ([]*Match, error) {
+	canonical := make(map[string][]*graphdb.NodeInfo)
+	matches := make([]*Match, 0, len(candidates))
+	for _, candidate := range candidates {
+		match := &Match{Candidate: candidate}
+		matches = append(matches, match)
+
+		if entry := r.crosswalk.Lookup(candidate.System, candidate.ExternalId); entry != nil {
+			match.Canonical = &graphdb.NodeInfo{Label: entry.Label, Id: entry.Id}
+			match.Confidence = 1
+			match.Method = METHOD_CROSSWALK
+			r.decide(match)
+			continue
+		}
+
+		nodes, loaded := canonical[candidate.Label]
+		if !loaded {
+			var err error
+			if nodes, err = r.canonicalNodes(ctx, candidate.Label); err != nil {
+				return nil, err
+			}
+			canonical[candidate.Label] = nodes
+		}
+		for _, node := range nodes {
+			if candidate.Node != nil && candidate.Node.Id == node.Id {
+				continue
+			}
+			confidence, method := Score(candidate, nodeName(node), stringAttr(node.Attrs, "state"), stringAttr(node.Attrs, "party"))
+			if confidence > match.Confidence {
+				match.Canonical = &graphdb.NodeInfo{Label: node.Label, Id: node.Id}
+				match.Confidence = confidence
+				match.Method = method
+			}
+		}
+		r.decide(match)
+	}
+	return matches, nil
+}
+
+// Link records the candidate identifier in the crosswalk and connects the candidate node, if any,
+// to the canonical node with a SAME_AS edge carrying the confidence of the match
+func (r *Resolver) Link(ctx context.Context, match *Match) error {
+	if match.Canonical == nil {
+		return fmt.Errorf("no canonical entity to link %s %s to", match.Candidate.System, match.Candidate.ExternalId)
+	}
+	candidate := match.Candidate
+	r.crosswalk.Add(match.Canonical.Label, match.Canonical.Id, candidate.System, candidate.ExternalId)
+	if candidate.Node == nil || candidate.Node.Id == match.Canonical.Id {
+		return nil
+	}
+	return r.svc.UpdateEdge(ctx, &graphdb.EdgeInfo{
+		Label: SAME_AS_LABEL,
+		Id:    candidate.Node.Id,
+		Left:  &graphdb.NodeInfo{Label: candidate.Node.Label, Id: candidate.Node.Id},
+		Right: match.Canonical,
+		Attrs: &map[string]interface{}{
+			"confidence": match.Confidence,
+			"method":     match.Method,
+			"linkedAt":   time.Now().UTC().Format(time.RFC3339),
+		},
+	}, true)
+}
+
+func reviewId(candidate *Candidate) string {
+	return fmt.Sprintf("%s:%s", candidate.System, candidate.ExternalId)
+}
+
+// queue stores match as a pending MatchReview node. A match accepted or rejected already is left as decided.
+func (r *Resolver) queue(ctx context.Context, match *Match) error {
+	candidate := match.Candidate
+	review, err := r.svc.GetNode(ctx, &graphdb.NodeInfo{Label: REVIEW_LABEL, Id: reviewId(candidate)})
+	if err != nil {
+		return err
+	}
+	if review != nil && stringAttr(review.Attrs, "status") != REVIEW_PENDING {
+		return nil
+	}
+	attrs := map[string]interface{}{
+		"status":         REVIEW_PENDING,
+		"candidateLabel": candidate.Label,
+		"system":         candidate.System,
+		"externalId":     candidate.ExternalId,
+		"name":           candidate.Name,
+		"state":          candidate.State,
+		"party":          candidate.Party,
+		"canonicalLabel": match.Canonical.Label,
+		"canonicalId":    match.Canonical.Id,
+		"confidence":     match.Confidence,
+		"method":         match.Method,
+	}
+	if candidate.Node != nil {
+		attrs["nodeLabel"] = candidate.Node.Label
+		attrs["nodeId"] = candidate.Node.Id
+	}
+	return r.svc.UpdateNode(ctx, &graphdb.NodeInfo{Label: REVIEW_LABEL, Id: reviewId(candidate), Attrs: &attrs}, true)
+}
+
+// Apply links the matches scoring the auto-link threshold and queues the uncertain ones for review
+func (r *Resolver) Apply(ctx context.Context, matches []*Match) error {
+	for _, match := range matches {
+		var err error
+		switch match.Decision {
+		case DECISION_LINKED:
+			err = r.Link(ctx, match)
+		case DECISION_REVIEW:
+			err = r.queue(ctx, match)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Merge folds duplicate into canonical: attributes canonical lacks are copied over, the edges of
+// duplicate are moved to canonical and duplicate is deleted
+func (r *Resolver) Merge(ctx context.Context, duplicate *graphdb.NodeInfo, canonical *graphdb.NodeInfo) error {
+	stored, err := r.svc.GetNode(ctx, duplicate)
+	if err != nil {
+		return err
+	}
+	if stored == nil {
+		return fmt.Errorf("%w: %s %s", graphdb.ErrNodeNotFound, duplicate.Label, duplicate.Id)
+	}
+	target, err := r.svc.GetNode(ctx, canonical)
+	if err != nil {
+		return err
+	}
+	if target == nil {
+		return fmt.Errorf("%w: %s %s", graphdb.ErrNodeNotFound, canonical.Label, canonical.Id)
+	}
+
+	missing := make(map[string]interface{})
+	for key, value := range *stored.Attrs {
+		if _, found := (*target.Attrs)[key]; !found && !strings.HasPrefix(key, "_") {
+			missing[key] = value
+		}
+	}
+	if len(missing) > 0 {
+		if err := r.svc.UpdateNode(ctx, &graphdb.NodeInfo{Label: canonical.Label, Id: canonical.Id, Attrs: &missing}, false); err != nil {
+			return err
+		}
+	}
+
+	edges, err := r.svc.FindEdges(ctx, "")
+	if err != nil {
+		return err
+	}
+	isDuplicate := func(node *graphdb.NodeInfo) bool {
+		return node.Label == duplicate.Label && node.Id == duplicate.Id
+	}
+	for _, edge := range edges {
+		if !isDuplicate(edge.Left) && !isDuplicate(edge.Right) {
+			continue
+		}
+		moved := *edge
+		if isDuplicate(edge.Left) {
+			moved.Left = canonical
+		}
+		if isDuplicate(edge.Right) {
+			moved.Right = canonical
+		}
+		// the link between the two nodes goes away with the duplicate
+		if moved.Label == SAME_AS_LABEL && moved.Left.Id == moved.Right.Id {
+			continue
+		}
+		if err := r.svc.UpdateEdge(ctx, &moved, true); err != nil {
+			return err
+		}
+	}
+
+	if err := r.svc.DeleteNode(ctx, duplicate); err != nil {
+		return err
+	}
+	r.crosswalk.Move(duplicate.Label, duplicate.Id, canonical.Id)
+	return nil
+}
+
+// PendingReviews returns the queued matches waiting for a decision
+func (r *Resolver) PendingReviews(ctx context.Context) ([]*graphdb.NodeInfo, error) {
+	reviews, err := r.svc.FindNodes(ctx, REVIEW_LABEL)
+	if err != nil {
+		return nil, err
+	}
+	pending := make([]*graphdb.NodeInfo, 0)
+	for _, review := range reviews {
+		if stringAttr(review.Attrs, "status") == REVIEW_PENDING {
+			pending = append(pending, review)
+		}
+	}
+	return pending, nil
+}
+
+func (r *Resolver) setReviewStatus(ctx context.Context, id string, status string) (*graphdb.NodeInfo, error) {
+	review, err := r.svc.GetNode(ctx, &graphdb.NodeInfo{Label: REVIEW_LABEL, Id: id})
+	if err != nil {
+		return nil, err
+	}
+	if review == nil {
+		return nil, fmt.Errorf("%w: %s %s", graphdb.ErrNodeNotFound, REVIEW_LABEL, id)
+	}
+	if current := stringAttr(review.Attrs, "status"); current != REVIEW_PENDING {
+		return nil, fmt.Errorf("review %s is %s already", id, current)
+	}
+	err = r.svc.UpdateNode(ctx, &graphdb.NodeInfo{
+		Label: REVIEW_LABEL,
+		Id:    id,
+		Attrs: &map[string]interface{}{
+			"status":    status,
+			"decidedAt": time.Now().UTC().Format(time.RFC3339),
+		},
+	}, false)
+	return review, err
+}
+
+// AcceptReview links the queued match with the given id. With merge, the candidate node is merged
+// into the canonical node instead of being kept beside it.
+func (r *Resolver) AcceptReview(ctx context.Context, id string, merge bool) error {
+	review, err := r.setReviewStatus(ctx, id, REVIEW_ACCEPTED)
+	if err != nil {
+		return err
+	}
+	attrs := review.Attrs
+	candidate := &Candidate{
+		Label:      stringAttr(attrs, "candidateLabel"),
+		System:     stringAttr(attrs, "system"),
+		ExternalId: stringAttr(attrs, "externalId"),
+		Name:       stringAttr(attrs, "name"),
+		State:      stringAttr(attrs, "state"),
+		Party:      stringAttr(attrs, "party"),
+	}
+	if nodeId := stringAttr(attrs, "nodeId"); nodeId != "" {
+		candidate.Node = &graphdb.NodeInfo{Label: stringAttr(attrs, "nodeLabel"), Id: nodeId}
+	}
+	confidence, _ := (*attrs)["confidence"].(float64)
+	canonical := &graphdb.NodeInfo{Label: stringAttr(attrs, "canonicalLabel"), Id: stringAttr(attrs, "canonicalId")}
+	err = r.Link(ctx, &Match{
+		Candidate:  candidate,
+		Canonical:  canonical,
+		Confidence: confidence,
+		Method:     stringAttr(attrs, "method"),
+		Decision:   DECISION_LINKED,
+	})
+	if err != nil || !merge || candidate.Node == nil || candidate.Node.Id == canonical.Id {
+		return err
+	}
+	return r.Merge(ctx, candidate.Node, canonical)
+}
+
+// RejectReview dismisses the queued match with the given id
+func (r *Resolver) RejectReview(ctx context.Context, id string) error {
+	_, err := r.setReviewStatus(ctx, id, REVIEW_REJECTED)
+	return err
+}
+
+func NewResolver(svc graphdb.GraphDbService, crosswalk *Crosswalk, cfg *config.Config) *Resolver {
+	return &Resolver{
+		svc:       svc,
+		crosswalk: crosswalk,
+		autoLink:  cfg.EntityRes.AutoLinkThreshold,
+		review:    cfg.EntityRes.ReviewThreshold,
+	}
+}
@@ -0,0 +1,112 @@
+package entityres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nedvisol/go-connectdots/config"
+	"github.com/nedvisol/go-connectdots/graphdb"
+)
+
+func newTestResolver(t *testing.T) (*Resolver, graphdb.GraphDbService) {
+	svc := graphdb.NewMemoryGraphService()
+	ctx := context.Background()
+	nodes := []*graphdb.NodeInfo{
+		{Label: "Person", Id: "wyden", Attrs: &map[string]interface{}{"first": "Ron", "last": "Wyden", "state": "OR", "party": "D"}},
+		{Label: "Person", Id: "fec-wyden", Attrs: &map[string]interface{}{"first": "Ronald", "last": "Wyden", "office": "S"}},
+		{Label: "Bill", Id: "hr1", Attrs: &map[string]interface{}{"title": "A bill"}},
+	}
+	for _, node := range nodes {
+		if err := svc.UpdateNode(ctx, node, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := svc.UpdateEdge(ctx, &graphdb.EdgeInfo{
+		Label: "SPONSORED",
+		Id:    "fec-wyden-hr1",
+		Left:  &graphdb.NodeInfo{Label: "Person", Id: "fec-wyden"},
+		Right: &graphdb.NodeInfo{Label: "Bill", Id: "hr1"},
+		Attrs: &map[string]interface{}{},
+	}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	crosswalk := OpenCrosswalk()
+	crosswalk.Add("Person", "wyden", SYSTEM_BIOGUIDE, "W000779")
+	crosswalk.Add("Person", "fec-wyden", SYSTEM_GOVTRACK, "300100")
+	cfg := &config.Config{EntityRes: &config.EntityResConfig{AutoLinkThreshold: 0.95, ReviewThreshold: 0.75}}
+	return NewResolver(svc, crosswalk, cfg), svc
+}
+
+// reviewMatch is the uncertain match of the FEC node to the canonical one
+func reviewMatch() *Match {
+	return &Match{
+		Candidate: &Candidate{
+			Label: "Person", System: SYSTEM_FEC, ExternalId: "S6OR00110", Name: "Ronald Wyden", State: "OR", Party: "D",
+			Node: &graphdb.NodeInfo{Label: "Person", Id: "fec-wyden"},
+		},
+		Canonical:  &graphdb.NodeInfo{Label: "Person", Id: "wyden"},
+		Confidence: 0.9,
+		Method:     METHOD_FUZZY,
+		Decision:   DECISION_REVIEW,
+	}
+}
+
+func TestQueueKeepsDecidedReviews(t *testing.T) {
+	resolver, _ := newTestResolver(t)
+	ctx := context.Background()
+	if err := resolver.Apply(ctx, []*Match{reviewMatch()}); err != nil {
+		t.Fatal(err)
+	}
+	if err := resolver.RejectReview(ctx, "fec:S6OR00110"); err != nil {
+		t.Fatal(err)
+	}
+	// the next run finds the same match
+	if err := resolver.Apply(ctx, []*Match{reviewMatch()}); err != nil {
+		t.Fatal(err)
+	}
+	pending, err := resolver.PendingReviews(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 0 {
+		t.Fatalf("rejected match queued again as %s", pending[0].Id)
+	}
+}
+
+func TestAcceptReviewMerge(t *testing.T) {
+	resolver, svc := newTestResolver(t)
+	ctx := context.Background()
+	if err := resolver.Apply(ctx, []*Match{reviewMatch()}); err != nil {
+		t.Fatal(err)
+	}
+	if err := resolver.AcceptReview(ctx, "fec:S6OR00110", true); err != nil {
+		t.Fatal(err)
+	}
+
+	if duplicate, _ := svc.GetNode(ctx, &graphdb.NodeInfo{Label: "Person", Id: "fec-wyden"}); duplicate != nil {
+		t.Error("merged node was kept")
+	}
+	canonical, _ := svc.GetNode(ctx, &graphdb.NodeInfo{Label: "Person", Id: "wyden"})
+	if (*canonical.Attrs)["first"] != "Ron" || (*canonical.Attrs)["office"] != "S" {
+		t.Errorf("canonical attributes are %v", *canonical.Attrs)
+	}
+	sponsored, _ := svc.GetEdge(ctx, &graphdb.EdgeInfo{
+		Label: "SPONSORED",
+		Id:    "fec-wyden-hr1",
+		Left:  &graphdb.NodeInfo{Label: "Person", Id: "wyden"},
+		Right: &graphdb.NodeInfo{Label: "Bill", Id: "hr1"},
+	})
+	if sponsored == nil {
+		t.Error("edge of the merged node was not moved")
+	}
+	for system, externalId := range map[string]string{SYSTEM_FEC: "S6OR00110", SYSTEM_GOVTRACK: "300100"} {
+		if entry := resolver.crosswalk.Lookup(system, externalId); entry == nil || entry.Id != "wyden" {
+			t.Errorf("%s id not moved to the canonical entity: %v", system, entry)
+		}
+	}
+	if entry := resolver.crosswalk.Get("Person", "fec-wyden"); entry != nil {
+		t.Errorf("merged entity left in the crosswalk: %v", entry)
+	}
+}
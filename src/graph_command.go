@@ -0,0 +1,271 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/nedvisol/go-connectdots/config"
+	"github.com/nedvisol/go-connectdots/entityres"
+	"github.com/nedvisol/go-connectdots/graphdb"
+	"github.com/nedvisol/go-connectdots/graphio"
+	"github.com/nedvisol/go-connectdots/util"
+	"go.uber.org/fx"
+)
+
+const GRAPH_USAGE = `usage: connectdots graph <command> [flags]
+
+commands:
+  export    write the graph or a subgraph to a file
+  import    load nodes and edges from JSON Lines or neo4j-admin CSV files
+  snapshot  write a versioned, compressed dump of the whole graph
+  restore   load a snapshot into an empty graph
+  review    list, accept or reject the uncertain entity matches
+`
+
+// runWithGraphDb runs fn with the configured GraphDbService and stops the app once fn returns
+func runWithGraphDb(fn interface{}) {
+	runGraphApp(graphdb.NewGraphDbService, fn)
+}
+
+// runWithGraphDbBackend is runWithGraphDb with the backend itself, writing the graph as is
+func runWithGraphDbBackend(fn interface{}) {
+	runGraphApp(graphdb.NewGraphDbBackend, fn)
+}
+
+func runGraphApp(newGraphDbService interface{}, fn interface{}) {
+	app := fx.New(
+		fx.NopLogger,
+		fx.Provide(
+			config.NewConfig,
+			context.Background,
+			graphdb.NewChangeStream,
+			newGraphDbService,
+			entityres.NewCrosswalk,
+			entityres.NewResolver,
+		),
+		fx.Invoke(fn),
+	)
+	if err := app.Start(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+	if err := app.Stop(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func parseDateFlag(name string, value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		log.Fatalf("invalid -%s %s, expected YYYY-MM-DD", name, value)
+	}
+	return &date
+}
+
+func splitList(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+func runGraphExport(args []string) {
+	flags := flag.NewFlagSet("graph export", flag.ExitOnError)
+	format := flags.String("format", graphio.FORMAT_JSONL, fmt.Sprintf("output format, one of %s", strings.Join(graphio.Formats, ", ")))
+	out := flags.String("out", "", "output file, or directory for neo4j-csv")
+	labels := flags.String("labels", "", "comma separated node labels to export")
+	dateAttr := flags.String("date-attr", graphio.DEFAULT_DATE_ATTR, "attribute used by -from and -to")
+	from := flags.String("from", "", "drop nodes and edges dated before YYYY-MM-DD")
+	to := flags.String("to", "", "drop nodes and edges dated after YYYY-MM-DD")
+	seedLabel := flags.String("seed-label", "", "label of the seed node of a neighborhood export")
+	seedId := flags.String("seed-id", "", "_id of the seed node of a neighborhood export")
+	hops := flags.Int("hops", 1, "neighborhood size around the seed node")
+	flags.Parse(args)
+
+	if *out == "" {
+		log.Fatal("-out is required")
+	}
+
+	filter := &graphio.Filter{
+		Labels:   splitList(*labels),
+		DateAttr: *dateAttr,
+		From:     parseDateFlag("from", *from),
+		To:       parseDateFlag("to", *to),
+		Hops:     *hops,
+	}
+	if *seedId != "" {
+		filter.Seed = &graphdb.NodeInfo{Label: *seedLabel, Id: *seedId}
+	}
+
+	runWithGraphDb(func(ctx context.Context, graphdbsvc graphdb.GraphDbService) error {
+		graph, err := graphio.LoadGraph(ctx, graphdbsvc, filter)
+		if err != nil {
+			return err
+		}
+		if err := graphio.Export(graph, *format, *out); err != nil {
+			return err
+		}
+		fmt.Printf("exported %d nodes and %d edges to %s\n", len(graph.Nodes), len(graph.Edges), *out)
+		return nil
+	})
+}
+
+const MAX_REPORTED_ERRORS = 20
+
+func runGraphImport(args []string) {
+	flags := flag.NewFlagSet("graph import", flag.ExitOnError)
+	format := flags.String("format", graphio.FORMAT_JSONL, fmt.Sprintf("input format, %s or %s", graphio.FORMAT_JSONL, graphio.FORMAT_NEO4J_CSV))
+	in := flags.String("in", "", "input file, or directory for neo4j-csv")
+	batchSize := flags.Int("batch-size", graphio.DEFAULT_BATCH_SIZE, "nodes or edges written per transaction")
+	flags.Parse(args)
+
+	if *in == "" {
+		log.Fatal("-in is required")
+	}
+
+	runWithGraphDb(func(ctx context.Context, graphdbsvc graphdb.GraphDbService, changes *graphdb.ChangeStream) error {
+		importer := graphio.NewImporter(graphdbsvc, *batchSize, func(stats *graphio.ImportStats) {
+			fmt.Printf("imported %d nodes, %d edges, %d failed\n", stats.Nodes, stats.Edges, stats.Failed)
+		})
+		stats, err := importer.Import(ctx, *format, *in)
+		if err != nil {
+			return err
+		}
+		for i, importErr := range stats.Errors {
+			if i == MAX_REPORTED_ERRORS {
+				fmt.Printf("... and %d more errors\n", len(stats.Errors)-MAX_REPORTED_ERRORS)
+				break
+			}
+			fmt.Printf("error: %s\n", importErr)
+		}
+		fmt.Printf("import of %s done: %d nodes, %d edges, %d failed\n", *in, stats.Nodes, stats.Edges, stats.Failed)
+		changes.PrintSummary()
+		if stats.Failed > 0 {
+			return fmt.Errorf("%d records failed to import", stats.Failed)
+		}
+		return nil
+	})
+}
+
+func runGraphSnapshot(args []string) {
+	flags := flag.NewFlagSet("graph snapshot", flag.ExitOnError)
+	out := flags.String("out", "", "snapshot file, e.g. graph.snapshot.jsonl.gz")
+	flags.Parse(args)
+
+	if *out == "" {
+		log.Fatal("-out is required")
+	}
+
+	runWithGraphDbBackend(func(ctx context.Context, graphdbsvc graphdb.GraphDbService, config *config.Config) error {
+		header, err := graphio.SaveSnapshot(ctx, graphdbsvc, config.RunId, *out)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("snapshot %s of run %s: %d nodes, %d edges at migration version %d\n",
+			*out, header.RunId, header.Nodes, header.Edges, header.MigrationVersion)
+		return nil
+	})
+}
+
+func runGraphRestore(args []string) {
+	flags := flag.NewFlagSet("graph restore", flag.ExitOnError)
+	in := flags.String("in", "", "snapshot file written by graph snapshot")
+	batchSize := flags.Int("batch-size", graphio.DEFAULT_BATCH_SIZE, "nodes or edges written per transaction")
+	flags.Parse(args)
+
+	if *in == "" {
+		log.Fatal("-in is required")
+	}
+
+	runWithGraphDbBackend(func(ctx context.Context, graphdbsvc graphdb.GraphDbService) error {
+		file, err := os.Open(*in)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		header, stats, err := graphio.RestoreSnapshot(ctx, graphdbsvc, file, *batchSize, func(stats *graphio.ImportStats) {
+			fmt.Printf("restored %d nodes, %d edges, %d failed\n", stats.Nodes, stats.Edges, stats.Failed)
+		})
+		if stats != nil {
+			for i, restoreErr := range stats.Errors {
+				if i == MAX_REPORTED_ERRORS {
+					fmt.Printf("... and %d more errors\n", len(stats.Errors)-MAX_REPORTED_ERRORS)
+					break
+				}
+				fmt.Printf("error: %s\n", restoreErr)
+			}
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("restored snapshot of run %s taken %s: %d nodes, %d edges at migration version %d\n",
+			header.RunId, header.CreatedAt, stats.Nodes, stats.Edges, header.MigrationVersion)
+		return nil
+	})
+}
+
+func runGraphReview(args []string) {
+	flags := flag.NewFlagSet("graph review", flag.ExitOnError)
+	accept := flags.String("accept", "", "id of the match to link")
+	reject := flags.String("reject", "", "id of the match to dismiss")
+	merge := flags.Bool("merge", false, "with -accept, merge the matched node into the canonical node instead of linking it")
+	flags.Parse(args)
+
+	runWithGraphDb(func(ctx context.Context, resolver *entityres.Resolver) error {
+		switch {
+		case *accept != "":
+			if err := resolver.AcceptReview(ctx, *accept, *merge); err != nil {
+				return err
+			}
+			fmt.Printf("%s %s\n", util.Ternary(*merge, "merged", "linked"), *accept)
+		case *reject != "":
+			if err := resolver.RejectReview(ctx, *reject); err != nil {
+				return err
+			}
+			fmt.Printf("rejected %s\n", *reject)
+		default:
+			reviews, err := resolver.PendingReviews(ctx)
+			if err != nil {
+				return err
+			}
+			for _, review := range reviews {
+				attrs := *review.Attrs
+				fmt.Printf("%s\t%s (%v %v) -> %v %v\tconfidence %.2f by %v\n", review.Id,
+					attrs["name"], attrs["state"], attrs["party"], attrs["canonicalLabel"], attrs["canonicalId"], attrs["confidence"], attrs["method"])
+			}
+			fmt.Printf("%d matches to review\n", len(reviews))
+		}
+		return nil
+	})
+}
+
+func runGraphCommand(args []string) {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, GRAPH_USAGE)
+		os.Exit(2)
+	}
+
+	switch args[0] {
+	case "export":
+		runGraphExport(args[1:])
+	case "import":
+		runGraphImport(args[1:])
+	case "snapshot":
+		runGraphSnapshot(args[1:])
+	case "restore":
+		runGraphRestore(args[1:])
+	case "review":
+		runGraphReview(args[1:])
+	default:
+		fmt.Fprint(os.Stderr, GRAPH_USAGE)
+		os.Exit(2)
+	}
+}
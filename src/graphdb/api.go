@@ -1,22 +1,74 @@
 package graphdb
 
+import "context"
+
 type NodeInfo struct {
-	Label string
-	Id    string
-	Attrs *map[string]interface{}
+	Label      string
+	Id         string
+	Attrs      *map[string]interface{}
+	Provenance *Provenance // stamped on the node by ProvenanceGraphService, not stored as is
 }
 
 type EdgeInfo struct {
-	Label string
-	Id    string
-	Attrs *map[string]interface{}
-	Left  *NodeInfo
-	Right *NodeInfo
+	Label      string
+	Id         string
+	Attrs      *map[string]interface{}
+	Left       *NodeInfo
+	Right      *NodeInfo
+	Provenance *Provenance
 }
 
+// GraphDbService is implemented by every backend with the same errors: UpdateNode fails with ErrNodeNotFound
+// when the node does not exist and allowUpsert is false, UpdateEdge fails with ErrNodeNotFound when an
+// endpoint does not exist, even with allowUpsert, and with ErrEdgeNotFound when the edge does not exist
+// and allowUpsert is false. Endpoints are never created by UpdateEdge.
 type GraphDbService interface {
-	CreateNode(node *NodeInfo) error
-	UpdateNode(node *NodeInfo, allowUpsert bool) error
-	DeleteNode(node *NodeInfo) error
-	UpdateEdge(edge *EdgeInfo, allowUpsert bool) error
+	CreateNode(ctx context.Context, node *NodeInfo) error
+	UpdateNode(ctx context.Context, node *NodeInfo, allowUpsert bool) error
+	DeleteNode(ctx context.Context, node *NodeInfo) error
+	UpdateEdge(ctx context.Context, edge *EdgeInfo, allowUpsert bool) error
+
+	// UpdateNodes and UpdateEdges are the batched forms of UpdateNode and UpdateEdge,
+	// a batch is written atomically so either all of it or none of it is stored
+	UpdateNodes(ctx context.Context, nodes []*NodeInfo, allowUpsert bool) error
+	UpdateEdges(ctx context.Context, edges []*EdgeInfo, allowUpsert bool) error
+
+	// GetNode returns the stored node with the label and id of node, or nil if there is none
+	GetNode(ctx context.Context, node *NodeInfo) (*NodeInfo, error)
+	// GetEdge returns the stored edge matching the label, id and endpoints of edge, or nil if there is none
+	GetEdge(ctx context.Context, edge *EdgeInfo) (*EdgeInfo, error)
+	// FindNodes returns all nodes with the given label, or every node when label is empty
+	FindNodes(ctx context.Context, label string) ([]*NodeInfo, error)
+	// FindEdges returns all edges with the given label, or every edge when label is empty
+	FindEdges(ctx context.Context, label string) ([]*EdgeInfo, error)
+
+	// ApplySchema creates the constraints and indexes declared in schema if they don't exist yet,
+	// and fails with ErrSchemaViolation if the stored graph does not satisfy them
+	ApplySchema(ctx context.Context, schema *Schema) error
+
+	// Ping checks that the backend is reachable
+	Ping(ctx context.Context) error
+}
+
+// GraphReader is implemented by backends which read every node and edge in one read transaction,
+// so that no edge is read without its nodes
+type GraphReader interface {
+	ReadGraph(ctx context.Context) ([]*NodeInfo, []*EdgeInfo, error)
+}
+
+// ReadGraph reads every node and edge of svc in one read transaction when its backend is a GraphReader,
+// with FindNodes and FindEdges otherwise
+func ReadGraph(ctx context.Context, svc GraphDbService) ([]*NodeInfo, []*EdgeInfo, error) {
+	if reader, ok := Unwrapped(svc).(GraphReader); ok {
+		return reader.ReadGraph(ctx)
+	}
+	nodes, err := svc.FindNodes(ctx, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	edges, err := svc.FindEdges(ctx, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	return nodes, edges, nil
 }
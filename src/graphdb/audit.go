@@ -0,0 +1,168 @@
+package graphdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AuditEntry is one logged write, Cypher and Params are what the neo4j backend runs for it
+// whichever backend is configured
+type AuditEntry struct {
+	Time        string                 `json:"time"`
+	RunId       string                 `json:"runId,omitempty"`
+	DryRun      bool                   `json:"dryRun"`
+	Op          string                 `json:"op"`
+	Label       string                 `json:"label,omitempty"`
+	Id          string                 `json:"id,omitempty"`
+	AllowUpsert bool                   `json:"allowUpsert,omitempty"`
+	Cypher      string                 `json:"cypher,omitempty"`
+	Params      map[string]interface{} `json:"params,omitempty"`
+	Error       string                 `json:"error,omitempty"`
+}
+
+// AuditGraphService is a GraphDbService decorator logging every write as a JSON Lines AuditEntry.
+// With dryRun set the writes are only logged, reads still go to the decorated service.
+type AuditGraphService struct {
+	GraphDbService
+	dryRun bool
+	runId  string
+
+	mu  sync.Mutex
+	out io.Writer
+}
+
+// compactCypher puts a query on a single line so audit logs diff line by line
+func compactCypher(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
+
+func (a *AuditGraphService) log(entry *AuditEntry, err error) {
+	entry.Time = time.Now().UTC().Format(time.RFC3339Nano)
+	entry.RunId = a.runId
+	entry.DryRun = a.dryRun
+	entry.Cypher = compactCypher(entry.Cypher)
+	if err != nil {
+		entry.Error = err.Error()
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	encoder := json.NewEncoder(a.out)
+	encoder.SetEscapeHTML(false)
+	if encodeErr := encoder.Encode(entry); encodeErr != nil {
+		fmt.Printf("unable to log %s %s %s: %s\n", entry.Op, entry.Label, entry.Id, encodeErr)
+	}
+}
+
+// write runs fn unless in dry-run mode and logs entry with its outcome
+func (a *AuditGraphService) write(entry *AuditEntry, fn func() error) error {
+	var err error
+	if !a.dryRun {
+		err = fn()
+	}
+	a.log(entry, err)
+	return err
+}
+
+func nodeEntry(op string, node *NodeInfo, allowUpsert bool) *AuditEntry {
+	query, params := updateNodeQuery(node, allowUpsert)
+	return &AuditEntry{Op: op, Label: node.Label, Id: node.Id, AllowUpsert: allowUpsert, Cypher: query, Params: params}
+}
+
+func edgeEntry(op string, edge *EdgeInfo, allowUpsert bool) *AuditEntry {
+	query, params := updateEdgeQuery(edge, allowUpsert)
+	return &AuditEntry{Op: op, Label: edge.Label, Id: edge.Id, AllowUpsert: allowUpsert, Cypher: query, Params: params}
+}
+
+// CreateNode implements GraphDbService.
+func (a *AuditGraphService) CreateNode(ctx context.Context, node *NodeInfo) error {
+	query, params := createNodeQuery(node)
+	entry := &AuditEntry{Op: "CreateNode", Label: node.Label, Id: node.Id, Cypher: query, Params: params}
+	return a.write(entry, func() error {
+		return a.GraphDbService.CreateNode(ctx, node)
+	})
+}
+
+// DeleteNode implements GraphDbService.
+func (a *AuditGraphService) DeleteNode(ctx context.Context, node *NodeInfo) error {
+	query, params := deleteNodeQuery(node)
+	entry := &AuditEntry{Op: "DeleteNode", Label: node.Label, Id: node.Id, Cypher: query, Params: params}
+	return a.write(entry, func() error {
+		return a.GraphDbService.DeleteNode(ctx, node)
+	})
+}
+
+// UpdateNode implements GraphDbService.
+func (a *AuditGraphService) UpdateNode(ctx context.Context, node *NodeInfo, allowUpsert bool) error {
+	return a.write(nodeEntry("UpdateNode", node, allowUpsert), func() error {
+		return a.GraphDbService.UpdateNode(ctx, node, allowUpsert)
+	})
+}
+
+// UpdateNodes implements GraphDbService. Every node of the batch is logged with the outcome of the batch.
+func (a *AuditGraphService) UpdateNodes(ctx context.Context, nodes []*NodeInfo, allowUpsert bool) error {
+	var err error
+	if !a.dryRun {
+		err = a.GraphDbService.UpdateNodes(ctx, nodes, allowUpsert)
+	}
+	for _, node := range nodes {
+		a.log(nodeEntry("UpdateNodes", node, allowUpsert), err)
+	}
+	return err
+}
+
+// UpdateEdge implements GraphDbService.
+func (a *AuditGraphService) UpdateEdge(ctx context.Context, edge *EdgeInfo, allowUpsert bool) error {
+	return a.write(edgeEntry("UpdateEdge", edge, allowUpsert), func() error {
+		return a.GraphDbService.UpdateEdge(ctx, edge, allowUpsert)
+	})
+}
+
+// UpdateEdges implements GraphDbService. Every edge of the batch is logged with the outcome of the batch.
+func (a *AuditGraphService) UpdateEdges(ctx context.Context, edges []*EdgeInfo, allowUpsert bool) error {
+	var err error
+	if !a.dryRun {
+		err = a.GraphDbService.UpdateEdges(ctx, edges, allowUpsert)
+	}
+	for _, edge := range edges {
+		a.log(edgeEntry("UpdateEdges", edge, allowUpsert), err)
+	}
+	return err
+}
+
+// ApplySchema implements GraphDbService. In dry-run mode the schema is neither applied nor validated.
+func (a *AuditGraphService) ApplySchema(ctx context.Context, schema *Schema) error {
+	return a.write(&AuditEntry{Op: "ApplySchema"}, func() error {
+		return a.GraphDbService.ApplySchema(ctx, schema)
+	})
+}
+
+// ExecuteCypher implements CypherExecutor when the decorated service does.
+func (a *AuditGraphService) ExecuteCypher(ctx context.Context, query string, params map[string]interface{}) error {
+	executor, ok := a.GraphDbService.(CypherExecutor)
+	if !ok {
+		return fmt.Errorf("graph db backend can't execute Cypher")
+	}
+	return a.write(&AuditEntry{Op: "ExecuteCypher", Cypher: query, Params: params}, func() error {
+		return executor.ExecuteCypher(ctx, query, params)
+	})
+}
+
+// ReadGraph implements GraphReader when the decorated service does.
+func (a *AuditGraphService) ReadGraph(ctx context.Context) ([]*NodeInfo, []*EdgeInfo, error) {
+	return ReadGraph(ctx, a.GraphDbService)
+}
+
+func NewAuditGraphService(svc GraphDbService, out io.Writer, dryRun bool, runId string) *AuditGraphService {
+	return &AuditGraphService{
+		GraphDbService: svc,
+		dryRun:         dryRun,
+		runId:          runId,
+		out:            out,
+	}
+}
@@ -0,0 +1,104 @@
+package graphdb
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nedvisol/go-connectdots/config"
+	"go.uber.org/fx/fxtest"
+)
+
+func senator(id string) *NodeInfo {
+	return &NodeInfo{Label: "Person", Id: id, Attrs: &map[string]interface{}{"first": id}}
+}
+
+func TestWithWriteMode(t *testing.T) {
+	tests := []struct {
+		mode        string
+		wantWritten bool
+	}{
+		{config.WRITE_MODE_DRYRUN, false},
+		{config.WRITE_MODE_AUDIT, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.mode, func(t *testing.T) {
+			ctx := context.Background()
+			logPath := filepath.Join(t.TempDir(), "audit.jsonl")
+			cfg := &config.Config{RunId: "run-1", GraphDb: &config.GraphDbConfig{WriteMode: test.mode, AuditLogPath: logPath}}
+			backend := NewMemoryGraphService()
+			if err := backend.UpdateNode(ctx, senator("W000779"), true); err != nil {
+				t.Fatal(err)
+			}
+			lifecycle := fxtest.NewLifecycle(t)
+			svc, err := withWriteMode(lifecycle, cfg, backend)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			wyden := &NodeInfo{Label: "Person", Id: "W000779", Attrs: &map[string]interface{}{"first": "Ron", "last": "Wyden"}}
+			ops := []func() error{
+				func() error { return svc.CreateNode(ctx, senator("M001176")) },
+				func() error { return svc.UpdateNode(ctx, wyden, false) },
+				func() error { return svc.UpdateNodes(ctx, []*NodeInfo{senator("S000033"), senator("W000437")}, true) },
+				func() error {
+					return svc.UpdateEdge(ctx, &EdgeInfo{Label: "CAST_VOTE", Id: "W000779-W000437", Left: senator("W000779"), Right: senator("W000437")}, true)
+				},
+				func() error { return svc.DeleteNode(ctx, senator("W000779")) },
+			}
+			for _, op := range ops {
+				if err := op(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			lifecycle.RequireStart().RequireStop()
+
+			people, err := backend.FindNodes(ctx, "Person")
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantPeople := map[bool]int{true: 3, false: 1}[test.wantWritten]
+			if len(people) != wantPeople {
+				t.Fatalf("backend has %d people, want %d", len(people), wantPeople)
+			}
+			stored, err := backend.GetNode(ctx, &NodeInfo{Label: "Person", Id: "W000779"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.wantWritten == (stored != nil) {
+				t.Fatalf("W000779 stored as %v after the delete", stored)
+			}
+			if !test.wantWritten && (*stored.Attrs)["last"] != nil {
+				t.Fatalf("dry run updated W000779 to %v", *stored.Attrs)
+			}
+
+			file, err := os.Open(logPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+			wantOps := []string{"CreateNode", "UpdateNode", "UpdateNodes", "UpdateNodes", "UpdateEdge", "DeleteNode"}
+			scanner := bufio.NewScanner(file)
+			for i := 0; scanner.Scan(); i++ {
+				var entry AuditEntry
+				if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+					t.Fatal(err)
+				}
+				if i >= len(wantOps) || entry.Op != wantOps[i] {
+					t.Fatalf("entry %d logs %s, want %v", i, entry.Op, wantOps)
+				}
+				if entry.DryRun == test.wantWritten || entry.RunId != "run-1" || entry.Cypher == "" || entry.Error != "" {
+					t.Fatalf("unexpected entry %+v", entry)
+				}
+				wantOps[i] = ""
+			}
+			if wantOps[len(wantOps)-1] != "" {
+				t.Fatalf("audit log misses entries, want %v", wantOps)
+			}
+		})
+	}
+}
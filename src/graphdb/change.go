@@ -0,0 +1,322 @@
+package graphdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nedvisol/go-connectdots/config"
+	"go.uber.org/fx"
+)
+
+const CHANGE_CREATED = "created"
+const CHANGE_MODIFIED = "modified"
+const CHANGE_UNCHANGED = "unchanged"
+const CHANGE_DELETED = "deleted"
+
+const ENTITY_NODE = "node"
+const ENTITY_EDGE = "edge"
+
+// CHANGE_BUFFER is the channel size of a subscriber, changes are dropped for subscribers falling behind
+const CHANGE_BUFFER = 1000
+
+type ChangeRef struct {
+	Label string `json:"label"`
+	Id    string `json:"id"`
+}
+
+// Change is the outcome of a write, Changed lists the attributes a modification set to a new value.
+// System attributes, prefixed with _, are not compared. Updates which match nothing publish no change.
+type Change struct {
+	Time    string     `json:"time"`
+	RunId   string     `json:"runId,omitempty"`
+	Entity  string     `json:"entity"`
+	Change  string     `json:"change"`
+	Label   string     `json:"label"`
+	Id      string     `json:"id"`
+	Left    *ChangeRef `json:"left,omitempty"`
+	Right   *ChangeRef `json:"right,omitempty"`
+	Changed []string   `json:"changed,omitempty"`
+}
+
+// ChangeStream publishes changes to a JSON Lines sink and to in-process subscribers,
+// and counts them for the run summary
+type ChangeStream struct {
+	mu          sync.Mutex
+	runId       string
+	sink        io.Writer
+	subscribers []chan *Change
+	counts      map[string]map[string]int
+}
+
+// Subscribe returns a channel receiving every change published from now on, closed by Close
+func (s *ChangeStream) Subscribe() <-chan *Change {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ch := make(chan *Change, CHANGE_BUFFER)
+	s.subscribers = append(s.subscribers, ch)
+	return ch
+}
+
+func (s *ChangeStream) Publish(change *Change) {
+	change.Time = time.Now().UTC().Format(time.RFC3339Nano)
+	change.RunId = s.runId
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.counts[change.Label] == nil {
+		s.counts[change.Label] = make(map[string]int)
+	}
+	s.counts[change.Label][change.Change]++
+
+	if s.sink != nil {
+		if err := json.NewEncoder(s.sink).Encode(change); err != nil {
+			fmt.Printf("unable to log change of %s %s: %s\n", change.Label, change.Id, err)
+		}
+	}
+	for _, ch := range s.subscribers {
+		select {
+		case ch <- change:
+		default:
+			fmt.Printf("change subscriber is full, dropped %s %s %s\n", change.Change, change.Label, change.Id)
+		}
+	}
+}
+
+// Summary returns the number of changes published per label and kind of change
+func (s *ChangeStream) Summary() map[string]map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	summary := make(map[string]map[string]int)
+	for label, counts := range s.counts {
+		summary[label] = make(map[string]int)
+		for change, count := range counts {
+			summary[label][change] = count
+		}
+	}
+	return summary
+}
+
+// PrintSummary prints the changes of the run per label
+func (s *ChangeStream) PrintSummary() {
+	summary := s.Summary()
+	labels := make([]string, 0, len(summary))
+	for label := range summary {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	for _, label := range labels {
+		counts := summary[label]
+		fmt.Printf("%s: %d created, %d modified, %d unchanged, %d deleted\n", label,
+			counts[CHANGE_CREATED], counts[CHANGE_MODIFIED], counts[CHANGE_UNCHANGED], counts[CHANGE_DELETED])
+	}
+}
+
+// Close closes the subscriber channels
+func (s *ChangeStream) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, ch := range s.subscribers {
+		close(ch)
+	}
+	s.subscribers = nil
+}
+
+// OpenChangeStream creates a ChangeStream writing to sink, which may be nil
+func OpenChangeStream(sink io.Writer, runId string) *ChangeStream {
+	return &ChangeStream{
+		runId:       runId,
+		sink:        sink,
+		subscribers: make([]chan *Change, 0),
+		counts:      make(map[string]map[string]int),
+	}
+}
+
+// NewChangeStream creates the ChangeStream of the app, logging to config.GraphDb.ChangeLogPath if set
+func NewChangeStream(lifecycle fx.Lifecycle, cfg *config.Config) (*ChangeStream, error) {
+	stream := OpenChangeStream(nil, cfg.RunId)
+	var file *os.File
+	if cfg.GraphDb.ChangeLogPath != "" {
+		var err error
+		if file, err = os.OpenFile(cfg.GraphDb.ChangeLogPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644); err != nil {
+			return nil, err
+		}
+		stream.sink = file
+	}
+
+	lifecycle.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			stream.Close()
+			if file != nil {
+				return file.Close()
+			}
+			return nil
+		},
+	})
+	return stream, nil
+}
+
+// changedAttrs returns the names of the attributes of attrs which differ from existing, sorted
+func changedAttrs(attrs *map[string]interface{}, existing *map[string]interface{}) []string {
+	changed := make([]string, 0)
+	if attrs == nil {
+		return changed
+	}
+	for key, value := range *attrs {
+		if isSystemAttr(key) {
+			continue
+		}
+		if current, found := (*existing)[key]; !found || !sameValue(current, value) {
+			changed = append(changed, key)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// ChangeGraphService is a GraphDbService decorator publishing the outcome of every write to a ChangeStream.
+// The stored entity is read before writing to tell creations, modifications and unchanged writes apart,
+// concurrent writes of a same entity must go through a SerializedGraphService for the changes to be right.
+type ChangeGraphService struct {
+	GraphDbService
+	stream *ChangeStream
+}
+
+func (c *ChangeGraphService) nodeChange(ctx context.Context, node *NodeInfo, allowUpsert bool) (*Change, error) {
+	existing, err := storedNode(ctx, c.GraphDbService, node)
+	if err != nil || (existing == nil && !allowUpsert) {
+		return nil, err
+	}
+	change := &Change{Entity: ENTITY_NODE, Change: CHANGE_CREATED, Label: node.Label, Id: node.Id}
+	if existing != nil {
+		change.Changed = changedAttrs(node.Attrs, existing.Attrs)
+		change.Change = CHANGE_MODIFIED
+		if len(change.Changed) == 0 {
+			change.Change = CHANGE_UNCHANGED
+		}
+	}
+	return change, nil
+}
+
+func (c *ChangeGraphService) edgeChange(ctx context.Context, edge *EdgeInfo, allowUpsert bool) (*Change, error) {
+	existing, err := storedEdge(ctx, c.GraphDbService, edge)
+	if err != nil || (existing == nil && !allowUpsert) {
+		return nil, err
+	}
+	change := &Change{
+		Entity: ENTITY_EDGE,
+		Change: CHANGE_CREATED,
+		Label:  edge.Label,
+		Id:     edge.Id,
+		Left:   &ChangeRef{Label: edge.Left.Label, Id: edge.Left.Id},
+		Right:  &ChangeRef{Label: edge.Right.Label, Id: edge.Right.Id},
+	}
+	if existing != nil {
+		change.Changed = changedAttrs(edge.Attrs, existing.Attrs)
+		change.Change = CHANGE_MODIFIED
+		if len(change.Changed) == 0 {
+			change.Change = CHANGE_UNCHANGED
+		}
+	}
+	return change, nil
+}
+
+// publish skips the nil changes of writes which matched nothing
+func (c *ChangeGraphService) publish(changes []*Change) {
+	for _, change := range changes {
+		if change != nil {
+			c.stream.Publish(change)
+		}
+	}
+}
+
+// CreateNode implements GraphDbService.
+func (c *ChangeGraphService) CreateNode(ctx context.Context, node *NodeInfo) error {
+	if err := c.GraphDbService.CreateNode(ctx, node); err != nil {
+		return err
+	}
+	c.stream.Publish(&Change{Entity: ENTITY_NODE, Change: CHANGE_CREATED, Label: node.Label, Id: node.Id})
+	return nil
+}
+
+// DeleteNode implements GraphDbService.
+func (c *ChangeGraphService) DeleteNode(ctx context.Context, node *NodeInfo) error {
+	if err := c.GraphDbService.DeleteNode(ctx, node); err != nil {
+		return err
+	}
+	c.stream.Publish(&Change{Entity: ENTITY_NODE, Change: CHANGE_DELETED, Label: node.Label, Id: node.Id})
+	return nil
+}
+
+// UpdateNode implements GraphDbService.
+func (c *ChangeGraphService) UpdateNode(ctx context.Context, node *NodeInfo, allowUpsert bool) error {
+	return c.UpdateNodes(ctx, []*NodeInfo{node}, allowUpsert)
+}
+
+// UpdateNodes implements GraphDbService.
+func (c *ChangeGraphService) UpdateNodes(ctx context.Context, nodes []*NodeInfo, allowUpsert bool) error {
+	changes := make([]*Change, 0, len(nodes))
+	for _, node := range nodes {
+		change, err := c.nodeChange(ctx, node, allowUpsert)
+		if err != nil {
+			return err
+		}
+		changes = append(changes, change)
+	}
+	var err error
+	if len(nodes) == 1 {
+		err = c.GraphDbService.UpdateNode(ctx, nodes[0], allowUpsert)
+	} else {
+		err = c.GraphDbService.UpdateNodes(ctx, nodes, allowUpsert)
+	}
+	if err != nil {
+		return err
+	}
+	c.publish(changes)
+	return nil
+}
+
+// UpdateEdge implements GraphDbService.
+func (c *ChangeGraphService) UpdateEdge(ctx context.Context, edge *EdgeInfo, allowUpsert bool) error {
+	return c.UpdateEdges(ctx, []*EdgeInfo{edge}, allowUpsert)
+}
+
+// UpdateEdges implements GraphDbService.
+func (c *ChangeGraphService) UpdateEdges(ctx context.Context, edges []*EdgeInfo, allowUpsert bool) error {
+	changes := make([]*Change, 0, len(edges))
+	for _, edge := range edges {
+		change, err := c.edgeChange(ctx, edge, allowUpsert)
+		if err != nil {
+			return err
+		}
+		changes = append(changes, change)
+	}
+	var err error
+	if len(edges) == 1 {
+		err = c.GraphDbService.UpdateEdge(ctx, edges[0], allowUpsert)
+	} else {
+		err = c.GraphDbService.UpdateEdges(ctx, edges, allowUpsert)
+	}
+	if err != nil {
+		return err
+	}
+	c.publish(changes)
+	return nil
+}
+
+// Unwrap returns the decorated service
+func (c *ChangeGraphService) Unwrap() GraphDbService {
+	return c.GraphDbService
+}
+
+func NewChangeGraphService(svc GraphDbService, stream *ChangeStream) *ChangeGraphService {
+	return &ChangeGraphService{
+		GraphDbService: svc,
+		stream:         stream,
+	}
+}
@@ -0,0 +1,219 @@
+package graphdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// contractBackends opens an empty graph of every embedded backend, each must behave as documented on GraphDbService
+var contractBackends = map[string]func(t *testing.T) GraphDbService{
+	"memory": func(t *testing.T) GraphDbService {
+		return NewMemoryGraphService()
+	},
+	"sqlite": func(t *testing.T) GraphDbService {
+		svc, err := OpenSqliteGraphService(filepath.Join(t.TempDir(), "graph.db"), 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { svc.db.Close() })
+		return svc
+	},
+}
+
+func person(id string) *NodeInfo {
+	return &NodeInfo{Label: "Person", Id: id, Attrs: &map[string]interface{}{"first": id}}
+}
+
+func voted(left string, right string) *EdgeInfo {
+	return &EdgeInfo{
+		Label: "CAST_VOTE",
+		Id:    left + "-" + right,
+		Left:  &NodeInfo{Label: "Person", Id: left},
+		Right: &NodeInfo{Label: "Person", Id: right},
+		Attrs: &map[string]interface{}{"position": "Yea"},
+	}
+}
+
+func TestBackendContract(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(ctx context.Context, svc GraphDbService) error
+		want  error
+	}{
+		{"create existing node", func(ctx context.Context, svc GraphDbService) error {
+			return svc.CreateNode(ctx, person("a"))
+		}, ErrNodeExists},
+		{"update missing node", func(ctx context.Context, svc GraphDbService) error {
+			return svc.UpdateNode(ctx, person("missing"), false)
+		}, ErrNodeNotFound},
+		{"upsert missing node", func(ctx context.Context, svc GraphDbService) error {
+			return svc.UpdateNode(ctx, person("c"), true)
+		}, nil},
+		{"update missing nodes", func(ctx context.Context, svc GraphDbService) error {
+			return svc.UpdateNodes(ctx, []*NodeInfo{person("a"), person("missing")}, false)
+		}, ErrNodeNotFound},
+		{"delete missing node", func(ctx context.Context, svc GraphDbService) error {
+			return svc.DeleteNode(ctx, person("missing"))
+		}, ErrNodeNotFound},
+		{"upsert edge from missing node", func(ctx context.Context, svc GraphDbService) error {
+			return svc.UpdateEdge(ctx, voted("missing", "a"), true)
+		}, ErrNodeNotFound},
+		{"upsert edge to missing node", func(ctx context.Context, svc GraphDbService) error {
+			return svc.UpdateEdge(ctx, voted("a", "missing"), true)
+		}, ErrNodeNotFound},
+		{"update missing edge", func(ctx context.Context, svc GraphDbService) error {
+			return svc.UpdateEdge(ctx, voted("b", "a"), false)
+		}, ErrEdgeNotFound},
+		{"upsert edge", func(ctx context.Context, svc GraphDbService) error {
+			return svc.UpdateEdge(ctx, voted("b", "a"), true)
+		}, nil},
+		{"upsert edges with a missing node", func(ctx context.Context, svc GraphDbService) error {
+			return svc.UpdateEdges(ctx, []*EdgeInfo{voted("b", "a"), voted("b", "missing")}, true)
+		}, ErrNodeNotFound},
+	}
+
+	for name, open := range contractBackends {
+		for _, test := range tests {
+			t.Run(name+"/"+test.name, func(t *testing.T) {
+				ctx := context.Background()
+				svc := open(t)
+				for _, id := range []string{"a", "b"} {
+					if err := svc.UpdateNode(ctx, person(id), true); err != nil {
+						t.Fatal(err)
+					}
+				}
+				err := test.write(ctx, svc)
+				if test.want == nil && err != nil {
+					t.Fatalf("got %v, want no error", err)
+				}
+				if test.want != nil && !errors.Is(err, test.want) {
+					t.Fatalf("got %v, want %v", err, test.want)
+				}
+			})
+		}
+	}
+}
+
+// TestBackendContractAtomicBatch checks a failed batch writes nothing
+func TestBackendContractAtomicBatch(t *testing.T) {
+	for name, open := range contractBackends {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			svc := open(t)
+			for _, id := range []string{"a", "b"} {
+				if err := svc.UpdateNode(ctx, person(id), true); err != nil {
+					t.Fatal(err)
+				}
+			}
+			err := svc.UpdateEdges(ctx, []*EdgeInfo{voted("a", "b"), voted("a", "missing")}, true)
+			if !errors.Is(err, ErrNodeNotFound) {
+				t.Fatalf("got %v, want %v", err, ErrNodeNotFound)
+			}
+			edge, err := svc.GetEdge(ctx, voted("a", "b"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if edge != nil {
+				t.Fatalf("edge of the failed batch was written")
+			}
+		})
+	}
+}
+
+// TestNeo4jUpdateQueriesCountWrites checks the neo4j updates return the count of what they wrote,
+// which tells a MATCH on a missing node from a write
+func TestNeo4jUpdateQueriesCountWrites(t *testing.T) {
+	for _, allowUpsert := range []bool{true, false} {
+		nodeQuery, _ := updateNodeQuery(person("a"), allowUpsert)
+		edgeQuery, _ := updateEdgeQuery(voted("a", "b"), allowUpsert)
+		for _, query := range []string{nodeQuery, edgeQuery} {
+			if !strings.Contains(query, "RETURN count(*) AS written") {
+				t.Errorf("query does not count what it writes: %s", query)
+			}
+		}
+	}
+}
+
+func TestNeo4jCreateNodeReportsExisting(t *testing.T) {
+	query, _ := createNodeQuery(person("a"))
+	if !strings.Contains(query, "WHERE existing IS NULL") || !strings.Contains(query, "RETURN count(*) AS written") {
+		t.Errorf("query does not skip an existing node: %s", query)
+	}
+
+	created := &neo4j.Record{Keys: []string{"written"}, Values: []any{int64(1)}}
+	found := &neo4j.Record{Keys: []string{"written"}, Values: []any{int64(0)}}
+	failed := errors.New("connection reset")
+	tests := []struct {
+		name    string
+		records []*neo4j.Record
+		err     error
+		want    error
+	}{
+		{"created", []*neo4j.Record{created}, nil, nil},
+		{"existing node", []*neo4j.Record{found}, nil, ErrNodeExists},
+		{"created concurrently", nil, &neo4j.Neo4jError{Code: NEO4J_CONSTRAINT_VIOLATION}, ErrNodeExists},
+		{"other error", nil, failed, failed},
+	}
+	for _, test := range tests {
+		err := createNodeError(person("a"), test.records, test.err)
+		if !errors.Is(err, test.want) || (test.want == nil && err != nil) {
+			t.Errorf("%s: got %v, want %v", test.name, err, test.want)
+		}
+	}
+}
+
+// TestBackendContractReadGraph checks ReadGraph never returns an edge without its nodes while
+// nodes and edges are being written
+func TestBackendContractReadGraph(t *testing.T) {
+	for name, open := range contractBackends {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			svc := open(t)
+			if err := svc.UpdateNode(ctx, person("a"), true); err != nil {
+				t.Fatal(err)
+			}
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				for i := 0; i < 50; i++ {
+					id := fmt.Sprintf("p%d", i)
+					if err := svc.UpdateNode(ctx, person(id), true); err != nil {
+						t.Error(err)
+						return
+					}
+					if err := svc.UpdateEdge(ctx, voted(id, "a"), true); err != nil {
+						t.Error(err)
+						return
+					}
+				}
+			}()
+
+			for reading := true; reading; {
+				select {
+				case <-done:
+					reading = false
+				default:
+				}
+				nodes, edges, err := svc.(GraphReader).ReadGraph(ctx)
+				if err != nil {
+					t.Fatal(err)
+				}
+				read := make(map[string]bool)
+				for _, node := range nodes {
+					read[nodeLockKey(node)] = true
+				}
+				for _, edge := range edges {
+					if !read[nodeLockKey(edge.Left)] || !read[nodeLockKey(edge.Right)] {
+						t.Fatalf("edge %s read without its nodes", edge.Id)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package graphdb
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/nedvisol/go-connectdots/config"
+	"github.com/nedvisol/go-connectdots/util"
+	"go.uber.org/fx"
+)
+
+// NewGraphDbService creates the backend selected by config.GraphDb.Type, stamping provenance on every write
+// and recording attribute history when config.GraphDb.Temporal is set. Writes are logged, or only logged,
+// according to config.GraphDb.WriteMode, and their outcome is published to changes. Writes of a same node
+// or edge are serialized.
+func NewGraphDbService(lifecycle fx.Lifecycle, cfg *config.Config, changes *ChangeStream) (GraphDbService, error) {
+	svc, err := NewGraphDbBackend(lifecycle, cfg)
+	if err != nil {
+		return nil, err
+	}
+	// the audit log sits next to the backend so it shows the writes with every stamped property
+	if svc, err = withWriteMode(lifecycle, cfg, svc); err != nil {
+		return nil, err
+	}
+	svc = NewProvenanceGraphService(svc, &Provenance{RunId: cfg.RunId})
+	if cfg.GraphDb.Temporal {
+		svc = NewTemporalGraphService(svc, time.Now)
+	}
+	// outermost, so the changes compare what the caller writes
+	svc = NewChangeGraphService(svc, changes)
+	// around every decorator reading the stored entity before writing it
+	svc = NewSerializedGraphService(svc)
+	return svc, nil
+}
+
+// unwrapper is implemented by the decorators which change what is written or publish it
+type unwrapper interface {
+	Unwrap() GraphDbService
+}
+
+// Unwrapped returns the service below the provenance, temporal, change and serializing decorators of svc,
+// the backend or the audit log in front of it, for the writes which must not be stamped as crawled data
+// such as migrations
+func Unwrapped(svc GraphDbService) GraphDbService {
+	for {
+		decorator, ok := svc.(unwrapper)
+		if !ok {
+			return svc
+		}
+		svc = decorator.Unwrap()
+	}
+}
+
+// NewGraphDbBackend creates the GraphDbService backend selected by config.GraphDb.Type without any decorator,
+// for tools which must write the graph as is
+func NewGraphDbBackend(lifecycle fx.Lifecycle, cfg *config.Config) (GraphDbService, error) {
+	switch cfg.GraphDb.Type {
+	case config.GRAPHDB_MEMORY:
+		return NewMemoryGraphService(), nil
+	case config.GRAPHDB_SQLITE:
+		return NewSqliteGraphService(lifecycle, cfg)
+	case config.GRAPHDB_NEO4J, "":
+		return NewNeo4jGraphService(lifecycle, cfg)
+	default:
+		return nil, fmt.Errorf("unknown graph db type %s", cfg.GraphDb.Type)
+	}
+}
+
+func withWriteMode(lifecycle fx.Lifecycle, cfg *config.Config, svc GraphDbService) (GraphDbService, error) {
+	switch cfg.GraphDb.WriteMode {
+	case "":
+		return svc, nil
+	case config.WRITE_MODE_DRYRUN, config.WRITE_MODE_AUDIT:
+	default:
+		return nil, fmt.Errorf("unknown graph write mode %s", cfg.GraphDb.WriteMode)
+	}
+
+	var out io.Writer = os.Stdout
+	if cfg.GraphDb.AuditLogPath != "" {
+		file, err := os.OpenFile(cfg.GraphDb.AuditLogPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, err
+		}
+		lifecycle.Append(fx.Hook{
+			OnStop: func(ctx context.Context) error {
+				return file.Close()
+			},
+		})
+		out = file
+	}
+	dryRun := cfg.GraphDb.WriteMode == config.WRITE_MODE_DRYRUN
+	fmt.Printf("graph write mode %s, logging writes to %s\n", cfg.GraphDb.WriteMode, util.Ternary(cfg.GraphDb.AuditLogPath == "", "stdout", cfg.GraphDb.AuditLogPath))
+	return NewAuditGraphService(svc, out, dryRun, cfg.RunId), nil
+}
+
+// withQueryTimeout bounds a single GraphDbService call by timeout on top of the deadline of ctx,
+// a zero timeout leaves ctx as is
+func withQueryTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
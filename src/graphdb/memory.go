@@ -0,0 +1,321 @@
+package graphdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+var ErrNodeNotFound = errors.New("node not found")
+var ErrNodeExists = errors.New("node already exists")
+var ErrEdgeNotFound = errors.New("edge not found")
+
+type memoryNodeKey struct {
+	label string
+	id    string
+}
+
+// edges are matched on the full (left)-[edge]->(right) pattern, same as the MERGE in neo4j.go
+type memoryEdgeKey struct {
+	label string
+	id    string
+	left  memoryNodeKey
+	right memoryNodeKey
+}
+
+type memoryNode struct {
+	attrs map[string]interface{}
+}
+
+type memoryEdge struct {
+	attrs map[string]interface{}
+}
+
+// MemoryGraphService is a thread-safe in-memory property graph implementing GraphDbService.
+// It is meant for tests and small runs where a Neo4j server is not available.
+type MemoryGraphService struct {
+	mutex sync.RWMutex
+	nodes map[memoryNodeKey]*memoryNode
+	edges map[memoryEdgeKey]*memoryEdge
+}
+
+func nodeKeyOf(node *NodeInfo) memoryNodeKey {
+	return memoryNodeKey{label: node.Label, id: node.Id}
+}
+
+func edgeKeyOf(edge *EdgeInfo) memoryEdgeKey {
+	return memoryEdgeKey{
+		label: edge.Label,
+		id:    edge.Id,
+		left:  nodeKeyOf(edge.Left),
+		right: nodeKeyOf(edge.Right),
+	}
+}
+
+func copyAttrs(attrs *map[string]interface{}) map[string]interface{} {
+	clone := make(map[string]interface{})
+	if attrs == nil {
+		return clone
+	}
+	for key, value := range *attrs {
+		clone[key] = value
+	}
+	return clone
+}
+
+func setAttrs(target map[string]interface{}, attrs *map[string]interface{}) {
+	if attrs == nil {
+		return
+	}
+	for key, value := range *attrs {
+		target[key] = value
+	}
+}
+
+// CreateNode implements GraphDbService.
+func (m *MemoryGraphService) CreateNode(ctx context.Context, node *NodeInfo) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	key := nodeKeyOf(node)
+	if _, exists := m.nodes[key]; exists {
+		return fmt.Errorf("%w: %s %s", ErrNodeExists, node.Label, node.Id)
+	}
+	m.nodes[key] = &memoryNode{attrs: copyAttrs(node.Attrs)}
+	return nil
+}
+
+func (m *MemoryGraphService) updateNode(node *NodeInfo, allowUpsert bool) error {
+	key := nodeKeyOf(node)
+	existing, exists := m.nodes[key]
+	if !exists {
+		if !allowUpsert {
+			return fmt.Errorf("%w: %s %s", ErrNodeNotFound, node.Label, node.Id)
+		}
+		existing = &memoryNode{attrs: make(map[string]interface{})}
+		m.nodes[key] = existing
+	}
+	setAttrs(existing.attrs, node.Attrs)
+	return nil
+}
+
+// UpdateNode implements GraphDbService.
+func (m *MemoryGraphService) UpdateNode(ctx context.Context, node *NodeInfo, allowUpsert bool) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.updateNode(node, allowUpsert)
+}
+
+// UpdateNodes implements GraphDbService.
+func (m *MemoryGraphService) UpdateNodes(ctx context.Context, nodes []*NodeInfo, allowUpsert bool) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if !allowUpsert {
+		for _, node := range nodes {
+			if _, exists := m.nodes[nodeKeyOf(node)]; !exists {
+				return fmt.Errorf("%w: %s %s", ErrNodeNotFound, node.Label, node.Id)
+			}
+		}
+	}
+	for _, node := range nodes {
+		m.updateNode(node, allowUpsert)
+	}
+	return nil
+}
+
+// DeleteNode implements GraphDbService. Edges attached to the node are removed with it.
+func (m *MemoryGraphService) DeleteNode(ctx context.Context, node *NodeInfo) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	key := nodeKeyOf(node)
+	if _, exists := m.nodes[key]; !exists {
+		return fmt.Errorf("%w: %s %s", ErrNodeNotFound, node.Label, node.Id)
+	}
+	delete(m.nodes, key)
+	for edgeKey := range m.edges {
+		if edgeKey.left == key || edgeKey.right == key {
+			delete(m.edges, edgeKey)
+		}
+	}
+	return nil
+}
+
+// checkEdge returns an error if edge can't be written, without modifying anything
+func (m *MemoryGraphService) checkEdge(edge *EdgeInfo, allowUpsert bool) error {
+	if _, exists := m.nodes[nodeKeyOf(edge.Left)]; !exists {
+		return fmt.Errorf("%w: %s %s", ErrNodeNotFound, edge.Left.Label, edge.Left.Id)
+	}
+	if _, exists := m.nodes[nodeKeyOf(edge.Right)]; !exists {
+		return fmt.Errorf("%w: %s %s", ErrNodeNotFound, edge.Right.Label, edge.Right.Id)
+	}
+	if _, exists := m.edges[edgeKeyOf(edge)]; !exists && !allowUpsert {
+		return fmt.Errorf("%w: %s %s", ErrEdgeNotFound, edge.Label, edge.Id)
+	}
+	return nil
+}
+
+func (m *MemoryGraphService) updateEdge(edge *EdgeInfo) {
+	key := edgeKeyOf(edge)
+	existing, exists := m.edges[key]
+	if !exists {
+		existing = &memoryEdge{attrs: make(map[string]interface{})}
+		m.edges[key] = existing
+	}
+	setAttrs(existing.attrs, edge.Attrs)
+}
+
+// UpdateEdge implements GraphDbService.
+func (m *MemoryGraphService) UpdateEdge(ctx context.Context, edge *EdgeInfo, allowUpsert bool) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if err := m.checkEdge(edge, allowUpsert); err != nil {
+		return err
+	}
+	m.updateEdge(edge)
+	return nil
+}
+
+// UpdateEdges implements GraphDbService.
+func (m *MemoryGraphService) UpdateEdges(ctx context.Context, edges []*EdgeInfo, allowUpsert bool) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, edge := range edges {
+		if err := m.checkEdge(edge, allowUpsert); err != nil {
+			return err
+		}
+	}
+	for _, edge := range edges {
+		m.updateEdge(edge)
+	}
+	return nil
+}
+
+// GetNode returns a copy of the stored node matching the label and id of node, or nil if there is none.
+func (m *MemoryGraphService) GetNode(ctx context.Context, node *NodeInfo) (*NodeInfo, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	key := nodeKeyOf(node)
+	existing, exists := m.nodes[key]
+	if !exists {
+		return nil, nil
+	}
+	return memoryNodeInfo(key, existing), nil
+}
+
+// GetEdge returns a copy of the stored edge matching the label, id and endpoints of edge, or nil if there is none.
+func (m *MemoryGraphService) GetEdge(ctx context.Context, edge *EdgeInfo) (*EdgeInfo, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	key := edgeKeyOf(edge)
+	existing, exists := m.edges[key]
+	if !exists {
+		return nil, nil
+	}
+	return memoryEdgeInfo(key, existing), nil
+}
+
+// FindNodes returns copies of all nodes with the given label, or of every node if label is empty.
+func (m *MemoryGraphService) FindNodes(ctx context.Context, label string) ([]*NodeInfo, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.findNodes(label), nil
+}
+
+func (m *MemoryGraphService) findNodes(label string) []*NodeInfo {
+	nodes := make([]*NodeInfo, 0)
+	for key, node := range m.nodes {
+		if label == "" || key.label == label {
+			nodes = append(nodes, memoryNodeInfo(key, node))
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].Label != nodes[j].Label {
+			return nodes[i].Label < nodes[j].Label
+		}
+		return nodes[i].Id < nodes[j].Id
+	})
+	return nodes
+}
+
+// FindEdges returns copies of all edges with the given label, or of every edge if label is empty.
+func (m *MemoryGraphService) FindEdges(ctx context.Context, label string) ([]*EdgeInfo, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.findEdges(label), nil
+}
+
+func (m *MemoryGraphService) findEdges(label string) []*EdgeInfo {
+	edges := make([]*EdgeInfo, 0)
+	for key, edge := range m.edges {
+		if label == "" || key.label == label {
+			edges = append(edges, memoryEdgeInfo(key, edge))
+		}
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].Label != edges[j].Label {
+			return edges[i].Label < edges[j].Label
+		}
+		if edges[i].Id != edges[j].Id {
+			return edges[i].Id < edges[j].Id
+		}
+		if edges[i].Left.Id != edges[j].Left.Id {
+			return edges[i].Left.Id < edges[j].Left.Id
+		}
+		return edges[i].Right.Id < edges[j].Right.Id
+	})
+	return edges
+}
+
+// ReadGraph implements GraphReader.
+func (m *MemoryGraphService) ReadGraph(ctx context.Context) ([]*NodeInfo, []*EdgeInfo, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.findNodes(""), m.findEdges(""), nil
+}
+
+// Ping implements GraphDbService.
+func (m *MemoryGraphService) Ping(ctx context.Context) error {
+	return nil
+}
+
+// ApplySchema implements GraphDbService. Nodes are keyed by label and _id so there is nothing to create,
+// the stored graph is only validated.
+func (m *MemoryGraphService) ApplySchema(ctx context.Context, schema *Schema) error {
+	return validateSchema(ctx, m, schema)
+}
+
+func memoryNodeInfo(key memoryNodeKey, node *memoryNode) *NodeInfo {
+	attrs := copyAttrs(&node.attrs)
+	return &NodeInfo{
+		Label: key.label,
+		Id:    key.id,
+		Attrs: &attrs,
+	}
+}
+
+func memoryEdgeInfo(key memoryEdgeKey, edge *memoryEdge) *EdgeInfo {
+	attrs := copyAttrs(&edge.attrs)
+	return &EdgeInfo{
+		Label: key.label,
+		Id:    key.id,
+		Attrs: &attrs,
+		Left:  &NodeInfo{Label: key.left.label, Id: key.left.id},
+		Right: &NodeInfo{Label: key.right.label, Id: key.right.id},
+	}
+}
+
+func NewMemoryGraphService() *MemoryGraphService {
+	return &MemoryGraphService{
+		nodes: make(map[memoryNodeKey]*memoryNode),
+		edges: make(map[memoryEdgeKey]*memoryEdge),
+	}
+}
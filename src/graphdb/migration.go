@@ -0,0 +1,167 @@
+package graphdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+var ErrSchemaOutOfDate = errors.New("graph schema is out of date")
+
+const MIGRATION_LABEL = "SchemaMigration"
+
+// CypherExecutor is implemented by backends which can run raw Cypher statements
+type CypherExecutor interface {
+	ExecuteCypher(ctx context.Context, query string, params map[string]interface{}) error
+}
+
+// Migration is a versioned change to the stored graph. Up is used when set,
+// otherwise the Cypher statements are run, which requires a CypherExecutor backend.
+type Migration struct {
+	Version int
+	Name    string
+	Cypher  []string
+	Up      func(ctx context.Context, svc GraphDbService) error
+}
+
+// Migrations is the ordered list of migrations the processors expect to be applied
+var Migrations = []*Migration{
+	{
+		Version: 1,
+		Name:    "baseline",
+		Up: func(ctx context.Context, svc GraphDbService) error {
+			return nil
+		},
+	},
+	{
+		Version: 2,
+		Name:    "readable-ids",
+		Up:      migrateReadableIds,
+	},
+}
+
+type Migrator struct {
+	svc        GraphDbService
+	migrations []*Migration
+}
+
+func (m *Migrator) appliedVersions(ctx context.Context) (map[int]bool, error) {
+	nodes, err := m.svc.FindNodes(ctx, MIGRATION_LABEL)
+	if err != nil {
+		return nil, err
+	}
+	applied := make(map[int]bool)
+	for _, node := range nodes {
+		var version int
+		if _, err := fmt.Sscanf(node.Id, "%d", &version); err != nil {
+			return nil, fmt.Errorf("invalid migration record %s: %w", node.Id, err)
+		}
+		applied[version] = true
+	}
+	return applied, nil
+}
+
+// AppliedVersion returns the highest migration version recorded in the graph, 0 when none is
+func (m *Migrator) AppliedVersion(ctx context.Context) (int, error) {
+	applied, err := m.appliedVersions(ctx)
+	if err != nil {
+		return 0, err
+	}
+	version := 0
+	for applied := range applied {
+		if applied > version {
+			version = applied
+		}
+	}
+	return version, nil
+}
+
+// Pending returns the migrations not recorded in the graph yet, in version order
+func (m *Migrator) Pending(ctx context.Context) ([]*Migration, error) {
+	applied, err := m.appliedVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pending := make([]*Migration, 0)
+	for _, migration := range m.migrations {
+		if !applied[migration.Version] {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+func (m *Migrator) apply(ctx context.Context, migration *Migration) error {
+	if migration.Up != nil {
+		return migration.Up(ctx, m.svc)
+	}
+	executor, ok := m.svc.(CypherExecutor)
+	if !ok {
+		return fmt.Errorf("migration %d %s requires a backend that can execute Cypher", migration.Version, migration.Name)
+	}
+	for _, statement := range migration.Cypher {
+		if err := executor.ExecuteCypher(ctx, statement, map[string]interface{}{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Migrate applies the pending migrations in order and records each one in the graph.
+// With dryRun set the pending migrations are only returned.
+func (m *Migrator) Migrate(ctx context.Context, dryRun bool) ([]*Migration, error) {
+	pending, err := m.Pending(ctx)
+	if err != nil || dryRun {
+		return pending, err
+	}
+
+	for i, migration := range pending {
+		if err := m.apply(ctx, migration); err != nil {
+			return pending[:i], fmt.Errorf("migration %d %s failed: %w", migration.Version, migration.Name, err)
+		}
+		err := m.svc.UpdateNode(ctx, &NodeInfo{
+			Label: MIGRATION_LABEL,
+			Id:    fmt.Sprintf("%d", migration.Version),
+			Attrs: &map[string]interface{}{
+				"version":   migration.Version,
+				"name":      migration.Name,
+				"appliedAt": time.Now().UTC().Format(time.RFC3339),
+			},
+		}, true)
+		if err != nil {
+			return pending[:i], err
+		}
+	}
+	return pending, nil
+}
+
+// CheckUpToDate returns ErrSchemaOutOfDate if any migration is pending
+func (m *Migrator) CheckUpToDate(ctx context.Context) error {
+	pending, err := m.Pending(ctx)
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w: %d pending migrations, first is %d %s", ErrSchemaOutOfDate, len(pending), pending[0].Version, pending[0].Name)
+	}
+	return nil
+}
+
+func NewMigrator(svc GraphDbService, migrations []*Migration) *Migrator {
+	sorted := make([]*Migration, len(migrations))
+	copy(sorted, migrations)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Version < sorted[j].Version
+	})
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i].Version == sorted[i-1].Version {
+			panic(fmt.Sprintf("duplicate migration version %d", sorted[i].Version))
+		}
+	}
+	return &Migrator{
+		svc:        svc,
+		migrations: sorted,
+	}
+}
@@ -0,0 +1,126 @@
+package graphdb
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nedvisol/go-connectdots/ids"
+)
+
+// readableNodeId derives the ids package identifier of a node written with a hashed id
+// from the congress.gov url it was stored with
+func readableNodeId(node *NodeInfo) (string, bool) {
+	if node.Attrs == nil {
+		return "", false
+	}
+	attrs := *node.Attrs
+	switch node.Label {
+	case "Person":
+		for _, attr := range []string{"sourceUrl", "url"} {
+			if url, ok := attrs[attr].(string); ok {
+				if id, found := ids.MemberFromUrl(url); found {
+					return id, true
+				}
+			}
+		}
+	case "Bill":
+		if url, ok := attrs["url"].(string); ok {
+			return ids.BillFromUrl(url)
+		}
+	}
+	return "", false
+}
+
+// readableEdgeId derives the identifier of an edge written before the ids package, whose nodes have readable
+// ids already. Only VOTED and SAME_AS edges were written then, any other label fails the migration.
+func readableEdgeId(edge *EdgeInfo) (string, error) {
+	switch edge.Label {
+	case "VOTED":
+		var date interface{}
+		if edge.Attrs != nil {
+			date = (*edge.Attrs)["date"]
+		}
+		if date, ok := date.(string); ok {
+			return ids.BillActionOf(edge.Right.Id, date)
+		}
+		return "", fmt.Errorf("no date to derive the id of VOTED edge %s", edge.Id)
+	case "SAME_AS":
+		// identified by the node linked to the canonical one
+		return edge.Left.Id, nil
+	}
+	return "", fmt.Errorf("unknown label of edge %s %s written before the ids package", edge.Label, edge.Id)
+}
+
+// migrateReadableIds replaces the base64 SHA-512 ids of the nodes and edges written before the
+// ids package by readable ones. Nodes are copied to their new id with their edges, then deleted.
+// Nodes whose id can't be derived keep their hashed id. The new ids are all derived before anything
+// is written, an edge whose id can't be derived fails the migration with the graph unchanged.
+func migrateReadableIds(ctx context.Context, svc GraphDbService) error {
+	nodes, err := svc.FindNodes(ctx, "")
+	if err != nil {
+		return err
+	}
+	renamed := make(map[string]string)
+	oldNodes := make([]*NodeInfo, 0)
+	for _, node := range nodes {
+		if ids.IsId(node.Id) {
+			continue
+		}
+		newId, ok := readableNodeId(node)
+		if !ok {
+			if node.Label == "Person" || node.Label == "Bill" {
+				fmt.Printf("keeping hashed id of %s %s, no url to derive it from\n", node.Label, node.Id)
+			}
+			continue
+		}
+		renamed[node.Label+"/"+node.Id] = newId
+		oldNodes = append(oldNodes, node)
+	}
+
+	edges, err := svc.FindEdges(ctx, "")
+	if err != nil {
+		return err
+	}
+	movedEdges := make([]*EdgeInfo, 0)
+	for _, edge := range edges {
+		leftId, leftRenamed := renamed[edge.Left.Label+"/"+edge.Left.Id]
+		rightId, rightRenamed := renamed[edge.Right.Label+"/"+edge.Right.Id]
+		if !leftRenamed && !rightRenamed {
+			continue
+		}
+		moved := *edge
+		if leftRenamed {
+			moved.Left = &NodeInfo{Label: edge.Left.Label, Id: leftId}
+		}
+		if rightRenamed {
+			moved.Right = &NodeInfo{Label: edge.Right.Label, Id: rightId}
+		}
+		if !ids.IsId(edge.Id) {
+			if moved.Id, err = readableEdgeId(&moved); err != nil {
+				return err
+			}
+		}
+		movedEdges = append(movedEdges, &moved)
+	}
+
+	for _, node := range oldNodes {
+		newId := renamed[node.Label+"/"+node.Id]
+		if err := svc.UpdateNode(ctx, &NodeInfo{Label: node.Label, Id: newId, Attrs: node.Attrs}, true); err != nil {
+			return err
+		}
+	}
+	for _, edge := range movedEdges {
+		if err := svc.UpdateEdge(ctx, edge, true); err != nil {
+			return err
+		}
+	}
+
+	// deleting the old nodes detaches their edges
+	for _, node := range oldNodes {
+		if err := svc.DeleteNode(ctx, node); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("replaced the hashed ids of %d nodes and %d edges\n", len(oldNodes), len(movedEdges))
+	return nil
+}
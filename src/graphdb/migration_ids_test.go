@@ -0,0 +1,127 @@
+package graphdb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nedvisol/go-connectdots/ids"
+)
+
+// v1Graph writes a graph with the hashed ids of the versions before the ids package
+func v1Graph(t *testing.T, extraEdges ...*EdgeInfo) *MemoryGraphService {
+	ctx := context.Background()
+	svc := NewMemoryGraphService()
+	nodes := []*NodeInfo{
+		{Label: "Person", Id: "aGFzaGVkV3lkZW4=", Attrs: &map[string]interface{}{"first": "Ron", "last": "Wyden",
+			"url": "https://api.congress.gov/v3/member/W000779?format=json"}},
+		{Label: "Person", Id: "aGFzaGVkTWVya2xleQ==", Attrs: &map[string]interface{}{"first": "Jeff", "last": "Merkley",
+			"sourceUrl": "https://api.congress.gov/v3/member/M001176?format=json"}},
+		{Label: "Person", Id: "aGFzaGVkTm9Vcmw=", Attrs: &map[string]interface{}{"first": "Ron", "last": "Wyden"}},
+		{Label: "Bill", Id: "aGFzaGVkQmlsbA==", Attrs: &map[string]interface{}{"congress": 118, "billType": "hr",
+			"url": "https://api.congress.gov/v3/bill/118/hr/1234?format=json"}},
+	}
+	edges := []*EdgeInfo{
+		{Label: "VOTED", Id: "aGFzaGVkVm90ZQ==", Left: nodes[0], Right: nodes[3], Attrs: &map[string]interface{}{"vote": "Yea", "date": "2023-05-01"}},
+		{Label: "VOTED", Id: "aGFzaGVkVm90ZTI=", Left: nodes[2], Right: nodes[3], Attrs: &map[string]interface{}{"vote": "Nay", "date": "2023-05-01"}},
+		{Label: "VOTED", Id: "aGFzaGVkVm90ZTM=", Left: nodes[1], Right: nodes[3], Attrs: &map[string]interface{}{"vote": "Present", "date": "2023-05-01"}},
+		{Label: "SAME_AS", Id: "aGFzaGVkTm9Vcmw=", Left: nodes[2], Right: nodes[0], Attrs: &map[string]interface{}{"confidence": 0.95}},
+	}
+	for _, node := range nodes {
+		if err := svc.UpdateNode(ctx, node, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, edge := range append(edges, extraEdges...) {
+		if err := svc.UpdateEdge(ctx, edge, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return svc
+}
+
+func TestMigrateReadableIds(t *testing.T) {
+	ctx := context.Background()
+	svc := v1Graph(t)
+	migrator := NewMigrator(svc, Migrations)
+	if _, err := migrator.Migrate(ctx, false); err != nil {
+		t.Fatal(err)
+	}
+
+	people, _ := svc.FindNodes(ctx, "Person")
+	personIds := make(map[string]bool)
+	for _, person := range people {
+		personIds[person.Id] = true
+	}
+	// the node without a url keeps its hashed id
+	if len(personIds) != 3 || !personIds[ids.Member("W000779")] || !personIds[ids.Member("M001176")] || !personIds["aGFzaGVkTm9Vcmw="] {
+		t.Errorf("people %v", personIds)
+	}
+	bills, _ := svc.FindNodes(ctx, "Bill")
+	if len(bills) != 1 || bills[0].Id != ids.Bill(118, "hr", "1234") || (*bills[0].Attrs)["congress"] != 118 {
+		t.Errorf("bills %v", bills)
+	}
+
+	billActionId, _ := ids.BillActionOf(ids.Bill(118, "hr", "1234"), "2023-05-01")
+	wantEdges := map[string]struct {
+		id    string
+		left  string
+		right string
+	}{
+		"VOTED/Yea":     {billActionId, ids.Member("W000779"), ids.Bill(118, "hr", "1234")},
+		"VOTED/Nay":     {billActionId, "aGFzaGVkTm9Vcmw=", ids.Bill(118, "hr", "1234")},
+		"VOTED/Present": {billActionId, ids.Member("M001176"), ids.Bill(118, "hr", "1234")},
+		"SAME_AS":       {"aGFzaGVkTm9Vcmw=", "aGFzaGVkTm9Vcmw=", ids.Member("W000779")},
+	}
+	edges, err := svc.FindEdges(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, edge := range edges {
+		key := edge.Label
+		if vote, ok := (*edge.Attrs)["vote"].(string); ok {
+			key += "/" + vote
+		}
+		want, found := wantEdges[key]
+		if !found || edge.Id != want.id || edge.Left.Id != want.left || edge.Right.Id != want.right {
+			t.Errorf("%s edge %s from %s to %s, want %v", key, edge.Id, edge.Left.Id, edge.Right.Id, want)
+		}
+		delete(wantEdges, key)
+	}
+	if len(wantEdges) != 0 {
+		t.Errorf("edges not migrated: %v", wantEdges)
+	}
+	if err := migrator.CheckUpToDate(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMigrateReadableIdsFailsOnUnknownEdges(t *testing.T) {
+	tests := []struct {
+		name string
+		edge *EdgeInfo
+	}{
+		{"unknown label", &EdgeInfo{Label: "FOLLOWS", Id: "aGFzaGVkRm9sbG93"}},
+		{"vote without a date", &EdgeInfo{Label: "VOTED", Id: "aGFzaGVkTm9EYXRl", Attrs: &map[string]interface{}{"vote": "Yea"}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			test.edge.Left = &NodeInfo{Label: "Person", Id: "aGFzaGVkV3lkZW4="}
+			test.edge.Right = &NodeInfo{Label: "Bill", Id: "aGFzaGVkQmlsbA=="}
+			svc := v1Graph(t, test.edge)
+			if _, err := NewMigrator(svc, Migrations).Migrate(ctx, false); err == nil {
+				t.Fatal("migrated an edge whose id can't be derived")
+			}
+			people, _ := svc.FindNodes(ctx, "Person")
+			for _, person := range people {
+				if ids.IsId(person.Id) {
+					t.Errorf("%s written by the failed migration", person.Id)
+				}
+			}
+			pending, err := NewMigrator(svc, Migrations).Pending(ctx)
+			if err != nil || len(pending) != 1 || pending[0].Version != 2 {
+				t.Errorf("pending migrations %v, %v", pending, err)
+			}
+		})
+	}
+}
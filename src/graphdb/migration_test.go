@@ -0,0 +1,58 @@
+package graphdb
+
+import (
+	"context"
+	"io"
+	"testing"
+)
+
+// cypherGraphService is a memory backend recording the Cypher statements it is asked to run
+type cypherGraphService struct {
+	*MemoryGraphService
+	statements []string
+}
+
+func (c *cypherGraphService) ExecuteCypher(ctx context.Context, query string, params map[string]interface{}) error {
+	c.statements = append(c.statements, query)
+	return nil
+}
+
+func TestMigrateUnwrapped(t *testing.T) {
+	backend := &cypherGraphService{MemoryGraphService: NewMemoryGraphService()}
+	var svc GraphDbService = NewAuditGraphService(backend, io.Discard, false, "run")
+	svc = NewProvenanceGraphService(svc, &Provenance{RunId: "run"})
+	svc = NewTemporalGraphService(svc, nil)
+	svc = NewSerializedGraphService(NewChangeGraphService(svc, OpenChangeStream(nil, "run")))
+
+	migrator := NewMigrator(Unwrapped(svc), []*Migration{
+		{Version: 1, Name: "index", Cypher: []string{"CREATE INDEX person_last IF NOT EXISTS FOR (n:Person) ON (n.last)"}},
+	})
+	ctx := context.Background()
+	if _, err := migrator.Migrate(ctx, false); err != nil {
+		t.Fatal(err)
+	}
+	if len(backend.statements) != 1 {
+		t.Fatalf("%d statements run, want 1", len(backend.statements))
+	}
+	if err := migrator.CheckUpToDate(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	record, err := backend.GetNode(ctx, &NodeInfo{Label: MIGRATION_LABEL, Id: "1"})
+	if err != nil || record == nil {
+		t.Fatalf("migration not recorded: %v", err)
+	}
+	for _, prop := range []string{PROP_RUN_ID, PROP_SOURCE_URLS, PROP_HISTORY} {
+		if _, stamped := (*record.Attrs)[prop]; stamped {
+			t.Errorf("migration record stamped with %s", prop)
+		}
+	}
+}
+
+func TestUnwrappedStopsAtAudit(t *testing.T) {
+	audit := NewAuditGraphService(NewMemoryGraphService(), io.Discard, true, "run")
+	svc := NewSerializedGraphService(NewProvenanceGraphService(audit, nil))
+	if Unwrapped(svc) != GraphDbService(audit) {
+		t.Fatal("the audit log of the migrations was unwrapped")
+	}
+}
@@ -2,8 +2,9 @@ package graphdb
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"sort"
 	"strings"
 
 	"github.com/nedvisol/go-connectdots/config"
@@ -13,7 +14,6 @@ import (
 )
 
 type Neo4jGraphService struct {
-	ctx    context.Context
 	config config.GraphDbConfig
 	driver neo4j.DriverWithContext
 }
@@ -22,12 +22,35 @@ func (n *Neo4jGraphService) getSession(ctx context.Context) neo4j.SessionWithCon
 	return n.driver.NewSession(ctx, neo4j.SessionConfig{DatabaseName: "neo4j"})
 }
 
-// CreateEdge implements GraphDbService.
-func (n *Neo4jGraphService) UpdateEdge(edge *EdgeInfo, allowUpsert bool) error {
-	queryAttrs := make([]string, 0, len(*edge.Attrs)+1)
-	for key := range *edge.Attrs {
+// txConfig makes the server abort transactions running longer than the query timeout
+func (n *Neo4jGraphService) txConfig() []func(*neo4j.TransactionConfig) {
+	if n.config.QueryTimeout <= 0 {
+		return nil
+	}
+	return []func(*neo4j.TransactionConfig){neo4j.WithTxTimeout(n.config.QueryTimeout)}
+}
+
+// sortedKeys keeps the generated queries stable from one run to the next
+func sortedKeys(params map[string]interface{}) []string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// updateEdgeQuery builds the MERGE/MATCH query and parameters of UpdateEdge
+func updateEdgeQuery(edge *EdgeInfo, allowUpsert bool) (string, map[string]interface{}) {
+	params := copyAttrs(edge.Attrs)
+	queryAttrs := make([]string, 0, len(params))
+	for _, key := range sortedKeys(params) {
 		queryAttrs = append(queryAttrs, fmt.Sprintf("edge.%s = $%s", key, key))
 	}
+	setClause := ""
+	if len(queryAttrs) > 0 {
+		setClause = "SET " + strings.Join(queryAttrs, ",")
+	}
 
 	mergeOrMatch := util.Ternary(allowUpsert, "MERGE", "MATCH")
 
@@ -35,42 +58,138 @@ func (n *Neo4jGraphService) UpdateEdge(edge *EdgeInfo, allowUpsert bool) error {
 	MATCH (left:%s { _id: $left_id })
 	MATCH (right:%s { _id: $right_id })
 	%s (left)-[edge:%s {_id: $edge_id}]->(right)
-	SET %s
-	RETURN edge._id
+	%s
+	RETURN count(*) AS written
 	`,
 		edge.Left.Label,
 		edge.Right.Label,
 		mergeOrMatch,
 		edge.Label,
-		strings.Join(queryAttrs, ","))
+		setClause)
+
+	params["left_id"] = edge.Left.Id
+	params["right_id"] = edge.Right.Id
+	params["edge_id"] = edge.Id
+	return query, params
+}
 
-	(*edge.Attrs)["left_id"] = edge.Left.Id
-	(*edge.Attrs)["right_id"] = edge.Right.Id
-	(*edge.Attrs)["edge_id"] = edge.Id
+// updateNodeQuery builds the MERGE/MATCH query and parameters of UpdateNode
+func updateNodeQuery(node *NodeInfo, allowUpsert bool) (string, map[string]interface{}) {
+	params := copyAttrs(node.Attrs)
+	queryAttrs := make([]string, 0, len(params))
+	for _, key := range sortedKeys(params) {
+		queryAttrs = append(queryAttrs, fmt.Sprintf("node.%s = $%s", key, key))
+	}
+	setClause := ""
+	if len(queryAttrs) > 0 {
+		setClause = "SET " + strings.Join(queryAttrs, ",")
+	}
 
-	//fmt.Printf("executing query %s\n", query)
+	mergeOrMatch := util.Ternary(allowUpsert, "MERGE", "MATCH")
 
-	// Execute the query inside a transaction
-	session := n.getSession(n.ctx)
-	defer session.Close(n.ctx)
-	records, err := session.Run(n.ctx, query, *edge.Attrs)
-	if err != nil {
-		return err
+	query := fmt.Sprintf(`
+	%s (node: %s {_id : $_id})
+	%s
+	RETURN count(*) AS written
+	`, mergeOrMatch, node.Label, setClause)
+	params["_id"] = node.Id
+	return query, params
+}
+
+// notWrittenError aborts a batch on its query at index, which matched nothing
+type notWrittenError struct {
+	index int
+}
+
+func (e *notWrittenError) Error() string {
+	return fmt.Sprintf("query %d of the batch matched nothing", e.index)
+}
+
+// written reads the count(*) AS written of the update queries, count(*) returns a row even when nothing matched
+func written(records []*neo4j.Record) bool {
+	if len(records) == 0 {
+		return false
 	}
+	count, _ := records[0].Get("written")
+	return count != int64(0)
+}
+
+// nodeNotWritten tells why an update of node matched nothing, only MATCH can miss
+func nodeNotWritten(node *NodeInfo) error {
+	return fmt.Errorf("%w: %s %s", ErrNodeNotFound, node.Label, node.Id)
+}
 
-	if records.Next(n.ctx) {
-		id, found := records.Record().Get("edge._id")
-		if !found {
-			fmt.Printf("error updating edge %s\n", id)
-			panic("unable to update edge")
+// edgeNotWritten tells why an update of edge matched nothing, a missing endpoint or, without upsert, a missing edge
+func (n *Neo4jGraphService) edgeNotWritten(ctx context.Context, edge *EdgeInfo) error {
+	for _, endpoint := range []*NodeInfo{edge.Left, edge.Right} {
+		found, err := n.GetNode(ctx, endpoint)
+		if err != nil {
+			return err
+		}
+		if found == nil {
+			return fmt.Errorf("%w: %s %s", ErrNodeNotFound, endpoint.Label, endpoint.Id)
 		}
-		//fmt.Printf("Updated node %s\n", id)
-		return nil
 	}
+	return fmt.Errorf("%w: %s %s", ErrEdgeNotFound, edge.Label, edge.Id)
+}
 
+// UpdateEdge implements GraphDbService.
+func (n *Neo4jGraphService) UpdateEdge(ctx context.Context, edge *EdgeInfo, allowUpsert bool) error {
+	query, params := updateEdgeQuery(edge, allowUpsert)
+	records, err := n.execute(ctx, query, params)
+	if err != nil {
+		return err
+	}
+	if !written(records) {
+		return n.edgeNotWritten(ctx, edge)
+	}
 	return nil
 }
 
+// UpdateEdges implements GraphDbService. All edges are written in a single transaction.
+func (n *Neo4jGraphService) UpdateEdges(ctx context.Context, edges []*EdgeInfo, allowUpsert bool) error {
+	queries := make([]string, 0, len(edges))
+	params := make([]map[string]interface{}, 0, len(edges))
+	for _, edge := range edges {
+		query, queryParams := updateEdgeQuery(edge, allowUpsert)
+		queries = append(queries, query)
+		params = append(params, queryParams)
+	}
+	err := n.runBatch(ctx, queries, params)
+	var notWritten *notWrittenError
+	if errors.As(err, &notWritten) {
+		return n.edgeNotWritten(ctx, edges[notWritten.index])
+	}
+	return err
+}
+
+// runBatch runs the update queries in one transaction, rolling back all of them if one fails or
+// matches nothing. The driver retries the whole transaction on transient errors.
+func (n *Neo4jGraphService) runBatch(ctx context.Context, queries []string, params []map[string]interface{}) error {
+	ctx, cancel := withQueryTimeout(ctx, n.config.QueryTimeout)
+	defer cancel()
+
+	session := n.getSession(ctx)
+	defer session.Close(ctx)
+	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		for i, query := range queries {
+			result, err := tx.Run(ctx, query, params[i])
+			if err != nil {
+				return nil, err
+			}
+			records, err := result.Collect(ctx)
+			if err != nil {
+				return nil, err
+			}
+			if !written(records) {
+				return nil, &notWrittenError{index: i}
+			}
+		}
+		return nil, nil
+	}, n.txConfig()...)
+	return err
+}
+
 // func cloneMap(source *map[string]interface{}) *map[string]interface{} {
 // 	clone := make(map[string]interface{})
 // 	for key, value := range *source {
@@ -80,92 +199,417 @@ func (n *Neo4jGraphService) UpdateEdge(edge *EdgeInfo, allowUpsert bool) error {
 // 	return &clone
 // }
 
-// CreateNode implements GraphDbService.
-func (n *Neo4jGraphService) CreateNode(node *NodeInfo) error {
-	queryAttrs := make([]string, 0, len(*node.Attrs)+1)
-	for key := range *node.Attrs {
+// createNodeQuery builds the CREATE query and parameters of CreateNode, which writes nothing when a node
+// with the same id exists
+func createNodeQuery(node *NodeInfo) (string, map[string]interface{}) {
+	params := copyAttrs(node.Attrs)
+	queryAttrs := make([]string, 0, len(params)+1)
+	for _, key := range sortedKeys(params) {
 		queryAttrs = append(queryAttrs, fmt.Sprintf("%s: $%s", key, key))
 	}
 	queryAttrs = append(queryAttrs, "_id: $_id")
 
 	query := fmt.Sprintf(`
+	OPTIONAL MATCH (existing: %s {_id : $_id})
+	WITH existing WHERE existing IS NULL
 	CREATE (node: %s {%s} )
-	RETURN node._id
-	`, node.Label, strings.Join(queryAttrs, ","))
-	(*node.Attrs)["_id"] = node.Id
+	RETURN count(*) AS written
+	`, node.Label, node.Label, strings.Join(queryAttrs, ","))
+	params["_id"] = node.Id
+	return query, params
+}
+
+// deleteNodeQuery builds the query and parameters of DeleteNode, which detaches the edges of the node
+func deleteNodeQuery(node *NodeInfo) (string, map[string]interface{}) {
+	query := fmt.Sprintf(`
+	MATCH (node: %s {_id : $_id})
+	DETACH DELETE node
+	RETURN count(*) AS deleted
+	`, node.Label)
+	return query, map[string]interface{}{"_id": node.Id}
+}
 
-	fmt.Printf("executing query %s\n", query)
+// NEO4J_CONSTRAINT_VIOLATION is the code of the error a write breaking a uniqueness constraint fails with
+const NEO4J_CONSTRAINT_VIOLATION = "Neo.ClientError.Schema.ConstraintValidationFailed"
 
-	// Execute the query inside a transaction
-	session := n.getSession(n.ctx)
-	defer session.Close(n.ctx)
-	records, err := session.Run(n.ctx, query, *node.Attrs)
+// createNodeError maps the outcome of a CreateNode query to ErrNodeExists when the node was found, or
+// created by a concurrent transaction, which the unique constraint on _id rejects
+func createNodeError(node *NodeInfo, records []*neo4j.Record, err error) error {
+	var neo4jErr *neo4j.Neo4jError
+	if errors.As(err, &neo4jErr) && neo4jErr.Code == NEO4J_CONSTRAINT_VIOLATION {
+		return fmt.Errorf("%w: %s %s", ErrNodeExists, node.Label, node.Id)
+	}
 	if err != nil {
 		return err
 	}
-
-	if records.Next(n.ctx) {
-		id, found := records.Record().Get("node._id")
-		if !found {
-			panic("unable to create node")
-		}
-		fmt.Printf("Created node %s", id)
-		return nil
+	if !written(records) {
+		return fmt.Errorf("%w: %s %s", ErrNodeExists, node.Label, node.Id)
 	}
-
 	return nil
 }
 
+// CreateNode implements GraphDbService.
+func (n *Neo4jGraphService) CreateNode(ctx context.Context, node *NodeInfo) error {
+	query, params := createNodeQuery(node)
+	records, err := n.execute(ctx, query, params)
+	return createNodeError(node, records, err)
+}
+
 // DeleteNode implements GraphDbService.
-func (n *Neo4jGraphService) DeleteNode(node *NodeInfo) error {
-	panic("unimplemented")
+func (n *Neo4jGraphService) DeleteNode(ctx context.Context, node *NodeInfo) error {
+	query, params := deleteNodeQuery(node)
+	records, err := n.execute(ctx, query, params)
+	if err != nil {
+		return err
+	}
+	// count(*) returns a row even when nothing matched
+	if deleted, _ := records[0].Get("deleted"); deleted == int64(0) {
+		return fmt.Errorf("%w: %s %s", ErrNodeNotFound, node.Label, node.Id)
+	}
+	return nil
 }
 
 // UpdateNode implements GraphDbService.
-func (n *Neo4jGraphService) UpdateNode(node *NodeInfo, allowUpsert bool) error {
-	queryAttrs := make([]string, 0, len(*node.Attrs)+1)
-	for key := range *node.Attrs {
-		queryAttrs = append(queryAttrs, fmt.Sprintf("node.%s = $%s", key, key))
+func (n *Neo4jGraphService) UpdateNode(ctx context.Context, node *NodeInfo, allowUpsert bool) error {
+	query, params := updateNodeQuery(node, allowUpsert)
+	records, err := n.execute(ctx, query, params)
+	if err != nil {
+		return err
+	}
+	if !written(records) {
+		return nodeNotWritten(node)
 	}
+	return nil
+}
 
-	mergeOrMatch := util.Ternary(allowUpsert, "MERGE", "MATCH")
+// UpdateNodes implements GraphDbService. All nodes are written in a single transaction.
+func (n *Neo4jGraphService) UpdateNodes(ctx context.Context, nodes []*NodeInfo, allowUpsert bool) error {
+	queries := make([]string, 0, len(nodes))
+	params := make([]map[string]interface{}, 0, len(nodes))
+	for _, node := range nodes {
+		query, queryParams := updateNodeQuery(node, allowUpsert)
+		queries = append(queries, query)
+		params = append(params, queryParams)
+	}
+	err := n.runBatch(ctx, queries, params)
+	var notWritten *notWrittenError
+	if errors.As(err, &notWritten) {
+		return nodeNotWritten(nodes[notWritten.index])
+	}
+	return err
+}
+
+func propsToAttrs(props interface{}) *map[string]interface{} {
+	attrs := make(map[string]interface{})
+	if propsMap, ok := props.(map[string]interface{}); ok {
+		for key, value := range propsMap {
+			if key != "_id" {
+				attrs[key] = value
+			}
+		}
+	}
+	return &attrs
+}
+
+// collect runs a read query in a managed transaction, retried by the driver on transient errors,
+// and returns all its records
+func (n *Neo4jGraphService) collect(ctx context.Context, query string, params map[string]interface{}) ([]*neo4j.Record, error) {
+	return n.run(ctx, neo4j.AccessModeRead, query, params)
+}
+
+// execute is collect for queries which write
+func (n *Neo4jGraphService) execute(ctx context.Context, query string, params map[string]interface{}) ([]*neo4j.Record, error) {
+	return n.run(ctx, neo4j.AccessModeWrite, query, params)
+}
+
+func (n *Neo4jGraphService) run(ctx context.Context, mode neo4j.AccessMode, query string, params map[string]interface{}) ([]*neo4j.Record, error) {
+	ctx, cancel := withQueryTimeout(ctx, n.config.QueryTimeout)
+	defer cancel()
+
+	session := n.getSession(ctx)
+	defer session.Close(ctx)
+	work := func(tx neo4j.ManagedTransaction) (any, error) {
+		result, err := tx.Run(ctx, query, params)
+		if err != nil {
+			return nil, err
+		}
+		return result.Collect(ctx)
+	}
+	var records any
+	var err error
+	if mode == neo4j.AccessModeRead {
+		records, err = session.ExecuteRead(ctx, work, n.txConfig()...)
+	} else {
+		records, err = session.ExecuteWrite(ctx, work, n.txConfig()...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return records.([]*neo4j.Record), nil
+}
 
+// Ping implements GraphDbService.
+func (n *Neo4jGraphService) Ping(ctx context.Context) error {
+	return n.driver.VerifyConnectivity(ctx)
+}
+
+// GetNode implements GraphDbService.
+func (n *Neo4jGraphService) GetNode(ctx context.Context, node *NodeInfo) (*NodeInfo, error) {
 	query := fmt.Sprintf(`
-	%s (node: %s {_id : $_id})
-	SET %s
-	RETURN node._id
-	`, mergeOrMatch, node.Label, strings.Join(queryAttrs, ","))
-	(*node.Attrs)["_id"] = node.Id
+	MATCH (node: %s {_id: $_id})
+	RETURN properties(node) AS props
+	LIMIT 1
+	`, node.Label)
+
+	records, err := n.collect(ctx, query, map[string]interface{}{"_id": node.Id})
+	if err != nil || len(records) == 0 {
+		return nil, err
+	}
+	props, _ := records[0].Get("props")
+	return &NodeInfo{
+		Label: node.Label,
+		Id:    node.Id,
+		Attrs: propsToAttrs(props),
+	}, nil
+}
+
+// GetEdge implements GraphDbService.
+func (n *Neo4jGraphService) GetEdge(ctx context.Context, edge *EdgeInfo) (*EdgeInfo, error) {
+	query := fmt.Sprintf(`
+	MATCH (left:%s { _id: $left_id })-[edge:%s {_id: $edge_id}]->(right:%s { _id: $right_id })
+	RETURN properties(edge) AS props
+	LIMIT 1
+	`, edge.Left.Label, edge.Label, edge.Right.Label)
+
+	records, err := n.collect(ctx, query, map[string]interface{}{
+		"left_id":  edge.Left.Id,
+		"right_id": edge.Right.Id,
+		"edge_id":  edge.Id,
+	})
+	if err != nil || len(records) == 0 {
+		return nil, err
+	}
+	props, _ := records[0].Get("props")
+	return &EdgeInfo{
+		Label: edge.Label,
+		Id:    edge.Id,
+		Attrs: propsToAttrs(props),
+		Left:  &NodeInfo{Label: edge.Left.Label, Id: edge.Left.Id},
+		Right: &NodeInfo{Label: edge.Right.Label, Id: edge.Right.Id},
+	}, nil
+}
+
+func findNodesQuery(label string) string {
+	pattern := "(node)"
+	if label != "" {
+		pattern = fmt.Sprintf("(node:%s)", label)
+	}
+	return fmt.Sprintf(`
+	MATCH %s
+	WHERE node._id IS NOT NULL
+	RETURN labels(node)[0] AS label, node._id AS id, properties(node) AS props
+	ORDER BY label, id
+	`, pattern)
+}
 
-	//fmt.Printf("executing query %s\n", query)
+func recordsToNodes(records []*neo4j.Record) []*NodeInfo {
+	nodes := make([]*NodeInfo, 0, len(records))
+	for _, record := range records {
+		values := record.AsMap()
+		nodes = append(nodes, &NodeInfo{
+			Label: values["label"].(string),
+			Id:    values["id"].(string),
+			Attrs: propsToAttrs(values["props"]),
+		})
+	}
+	return nodes
+}
+
+func findEdgesQuery(label string) string {
+	pattern := "[edge]"
+	if label != "" {
+		pattern = fmt.Sprintf("[edge:%s]", label)
+	}
+	return fmt.Sprintf(`
+	MATCH (left)-%s->(right)
+	WHERE edge._id IS NOT NULL
+	RETURN type(edge) AS label, edge._id AS id, properties(edge) AS props,
+		labels(left)[0] AS left_label, left._id AS left_id,
+		labels(right)[0] AS right_label, right._id AS right_id
+	ORDER BY label, id, left_id, right_id
+	`, pattern)
+}
 
-	// Execute the query inside a transaction
-	session := n.getSession(n.ctx)
-	defer session.Close(n.ctx)
-	records, err := session.Run(n.ctx, query, *node.Attrs)
+func recordsToEdges(records []*neo4j.Record) []*EdgeInfo {
+	edges := make([]*EdgeInfo, 0, len(records))
+	for _, record := range records {
+		values := record.AsMap()
+		edges = append(edges, &EdgeInfo{
+			Label: values["label"].(string),
+			Id:    values["id"].(string),
+			Attrs: propsToAttrs(values["props"]),
+			Left:  &NodeInfo{Label: values["left_label"].(string), Id: values["left_id"].(string)},
+			Right: &NodeInfo{Label: values["right_label"].(string), Id: values["right_id"].(string)},
+		})
+	}
+	return edges
+}
+
+// FindNodes implements GraphDbService.
+func (n *Neo4jGraphService) FindNodes(ctx context.Context, label string) ([]*NodeInfo, error) {
+	records, err := n.collect(ctx, findNodesQuery(label), map[string]interface{}{})
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return recordsToNodes(records), nil
+}
+
+// FindEdges implements GraphDbService.
+func (n *Neo4jGraphService) FindEdges(ctx context.Context, label string) ([]*EdgeInfo, error) {
+	records, err := n.collect(ctx, findEdgesQuery(label), map[string]interface{}{})
+	if err != nil {
+		return nil, err
+	}
+	return recordsToEdges(records), nil
+}
 
-	if records.Next(n.ctx) {
-		id, found := records.Record().Get("node._id")
-		if !found {
-			fmt.Printf("error updating node %s\n", id)
-			panic("unable to update node")
+// ReadGraph implements GraphReader.
+func (n *Neo4jGraphService) ReadGraph(ctx context.Context) ([]*NodeInfo, []*EdgeInfo, error) {
+	ctx, cancel := withQueryTimeout(ctx, n.config.QueryTimeout)
+	defer cancel()
+
+	session := n.getSession(ctx)
+	defer session.Close(ctx)
+	var nodes []*NodeInfo
+	var edges []*EdgeInfo
+	_, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		result, err := tx.Run(ctx, findNodesQuery(""), map[string]interface{}{})
+		if err != nil {
+			return nil, err
 		}
-		//fmt.Printf("Updated node %s\n", id)
-		return nil
+		records, err := result.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		nodes = recordsToNodes(records)
+
+		if result, err = tx.Run(ctx, findEdgesQuery(""), map[string]interface{}{}); err != nil {
+			return nil, err
+		}
+		if records, err = result.Collect(ctx); err != nil {
+			return nil, err
+		}
+		edges = recordsToEdges(records)
+		return nil, nil
+	}, n.txConfig()...)
+	if err != nil {
+		return nil, nil, err
 	}
+	return nodes, edges, nil
+}
+
+// ExecuteCypher implements CypherExecutor.
+func (n *Neo4jGraphService) ExecuteCypher(ctx context.Context, query string, params map[string]interface{}) error {
+	_, err := n.execute(ctx, query, params)
+	return err
+}
 
+func (n *Neo4jGraphService) countViolations(ctx context.Context, query string) (int64, error) {
+	records, err := n.collect(ctx, query, map[string]interface{}{})
+	if err != nil || len(records) == 0 {
+		return 0, err
+	}
+	count, _ := records[0].Get("cnt")
+	return count.(int64), nil
+}
+
+// ApplySchema implements GraphDbService. Creating a uniqueness constraint fails when duplicates
+// already exist; required properties are checked with queries since existence constraints need Enterprise.
+func (n *Neo4jGraphService) ApplySchema(ctx context.Context, schema *Schema) error {
+	statements := make([]string, 0)
+	for _, labelSchema := range schema.Labels {
+		for _, prop := range labelSchema.Unique {
+			statements = append(statements, fmt.Sprintf(
+				"CREATE CONSTRAINT %s IF NOT EXISTS FOR (node:%s) REQUIRE node.%s IS UNIQUE",
+				schemaObjectName("unique", labelSchema.Label, prop), labelSchema.Label, prop,
+			))
+		}
+		for _, prop := range labelSchema.Indexed {
+			statements = append(statements, fmt.Sprintf(
+				"CREATE INDEX %s IF NOT EXISTS FOR (node:%s) ON (node.%s)",
+				schemaObjectName("index", labelSchema.Label, prop), labelSchema.Label, prop,
+			))
+		}
+	}
+	for _, relSchema := range schema.Relationships {
+		for _, prop := range relSchema.Indexed {
+			statements = append(statements, fmt.Sprintf(
+				"CREATE INDEX %s IF NOT EXISTS FOR ()-[edge:%s]-() ON (edge.%s)",
+				schemaObjectName("index", relSchema.Type, prop), relSchema.Type, prop,
+			))
+		}
+	}
+
+	for _, statement := range statements {
+		if _, err := n.execute(ctx, statement, map[string]interface{}{}); err != nil {
+			return fmt.Errorf("%w: %s: %s", ErrSchemaViolation, statement, err)
+		}
+	}
+
+	for _, labelSchema := range schema.Labels {
+		for _, prop := range labelSchema.Required {
+			cnt, err := n.countViolations(ctx, fmt.Sprintf(
+				"MATCH (node:%s) WHERE node.%s IS NULL RETURN count(node) AS cnt", labelSchema.Label, prop,
+			))
+			if err != nil {
+				return err
+			}
+			if cnt > 0 {
+				return fmt.Errorf("%w: %d %s nodes are missing required property %s", ErrSchemaViolation, cnt, labelSchema.Label, prop)
+			}
+		}
+	}
+	for _, relSchema := range schema.Relationships {
+		for _, prop := range relSchema.Required {
+			cnt, err := n.countViolations(ctx, fmt.Sprintf(
+				"MATCH ()-[edge:%s]->() WHERE edge.%s IS NULL RETURN count(edge) AS cnt", relSchema.Type, prop,
+			))
+			if err != nil {
+				return err
+			}
+			if cnt > 0 {
+				return fmt.Errorf("%w: %d %s edges are missing required property %s", ErrSchemaViolation, cnt, relSchema.Type, prop)
+			}
+		}
+	}
 	return nil
 }
 
-func NewNeo4jGraphService(lifecycle fx.Lifecycle, ctx context.Context, cfg *config.Config) GraphDbService {
+// NewNeo4jGraphService connects to neo4j, retrying up to config.StartupRetries times before returning an error
+func NewNeo4jGraphService(lifecycle fx.Lifecycle, cfg *config.Config) (GraphDbService, error) {
 	graphcfg := cfg.GraphDb
-	driver, err := neo4j.NewDriverWithContext(graphcfg.Uri, neo4j.BasicAuth(graphcfg.Username, graphcfg.Password, ""))
+	driver, err := neo4j.NewDriverWithContext(
+		graphcfg.Uri,
+		neo4j.BasicAuth(graphcfg.Username, graphcfg.Password, ""),
+		func(driverConfig *neo4j.Config) {
+			if graphcfg.MaxRetryTime > 0 {
+				driverConfig.MaxTransactionRetryTime = graphcfg.MaxRetryTime
+			}
+		},
+	)
 	if err != nil {
-		log.Fatal("Error creating Neo4j driver: ", err)
+		return nil, fmt.Errorf("error creating neo4j driver: %w", err)
 	}
+	err = util.Retry("neo4j connectivity check", cfg.StartupRetries, cfg.StartupRetryDelay, func() error {
+		ctx, cancel := withQueryTimeout(context.Background(), graphcfg.QueryTimeout)
+		defer cancel()
+		return driver.VerifyConnectivity(ctx)
+	})
+	if err != nil {
+		driver.Close(context.Background())
+		return nil, fmt.Errorf("neo4j at %s is not reachable: %w", graphcfg.Uri, err)
+	}
+	fmt.Println("Connected to Neo4j!")
 	//defer driver.Close()
 
 	// Start a new session
@@ -181,8 +625,7 @@ func NewNeo4jGraphService(lifecycle fx.Lifecycle, ctx context.Context, cfg *conf
 	})
 
 	return &Neo4jGraphService{
-		ctx:    ctx,
 		config: *cfg.GraphDb,
 		driver: driver,
-	}
+	}, nil
 }
@@ -0,0 +1,214 @@
+package graphdb
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Provenance describes where the data of a node or edge write comes from
+type Provenance struct {
+	SourceSystem     string
+	SourceUrl        string
+	FetchedAt        time.Time
+	Processor        string
+	ProcessorVersion string
+	RunId            string
+}
+
+// provenance properties stamped on every node and edge, _sourceUrls and _sourceSystems
+// accumulate every source which contributed while the others describe the latest write
+const PROP_SOURCE_SYSTEM = "_sourceSystem"
+const PROP_SOURCE_URL = "_sourceUrl"
+const PROP_FETCHED_AT = "_fetchedAt"
+const PROP_PROCESSOR = "_processor"
+const PROP_PROCESSOR_VERSION = "_processorVersion"
+const PROP_RUN_ID = "_runId"
+const PROP_SOURCE_URLS = "_sourceUrls"
+const PROP_SOURCE_SYSTEMS = "_sourceSystems"
+
+// ProvenanceGraphService is a GraphDbService decorator stamping provenance properties on every write.
+// Fields missing from the Provenance of a node or edge are taken from defaults. The source lists are merged
+// with the stored ones, so concurrent writes of a same entity must go through a SerializedGraphService.
+type ProvenanceGraphService struct {
+	GraphDbService
+	defaults *Provenance
+}
+
+func (p *ProvenanceGraphService) merged(provenance *Provenance) *Provenance {
+	merged := *p.defaults
+	if provenance == nil {
+		return &merged
+	}
+	if provenance.SourceSystem != "" {
+		merged.SourceSystem = provenance.SourceSystem
+	}
+	if provenance.SourceUrl != "" {
+		merged.SourceUrl = provenance.SourceUrl
+	}
+	if !provenance.FetchedAt.IsZero() {
+		merged.FetchedAt = provenance.FetchedAt
+	}
+	if provenance.Processor != "" {
+		merged.Processor = provenance.Processor
+	}
+	if provenance.ProcessorVersion != "" {
+		merged.ProcessorVersion = provenance.ProcessorVersion
+	}
+	if provenance.RunId != "" {
+		merged.RunId = provenance.RunId
+	}
+	return &merged
+}
+
+// mergeLists unions string lists as they come back from the backends, []string or []interface{}
+func mergeLists(lists ...interface{}) []string {
+	merged := make([]string, 0)
+	seen := make(map[string]bool)
+	add := func(item string) {
+		if item != "" && !seen[item] {
+			seen[item] = true
+			merged = append(merged, item)
+		}
+	}
+	for _, list := range lists {
+		switch v := list.(type) {
+		case []string:
+			for _, item := range v {
+				add(item)
+			}
+		case []interface{}:
+			for _, item := range v {
+				add(fmt.Sprintf("%v", item))
+			}
+		case string:
+			add(v)
+		}
+	}
+	return merged
+}
+
+// stamp returns a copy of attrs with the provenance properties set, existing holds the
+// stored properties of the entity if it exists already
+func (p *ProvenanceGraphService) stamp(attrs *map[string]interface{}, provenance *Provenance, existing *map[string]interface{}) *map[string]interface{} {
+	stamped := copyAttrs(attrs)
+	merged := p.merged(provenance)
+
+	setIfPresent := func(key string, value string) {
+		if value != "" {
+			stamped[key] = value
+		}
+	}
+	setIfPresent(PROP_SOURCE_SYSTEM, merged.SourceSystem)
+	setIfPresent(PROP_SOURCE_URL, merged.SourceUrl)
+	setIfPresent(PROP_PROCESSOR, merged.Processor)
+	setIfPresent(PROP_PROCESSOR_VERSION, merged.ProcessorVersion)
+	setIfPresent(PROP_RUN_ID, merged.RunId)
+	if !merged.FetchedAt.IsZero() {
+		stamped[PROP_FETCHED_AT] = merged.FetchedAt.UTC().Format(time.RFC3339)
+	}
+
+	var existingUrls, existingSystems interface{}
+	if existing != nil {
+		existingUrls = (*existing)[PROP_SOURCE_URLS]
+		existingSystems = (*existing)[PROP_SOURCE_SYSTEMS]
+	}
+	// attrs may carry source lists of their own, e.g. when importing an export
+	stamped[PROP_SOURCE_URLS] = mergeLists(existingUrls, stamped[PROP_SOURCE_URLS], merged.SourceUrl)
+	stamped[PROP_SOURCE_SYSTEMS] = mergeLists(existingSystems, stamped[PROP_SOURCE_SYSTEMS], merged.SourceSystem)
+	return &stamped
+}
+
+func (p *ProvenanceGraphService) stampNode(ctx context.Context, node *NodeInfo) (*NodeInfo, error) {
+	existing, err := storedNode(ctx, p.GraphDbService, node)
+	if err != nil {
+		return nil, err
+	}
+	var existingAttrs *map[string]interface{}
+	if existing != nil {
+		existingAttrs = existing.Attrs
+	}
+	stamped := *node
+	stamped.Attrs = p.stamp(node.Attrs, node.Provenance, existingAttrs)
+	return &stamped, nil
+}
+
+func (p *ProvenanceGraphService) stampEdge(ctx context.Context, edge *EdgeInfo) (*EdgeInfo, error) {
+	existing, err := storedEdge(ctx, p.GraphDbService, edge)
+	if err != nil {
+		return nil, err
+	}
+	var existingAttrs *map[string]interface{}
+	if existing != nil {
+		existingAttrs = existing.Attrs
+	}
+	stamped := *edge
+	stamped.Attrs = p.stamp(edge.Attrs, edge.Provenance, existingAttrs)
+	return &stamped, nil
+}
+
+// CreateNode implements GraphDbService.
+func (p *ProvenanceGraphService) CreateNode(ctx context.Context, node *NodeInfo) error {
+	stamped := *node
+	stamped.Attrs = p.stamp(node.Attrs, node.Provenance, nil)
+	return p.GraphDbService.CreateNode(ctx, &stamped)
+}
+
+// UpdateNode implements GraphDbService.
+func (p *ProvenanceGraphService) UpdateNode(ctx context.Context, node *NodeInfo, allowUpsert bool) error {
+	stamped, err := p.stampNode(ctx, node)
+	if err != nil {
+		return err
+	}
+	return p.GraphDbService.UpdateNode(ctx, stamped, allowUpsert)
+}
+
+// UpdateNodes implements GraphDbService.
+func (p *ProvenanceGraphService) UpdateNodes(ctx context.Context, nodes []*NodeInfo, allowUpsert bool) error {
+	stamped := make([]*NodeInfo, 0, len(nodes))
+	for _, node := range nodes {
+		stampedNode, err := p.stampNode(ctx, node)
+		if err != nil {
+			return err
+		}
+		stamped = append(stamped, stampedNode)
+	}
+	return p.GraphDbService.UpdateNodes(ctx, stamped, allowUpsert)
+}
+
+// UpdateEdge implements GraphDbService.
+func (p *ProvenanceGraphService) UpdateEdge(ctx context.Context, edge *EdgeInfo, allowUpsert bool) error {
+	stamped, err := p.stampEdge(ctx, edge)
+	if err != nil {
+		return err
+	}
+	return p.GraphDbService.UpdateEdge(ctx, stamped, allowUpsert)
+}
+
+// UpdateEdges implements GraphDbService.
+func (p *ProvenanceGraphService) UpdateEdges(ctx context.Context, edges []*EdgeInfo, allowUpsert bool) error {
+	stamped := make([]*EdgeInfo, 0, len(edges))
+	for _, edge := range edges {
+		stampedEdge, err := p.stampEdge(ctx, edge)
+		if err != nil {
+			return err
+		}
+		stamped = append(stamped, stampedEdge)
+	}
+	return p.GraphDbService.UpdateEdges(ctx, stamped, allowUpsert)
+}
+
+// Unwrap returns the decorated service
+func (p *ProvenanceGraphService) Unwrap() GraphDbService {
+	return p.GraphDbService
+}
+
+func NewProvenanceGraphService(svc GraphDbService, defaults *Provenance) *ProvenanceGraphService {
+	if defaults == nil {
+		defaults = &Provenance{}
+	}
+	return &ProvenanceGraphService{
+		GraphDbService: svc,
+		defaults:       defaults,
+	}
+}
@@ -0,0 +1,235 @@
+package graphdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrSchemaViolation = errors.New("graph schema violation")
+
+// LabelSchema declares the properties of nodes with a given label
+type LabelSchema struct {
+	Label    string
+	Unique   []string // properties with a uniqueness constraint, _id should always be one of them
+	Indexed  []string // properties with a plain index
+	Required []string // properties every node must carry
+}
+
+// RelationshipSchema declares the properties of edges with a given relationship type
+type RelationshipSchema struct {
+	Type     string
+	Indexed  []string
+	Required []string
+}
+
+type Schema struct {
+	Labels        []*LabelSchema
+	Relationships []*RelationshipSchema
+}
+
+// DefaultSchema is the schema of the graph produced by the processors
+var DefaultSchema = &Schema{
+	Labels: []*LabelSchema{
+		{
+			Label:    "Person",
+			Unique:   []string{"_id"},
+			Required: []string{"first", "last"},
+		},
+		{
+			Label:    "Bill",
+			Unique:   []string{"_id"},
+			Indexed:  []string{"congress"},
+			Required: []string{"congress", "billType"},
+		},
+		{
+			Label:    "Vote",
+			Unique:   []string{"_id"},
+			Indexed:  []string{"congress", "chamber"},
+			Required: []string{"congress", "chamber", "rollNumber"},
+		},
+		{
+			Label:    "Amendment",
+			Unique:   []string{"_id"},
+			Indexed:  []string{"congress"},
+			Required: []string{"congress", "amendmentType"},
+		},
+		{
+			Label:    "Subject",
+			Unique:   []string{"_id"},
+			Required: []string{"name"},
+		},
+		{
+			Label:    "PolicyArea",
+			Unique:   []string{"_id"},
+			Required: []string{"name"},
+		},
+		{
+			Label:    "State",
+			Unique:   []string{"_id"},
+			Required: []string{"code"},
+		},
+		{
+			Label:    "District",
+			Unique:   []string{"_id"},
+			Required: []string{"state", "number"},
+		},
+		{
+			Label:    "Party",
+			Unique:   []string{"_id"},
+			Required: []string{"name"},
+		},
+		{
+			Label:    "Committee",
+			Unique:   []string{"_id"},
+			Indexed:  []string{"chamber"},
+			Required: []string{"name", "systemCode"},
+		},
+		{
+			Label:    "CommitteeReport",
+			Unique:   []string{"_id"},
+			Required: []string{"citation"},
+		},
+		{
+			Label:    MIGRATION_LABEL,
+			Unique:   []string{"_id"},
+			Required: []string{"version"},
+		},
+		{
+			Label:    "MatchReview",
+			Unique:   []string{"_id"},
+			Indexed:  []string{"status"},
+			Required: []string{"status", "canonicalId", "confidence"},
+		},
+	},
+	Relationships: []*RelationshipSchema{
+		{
+			Type:     "CAST_VOTE",
+			Indexed:  []string{"_id"},
+			Required: []string{"position"},
+		},
+		{
+			Type:    "CONCERNS",
+			Indexed: []string{"_id"},
+		},
+		{
+			Type:    "SPONSORED",
+			Indexed: []string{"_id"},
+		},
+		{
+			Type:     "COSPONSORED",
+			Indexed:  []string{"_id"},
+			Required: []string{"date"},
+		},
+		{
+			Type:    "AMENDS",
+			Indexed: []string{"_id"},
+		},
+		{
+			Type:    "ABOUT",
+			Indexed: []string{"_id"},
+		},
+		{
+			Type:     "REPRESENTED",
+			Indexed:  []string{"_id"},
+			Required: []string{"chamber", "congress"},
+		},
+		{
+			Type:    "IN_STATE",
+			Indexed: []string{"_id"},
+		},
+		{
+			Type:     "MEMBER_OF",
+			Indexed:  []string{"_id"},
+			Required: []string{"start"},
+		},
+		{
+			Type:    "SUBCOMMITTEE_OF",
+			Indexed: []string{"_id"},
+		},
+		{
+			Type:    "REFERRED_TO",
+			Indexed: []string{"_id"},
+		},
+		{
+			Type:    "ISSUED",
+			Indexed: []string{"_id"},
+		},
+		{
+			Type:     "SERVES_ON",
+			Indexed:  []string{"_id"},
+			Required: []string{"role"},
+		},
+		{
+			Type:     "SAME_AS",
+			Required: []string{"confidence"},
+		},
+	},
+}
+
+func schemaObjectName(kind string, label string, prop string) string {
+	return strings.ToLower(fmt.Sprintf("%s_%s_%s", label, strings.TrimPrefix(prop, "_"), kind))
+}
+
+func attrValue(id string, attrs *map[string]interface{}, prop string) (interface{}, bool) {
+	if prop == "_id" {
+		return id, true
+	}
+	if attrs == nil {
+		return nil, false
+	}
+	value, found := (*attrs)[prop]
+	return value, found && value != nil
+}
+
+// validateSchema checks the stored graph against schema by scanning it through svc.
+// Backends without native constraints use it when applying a schema.
+func validateSchema(ctx context.Context, svc GraphDbService, schema *Schema) error {
+	for _, labelSchema := range schema.Labels {
+		nodes, err := svc.FindNodes(ctx, labelSchema.Label)
+		if err != nil {
+			return err
+		}
+		seen := make(map[string]map[interface{}]string)
+		for _, prop := range labelSchema.Unique {
+			seen[prop] = make(map[interface{}]string)
+		}
+		for _, node := range nodes {
+			for _, prop := range labelSchema.Required {
+				if _, found := attrValue(node.Id, node.Attrs, prop); !found {
+					return fmt.Errorf("%w: %s %s is missing required property %s", ErrSchemaViolation, node.Label, node.Id, prop)
+				}
+			}
+			for _, prop := range labelSchema.Unique {
+				value, found := attrValue(node.Id, node.Attrs, prop)
+				if !found {
+					continue
+				}
+				key := fmt.Sprintf("%v", value)
+				if otherId, dup := seen[prop][key]; dup {
+					return fmt.Errorf("%w: %s %s and %s share unique property %s", ErrSchemaViolation, node.Label, node.Id, otherId, prop)
+				}
+				seen[prop][key] = node.Id
+			}
+		}
+	}
+
+	for _, relSchema := range schema.Relationships {
+		if len(relSchema.Required) == 0 {
+			continue
+		}
+		edges, err := svc.FindEdges(ctx, relSchema.Type)
+		if err != nil {
+			return err
+		}
+		for _, edge := range edges {
+			for _, prop := range relSchema.Required {
+				if _, found := attrValue(edge.Id, edge.Attrs, prop); !found {
+					return fmt.Errorf("%w: %s %s is missing required property %s", ErrSchemaViolation, edge.Label, edge.Id, prop)
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package graphdb
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+// schemaBackends opens an empty graph of every backend validating the schema by scanning the graph
+var schemaBackends = map[string]func(t *testing.T) GraphDbService{
+	"memory": func(t *testing.T) GraphDbService {
+		return NewMemoryGraphService()
+	},
+	"sqlite": func(t *testing.T) GraphDbService {
+		svc, err := OpenSqliteGraphService(filepath.Join(t.TempDir(), "graph.db"), 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { svc.db.Close() })
+		return svc
+	},
+}
+
+var testSchema = &Schema{
+	Labels: []*LabelSchema{
+		{Label: "State", Unique: []string{"_id", "code"}, Required: []string{"name"}},
+	},
+	Relationships: []*RelationshipSchema{
+		{Type: "BORDERS", Required: []string{"length"}},
+	},
+}
+
+func state(id string, attrs map[string]interface{}) *NodeInfo {
+	return &NodeInfo{Label: "State", Id: id, Attrs: &attrs}
+}
+
+func TestApplySchema(t *testing.T) {
+	tests := []struct {
+		name  string
+		nodes []*NodeInfo
+		edges []*EdgeInfo
+		want  error
+	}{
+		{"valid", []*NodeInfo{
+			state("or", map[string]interface{}{"name": "Oregon", "code": "OR"}),
+			state("wa", map[string]interface{}{"name": "Washington", "code": "WA"}),
+		}, []*EdgeInfo{
+			{Label: "BORDERS", Id: "or-wa", Left: state("or", nil), Right: state("wa", nil), Attrs: &map[string]interface{}{"length": 300}},
+		}, nil},
+		{"required property missing", []*NodeInfo{
+			state("or", map[string]interface{}{"code": "OR"}),
+		}, nil, ErrSchemaViolation},
+		{"required property nil", []*NodeInfo{
+			state("or", map[string]interface{}{"name": nil, "code": "OR"}),
+		}, nil, ErrSchemaViolation},
+		{"unique property shared", []*NodeInfo{
+			state("or", map[string]interface{}{"name": "Oregon", "code": "OR"}),
+			state("oregon", map[string]interface{}{"name": "Oregon", "code": "OR"}),
+		}, nil, ErrSchemaViolation},
+		{"unique property missing", []*NodeInfo{
+			state("or", map[string]interface{}{"name": "Oregon"}),
+			state("wa", map[string]interface{}{"name": "Washington"}),
+		}, nil, nil},
+		{"required edge property missing", []*NodeInfo{
+			state("or", map[string]interface{}{"name": "Oregon", "code": "OR"}),
+			state("wa", map[string]interface{}{"name": "Washington", "code": "WA"}),
+		}, []*EdgeInfo{
+			{Label: "BORDERS", Id: "or-wa", Left: state("or", nil), Right: state("wa", nil), Attrs: &map[string]interface{}{}},
+		}, ErrSchemaViolation},
+		{"other labels not checked", []*NodeInfo{
+			{Label: "County", Id: "multnomah", Attrs: &map[string]interface{}{}},
+		}, nil, nil},
+	}
+
+	for name, open := range schemaBackends {
+		for _, test := range tests {
+			t.Run(name+"/"+test.name, func(t *testing.T) {
+				svc := open(t)
+				for _, node := range test.nodes {
+					if err := svc.UpdateNode(context.Background(), node, true); err != nil {
+						t.Fatal(err)
+					}
+				}
+				for _, edge := range test.edges {
+					if err := svc.UpdateEdge(context.Background(), edge, true); err != nil {
+						t.Fatal(err)
+					}
+				}
+				err := svc.ApplySchema(context.Background(), testSchema)
+				if test.want == nil && err != nil {
+					t.Fatalf("got %v, want no error", err)
+				}
+				if test.want != nil && !errors.Is(err, test.want) {
+					t.Fatalf("got %v, want %v", err, test.want)
+				}
+			})
+		}
+	}
+}
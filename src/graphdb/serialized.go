@@ -0,0 +1,215 @@
+package graphdb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+type entityLock struct {
+	mutex sync.Mutex
+	refs  int
+}
+
+// entityLocks holds a mutex per node or edge being written, released once nobody waits for it
+type entityLocks struct {
+	mutex sync.Mutex
+	locks map[string]*entityLock
+}
+
+// lock locks the entities of keys in order, so that batches sharing entities can't deadlock,
+// and returns the function unlocking them
+func (l *entityLocks) lock(keys []string) func() {
+	sorted := make([]string, 0, len(keys))
+	seen := make(map[string]bool)
+	for _, key := range keys {
+		if !seen[key] {
+			seen[key] = true
+			sorted = append(sorted, key)
+		}
+	}
+	sort.Strings(sorted)
+
+	held := make([]*entityLock, 0, len(sorted))
+	l.mutex.Lock()
+	for _, key := range sorted {
+		lock, found := l.locks[key]
+		if !found {
+			lock = &entityLock{}
+			l.locks[key] = lock
+		}
+		lock.refs++
+		held = append(held, lock)
+	}
+	l.mutex.Unlock()
+	for _, lock := range held {
+		lock.mutex.Lock()
+	}
+
+	return func() {
+		for i := len(held) - 1; i >= 0; i-- {
+			held[i].mutex.Unlock()
+		}
+		l.mutex.Lock()
+		defer l.mutex.Unlock()
+		for i, key := range sorted {
+			if held[i].refs--; held[i].refs == 0 {
+				delete(l.locks, key)
+			}
+		}
+	}
+}
+
+func nodeLockKey(node *NodeInfo) string {
+	return fmt.Sprintf("node %s %s", node.Label, node.Id)
+}
+
+func edgeLockKey(edge *EdgeInfo) string {
+	return fmt.Sprintf("edge %s %s %s %s %s %s", edge.Label, edge.Id, edge.Left.Label, edge.Left.Id, edge.Right.Label, edge.Right.Id)
+}
+
+type storedContextKey struct{}
+
+// storedEntities are the nodes and edges read by SerializedGraphService before a write, nil for
+// those which don't exist
+type storedEntities struct {
+	nodes map[memoryNodeKey]*NodeInfo
+	edges map[memoryEdgeKey]*EdgeInfo
+}
+
+// storedNode returns the stored node the write in progress applies to, as read by SerializedGraphService,
+// or reads it from svc outside of a serialized write. The returned node must not be modified.
+func storedNode(ctx context.Context, svc GraphDbService, node *NodeInfo) (*NodeInfo, error) {
+	if stored, ok := ctx.Value(storedContextKey{}).(*storedEntities); ok {
+		if found, read := stored.nodes[nodeKeyOf(node)]; read {
+			return found, nil
+		}
+	}
+	return svc.GetNode(ctx, node)
+}
+
+// storedEdge is storedNode for edges
+func storedEdge(ctx context.Context, svc GraphDbService, edge *EdgeInfo) (*EdgeInfo, error) {
+	if stored, ok := ctx.Value(storedContextKey{}).(*storedEntities); ok {
+		if found, read := stored.edges[edgeKeyOf(edge)]; read {
+			return found, nil
+		}
+	}
+	return svc.GetEdge(ctx, edge)
+}
+
+// SerializedGraphService is a GraphDbService decorator serializing the writes of a same node or edge.
+// The decorators it wraps merge a write with the stored entity, e.g. appending to _sourceUrls or _history,
+// which concurrent download callbacks writing the same Person or Bill would otherwise overwrite.
+// The stored entities are read once per write and passed to those decorators, see storedNode.
+type SerializedGraphService struct {
+	GraphDbService
+	locks *entityLocks
+}
+
+func (s *SerializedGraphService) readNodes(ctx context.Context, nodes []*NodeInfo) (context.Context, error) {
+	stored := &storedEntities{nodes: make(map[memoryNodeKey]*NodeInfo)}
+	for _, node := range nodes {
+		found, err := s.GraphDbService.GetNode(ctx, node)
+		if err != nil {
+			return nil, err
+		}
+		stored.nodes[nodeKeyOf(node)] = found
+	}
+	return context.WithValue(ctx, storedContextKey{}, stored), nil
+}
+
+func (s *SerializedGraphService) readEdges(ctx context.Context, edges []*EdgeInfo) (context.Context, error) {
+	stored := &storedEntities{edges: make(map[memoryEdgeKey]*EdgeInfo)}
+	for _, edge := range edges {
+		found, err := s.GraphDbService.GetEdge(ctx, edge)
+		if err != nil {
+			return nil, err
+		}
+		stored.edges[edgeKeyOf(edge)] = found
+	}
+	return context.WithValue(ctx, storedContextKey{}, stored), nil
+}
+
+func (s *SerializedGraphService) lockNodes(nodes []*NodeInfo) func() {
+	keys := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		keys = append(keys, nodeLockKey(node))
+	}
+	return s.locks.lock(keys)
+}
+
+func (s *SerializedGraphService) lockEdges(edges []*EdgeInfo) func() {
+	keys := make([]string, 0, len(edges))
+	for _, edge := range edges {
+		keys = append(keys, edgeLockKey(edge))
+	}
+	return s.locks.lock(keys)
+}
+
+// CreateNode implements GraphDbService.
+func (s *SerializedGraphService) CreateNode(ctx context.Context, node *NodeInfo) error {
+	defer s.lockNodes([]*NodeInfo{node})()
+	return s.GraphDbService.CreateNode(ctx, node)
+}
+
+// DeleteNode implements GraphDbService.
+func (s *SerializedGraphService) DeleteNode(ctx context.Context, node *NodeInfo) error {
+	defer s.lockNodes([]*NodeInfo{node})()
+	return s.GraphDbService.DeleteNode(ctx, node)
+}
+
+// UpdateNode implements GraphDbService.
+func (s *SerializedGraphService) UpdateNode(ctx context.Context, node *NodeInfo, allowUpsert bool) error {
+	nodes := []*NodeInfo{node}
+	defer s.lockNodes(nodes)()
+	ctx, err := s.readNodes(ctx, nodes)
+	if err != nil {
+		return err
+	}
+	return s.GraphDbService.UpdateNode(ctx, node, allowUpsert)
+}
+
+// UpdateNodes implements GraphDbService.
+func (s *SerializedGraphService) UpdateNodes(ctx context.Context, nodes []*NodeInfo, allowUpsert bool) error {
+	defer s.lockNodes(nodes)()
+	ctx, err := s.readNodes(ctx, nodes)
+	if err != nil {
+		return err
+	}
+	return s.GraphDbService.UpdateNodes(ctx, nodes, allowUpsert)
+}
+
+// UpdateEdge implements GraphDbService.
+func (s *SerializedGraphService) UpdateEdge(ctx context.Context, edge *EdgeInfo, allowUpsert bool) error {
+	edges := []*EdgeInfo{edge}
+	defer s.lockEdges(edges)()
+	ctx, err := s.readEdges(ctx, edges)
+	if err != nil {
+		return err
+	}
+	return s.GraphDbService.UpdateEdge(ctx, edge, allowUpsert)
+}
+
+// UpdateEdges implements GraphDbService.
+func (s *SerializedGraphService) UpdateEdges(ctx context.Context, edges []*EdgeInfo, allowUpsert bool) error {
+	defer s.lockEdges(edges)()
+	ctx, err := s.readEdges(ctx, edges)
+	if err != nil {
+		return err
+	}
+	return s.GraphDbService.UpdateEdges(ctx, edges, allowUpsert)
+}
+
+// Unwrap returns the decorated service
+func (s *SerializedGraphService) Unwrap() GraphDbService {
+	return s.GraphDbService
+}
+
+func NewSerializedGraphService(svc GraphDbService) *SerializedGraphService {
+	return &SerializedGraphService{
+		GraphDbService: svc,
+		locks:          &entityLocks{locks: make(map[string]*entityLock)},
+	}
+}
@@ -0,0 +1,122 @@
+package graphdb
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingGraphService counts the reads of the stored entities, returning node reads after delay
+// to widen the window between the read and the write of a decorator
+type countingGraphService struct {
+	GraphDbService
+	reads atomic.Int32
+	delay time.Duration
+}
+
+func (c *countingGraphService) GetNode(ctx context.Context, node *NodeInfo) (*NodeInfo, error) {
+	c.reads.Add(1)
+	defer time.Sleep(c.delay)
+	return c.GraphDbService.GetNode(ctx, node)
+}
+
+func (c *countingGraphService) GetEdge(ctx context.Context, edge *EdgeInfo) (*EdgeInfo, error) {
+	c.reads.Add(1)
+	return c.GraphDbService.GetEdge(ctx, edge)
+}
+
+// writeConcurrently upserts the same Person from n goroutines, each from its own source url
+func writeConcurrently(t *testing.T, svc GraphDbService, n int) {
+	wg := &sync.WaitGroup{}
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := svc.UpdateNode(context.Background(), &NodeInfo{
+				Label:      "Person",
+				Id:         "a",
+				Attrs:      &map[string]interface{}{"seen": i},
+				Provenance: &Provenance{SourceUrl: fmt.Sprintf("https://example.org/%d", i)},
+			}, true)
+			if err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestSerializedProvenanceKeepsEverySource(t *testing.T) {
+	backend := &countingGraphService{GraphDbService: NewMemoryGraphService(), delay: time.Millisecond}
+	svc := NewSerializedGraphService(NewProvenanceGraphService(backend, nil))
+	writeConcurrently(t, svc, 50)
+
+	node, err := backend.GetNode(context.Background(), &NodeInfo{Label: "Person", Id: "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if urls := mergeLists((*node.Attrs)[PROP_SOURCE_URLS]); len(urls) != 50 {
+		t.Fatalf("%d source urls kept, want 50", len(urls))
+	}
+}
+
+func TestSerializedReadsOncePerWrite(t *testing.T) {
+	backend := &countingGraphService{GraphDbService: NewMemoryGraphService()}
+	svc := NewSerializedGraphService(
+		NewChangeGraphService(NewTemporalGraphService(NewProvenanceGraphService(backend, nil), nil), OpenChangeStream(nil, "")),
+	)
+	ctx := context.Background()
+	svc.UpdateNode(ctx, person("a"), true)
+	svc.UpdateNode(ctx, person("b"), true)
+	svc.UpdateEdge(ctx, voted("a", "b"), true)
+
+	if reads := backend.reads.Load(); reads != 3 {
+		t.Fatalf("%d reads for 3 writes", reads)
+	}
+}
+
+func TestEntityLocksRelease(t *testing.T) {
+	locks := &entityLocks{locks: make(map[string]*entityLock)}
+	unlock := locks.lock([]string{"b", "a", "b"})
+	done := make(chan struct{})
+	go func() {
+		locks.lock([]string{"a"})()
+		close(done)
+	}()
+	unlock()
+	<-done
+	locks.mutex.Lock()
+	defer locks.mutex.Unlock()
+	if len(locks.locks) != 0 {
+		t.Fatalf("%d locks left after release", len(locks.locks))
+	}
+}
+
+func TestSerializedTemporalKeepsEveryVersion(t *testing.T) {
+	backend := &countingGraphService{GraphDbService: NewMemoryGraphService(), delay: time.Millisecond}
+	svc := NewSerializedGraphService(NewTemporalGraphService(backend, nil))
+	writeConcurrently(t, svc, 50)
+
+	node, err := backend.GetNode(context.Background(), &NodeInfo{Label: "Person", Id: "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if history := ParseHistory(node.Attrs); len(history) != 50 {
+		t.Fatalf("%d versions of seen kept, want 50", len(history))
+	}
+}
+
+func TestSerializedChangesCreateOnce(t *testing.T) {
+	backend := &countingGraphService{GraphDbService: NewMemoryGraphService(), delay: time.Millisecond}
+	stream := OpenChangeStream(nil, "")
+	svc := NewSerializedGraphService(NewChangeGraphService(backend, stream))
+	writeConcurrently(t, svc, 50)
+
+	counts := stream.Summary()["Person"]
+	if counts[CHANGE_CREATED] != 1 || counts[CHANGE_MODIFIED] != 49 {
+		t.Fatalf("%d created and %d modified, want 1 and 49", counts[CHANGE_CREATED], counts[CHANGE_MODIFIED])
+	}
+}
@@ -0,0 +1,509 @@
+package graphdb
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/nedvisol/go-connectdots/config"
+	"go.uber.org/fx"
+	_ "modernc.org/sqlite"
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS nodes (
+	pk    INTEGER PRIMARY KEY AUTOINCREMENT,
+	label TEXT NOT NULL,
+	_id   TEXT NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS nodes_label_id ON nodes (label, _id);
+CREATE INDEX IF NOT EXISTS nodes_id ON nodes (_id);
+
+CREATE TABLE IF NOT EXISTS edges (
+	pk       INTEGER PRIMARY KEY AUTOINCREMENT,
+	label    TEXT NOT NULL,
+	_id      TEXT NOT NULL,
+	left_pk  INTEGER NOT NULL REFERENCES nodes (pk) ON DELETE CASCADE,
+	right_pk INTEGER NOT NULL REFERENCES nodes (pk) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS edges_pattern ON edges (label, _id, left_pk, right_pk);
+CREATE INDEX IF NOT EXISTS edges_id ON edges (_id);
+CREATE INDEX IF NOT EXISTS edges_left ON edges (left_pk);
+CREATE INDEX IF NOT EXISTS edges_right ON edges (right_pk);
+
+CREATE TABLE IF NOT EXISTS properties (
+	owner    TEXT NOT NULL CHECK (owner IN ('node', 'edge')),
+	owner_pk INTEGER NOT NULL,
+	props    TEXT NOT NULL,
+	PRIMARY KEY (owner, owner_pk)
+);
+`
+
+const ownerNode = "node"
+const ownerEdge = "edge"
+
+// SqliteGraphService is a GraphDbService persisting the graph into a single SQLite file.
+// Properties are stored as JSON documents keyed by the owning node or edge.
+type SqliteGraphService struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+func sqliteNodePk(ctx context.Context, tx *sql.Tx, node *NodeInfo) (int64, bool, error) {
+	var pk int64
+	err := tx.QueryRowContext(ctx, "SELECT pk FROM nodes WHERE label = ? AND _id = ?", node.Label, node.Id).Scan(&pk)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return pk, true, nil
+}
+
+func sqliteEdgePk(ctx context.Context, tx *sql.Tx, edge *EdgeInfo, leftPk int64, rightPk int64) (int64, bool, error) {
+	var pk int64
+	err := tx.QueryRowContext(ctx,
+		"SELECT pk FROM edges WHERE label = ? AND _id = ? AND left_pk = ? AND right_pk = ?",
+		edge.Label, edge.Id, leftPk, rightPk,
+	).Scan(&pk)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return pk, true, nil
+}
+
+// decodeProps parses a JSON properties document, keeping integers as int64 like the neo4j driver does
+func decodeProps(data string) (map[string]interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewBufferString(data))
+	decoder.UseNumber()
+	props := make(map[string]interface{})
+	if err := decoder.Decode(&props); err != nil {
+		return nil, err
+	}
+	for key, value := range props {
+		props[key] = normalizeJsonValue(value)
+	}
+	return props, nil
+}
+
+func normalizeJsonValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case []interface{}:
+		for i := range v {
+			v[i] = normalizeJsonValue(v[i])
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+func sqliteReadProps(ctx context.Context, tx *sql.Tx, owner string, ownerPk int64) (map[string]interface{}, error) {
+	var data string
+	err := tx.QueryRowContext(ctx, "SELECT props FROM properties WHERE owner = ? AND owner_pk = ?", owner, ownerPk).Scan(&data)
+	if err == sql.ErrNoRows {
+		return make(map[string]interface{}), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeProps(data)
+}
+
+func sqliteWriteProps(ctx context.Context, tx *sql.Tx, owner string, ownerPk int64, props map[string]interface{}) error {
+	data, err := json.Marshal(props)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+	INSERT INTO properties (owner, owner_pk, props) VALUES (?, ?, ?)
+	ON CONFLICT (owner, owner_pk) DO UPDATE SET props = excluded.props
+	`, owner, ownerPk, string(data))
+	return err
+}
+
+// sqliteMergeProps applies attrs on top of the stored properties, same as SET in neo4j.go
+func sqliteMergeProps(ctx context.Context, tx *sql.Tx, owner string, ownerPk int64, attrs *map[string]interface{}) error {
+	props, err := sqliteReadProps(ctx, tx, owner, ownerPk)
+	if err != nil {
+		return err
+	}
+	setAttrs(props, attrs)
+	return sqliteWriteProps(ctx, tx, owner, ownerPk, props)
+}
+
+func (s *SqliteGraphService) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	ctx, cancel := withQueryTimeout(ctx, s.timeout)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// CreateNode implements GraphDbService.
+func (s *SqliteGraphService) CreateNode(ctx context.Context, node *NodeInfo) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		if _, exists, err := sqliteNodePk(ctx, tx, node); err != nil {
+			return err
+		} else if exists {
+			return fmt.Errorf("%w: %s %s", ErrNodeExists, node.Label, node.Id)
+		}
+		result, err := tx.ExecContext(ctx, "INSERT INTO nodes (label, _id) VALUES (?, ?)", node.Label, node.Id)
+		if err != nil {
+			return err
+		}
+		pk, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		return sqliteWriteProps(ctx, tx, ownerNode, pk, copyAttrs(node.Attrs))
+	})
+}
+
+func sqliteUpdateNode(ctx context.Context, tx *sql.Tx, node *NodeInfo, allowUpsert bool) error {
+	pk, exists, err := sqliteNodePk(ctx, tx, node)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		if !allowUpsert {
+			return fmt.Errorf("%w: %s %s", ErrNodeNotFound, node.Label, node.Id)
+		}
+		result, err := tx.ExecContext(ctx, "INSERT INTO nodes (label, _id) VALUES (?, ?)", node.Label, node.Id)
+		if err != nil {
+			return err
+		}
+		if pk, err = result.LastInsertId(); err != nil {
+			return err
+		}
+	}
+	return sqliteMergeProps(ctx, tx, ownerNode, pk, node.Attrs)
+}
+
+// UpdateNode implements GraphDbService.
+func (s *SqliteGraphService) UpdateNode(ctx context.Context, node *NodeInfo, allowUpsert bool) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		return sqliteUpdateNode(ctx, tx, node, allowUpsert)
+	})
+}
+
+// UpdateNodes implements GraphDbService.
+func (s *SqliteGraphService) UpdateNodes(ctx context.Context, nodes []*NodeInfo, allowUpsert bool) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		for _, node := range nodes {
+			if err := sqliteUpdateNode(ctx, tx, node, allowUpsert); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteNode implements GraphDbService. Edges attached to the node are removed with it.
+func (s *SqliteGraphService) DeleteNode(ctx context.Context, node *NodeInfo) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		pk, exists, err := sqliteNodePk(ctx, tx, node)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("%w: %s %s", ErrNodeNotFound, node.Label, node.Id)
+		}
+		_, err = tx.ExecContext(ctx, `
+		DELETE FROM properties WHERE owner = 'edge' AND owner_pk IN (
+			SELECT pk FROM edges WHERE left_pk = ? OR right_pk = ?
+		)`, pk, pk)
+		if err != nil {
+			return err
+		}
+		if _, err = tx.ExecContext(ctx, "DELETE FROM edges WHERE left_pk = ? OR right_pk = ?", pk, pk); err != nil {
+			return err
+		}
+		if _, err = tx.ExecContext(ctx, "DELETE FROM properties WHERE owner = 'node' AND owner_pk = ?", pk); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, "DELETE FROM nodes WHERE pk = ?", pk)
+		return err
+	})
+}
+
+func sqliteUpdateEdge(ctx context.Context, tx *sql.Tx, edge *EdgeInfo, allowUpsert bool) error {
+	leftPk, exists, err := sqliteNodePk(ctx, tx, edge.Left)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("%w: %s %s", ErrNodeNotFound, edge.Left.Label, edge.Left.Id)
+	}
+	rightPk, exists, err := sqliteNodePk(ctx, tx, edge.Right)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("%w: %s %s", ErrNodeNotFound, edge.Right.Label, edge.Right.Id)
+	}
+
+	pk, exists, err := sqliteEdgePk(ctx, tx, edge, leftPk, rightPk)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		if !allowUpsert {
+			return fmt.Errorf("%w: %s %s", ErrEdgeNotFound, edge.Label, edge.Id)
+		}
+		result, err := tx.ExecContext(ctx,
+			"INSERT INTO edges (label, _id, left_pk, right_pk) VALUES (?, ?, ?, ?)",
+			edge.Label, edge.Id, leftPk, rightPk,
+		)
+		if err != nil {
+			return err
+		}
+		if pk, err = result.LastInsertId(); err != nil {
+			return err
+		}
+	}
+	return sqliteMergeProps(ctx, tx, ownerEdge, pk, edge.Attrs)
+}
+
+// UpdateEdge implements GraphDbService.
+func (s *SqliteGraphService) UpdateEdge(ctx context.Context, edge *EdgeInfo, allowUpsert bool) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		return sqliteUpdateEdge(ctx, tx, edge, allowUpsert)
+	})
+}
+
+// UpdateEdges implements GraphDbService.
+func (s *SqliteGraphService) UpdateEdges(ctx context.Context, edges []*EdgeInfo, allowUpsert bool) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		for _, edge := range edges {
+			if err := sqliteUpdateEdge(ctx, tx, edge, allowUpsert); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetNode implements GraphDbService.
+func (s *SqliteGraphService) GetNode(ctx context.Context, node *NodeInfo) (*NodeInfo, error) {
+	var found *NodeInfo
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		pk, exists, err := sqliteNodePk(ctx, tx, node)
+		if err != nil || !exists {
+			return err
+		}
+		props, err := sqliteReadProps(ctx, tx, ownerNode, pk)
+		if err != nil {
+			return err
+		}
+		found = &NodeInfo{Label: node.Label, Id: node.Id, Attrs: &props}
+		return nil
+	})
+	return found, err
+}
+
+// GetEdge implements GraphDbService.
+func (s *SqliteGraphService) GetEdge(ctx context.Context, edge *EdgeInfo) (*EdgeInfo, error) {
+	var found *EdgeInfo
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		leftPk, exists, err := sqliteNodePk(ctx, tx, edge.Left)
+		if err != nil || !exists {
+			return err
+		}
+		rightPk, exists, err := sqliteNodePk(ctx, tx, edge.Right)
+		if err != nil || !exists {
+			return err
+		}
+		pk, exists, err := sqliteEdgePk(ctx, tx, edge, leftPk, rightPk)
+		if err != nil || !exists {
+			return err
+		}
+		props, err := sqliteReadProps(ctx, tx, ownerEdge, pk)
+		if err != nil {
+			return err
+		}
+		found = &EdgeInfo{
+			Label: edge.Label,
+			Id:    edge.Id,
+			Attrs: &props,
+			Left:  &NodeInfo{Label: edge.Left.Label, Id: edge.Left.Id},
+			Right: &NodeInfo{Label: edge.Right.Label, Id: edge.Right.Id},
+		}
+		return nil
+	})
+	return found, err
+}
+
+// sqliteQueryer is a *sql.DB or a *sql.Tx
+type sqliteQueryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// FindNodes implements GraphDbService.
+func (s *SqliteGraphService) FindNodes(ctx context.Context, label string) ([]*NodeInfo, error) {
+	ctx, cancel := withQueryTimeout(ctx, s.timeout)
+	defer cancel()
+	return sqliteFindNodes(ctx, s.db, label)
+}
+
+func sqliteFindNodes(ctx context.Context, q sqliteQueryer, label string) ([]*NodeInfo, error) {
+	rows, err := q.QueryContext(ctx, `
+	SELECT n.label, n._id, COALESCE(p.props, '{}')
+	FROM nodes n
+	LEFT JOIN properties p ON p.owner = 'node' AND p.owner_pk = n.pk
+	WHERE ? = '' OR n.label = ?
+	ORDER BY n.label, n._id
+	`, label, label)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	nodes := make([]*NodeInfo, 0)
+	for rows.Next() {
+		var nodeLabel, id, data string
+		if err := rows.Scan(&nodeLabel, &id, &data); err != nil {
+			return nil, err
+		}
+		props, err := decodeProps(data)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, &NodeInfo{Label: nodeLabel, Id: id, Attrs: &props})
+	}
+	return nodes, rows.Err()
+}
+
+// FindEdges implements GraphDbService.
+func (s *SqliteGraphService) FindEdges(ctx context.Context, label string) ([]*EdgeInfo, error) {
+	ctx, cancel := withQueryTimeout(ctx, s.timeout)
+	defer cancel()
+	return sqliteFindEdges(ctx, s.db, label)
+}
+
+func sqliteFindEdges(ctx context.Context, q sqliteQueryer, label string) ([]*EdgeInfo, error) {
+	rows, err := q.QueryContext(ctx, `
+	SELECT e.label, e._id, COALESCE(p.props, '{}'), l.label, l._id, r.label, r._id
+	FROM edges e
+	JOIN nodes l ON l.pk = e.left_pk
+	JOIN nodes r ON r.pk = e.right_pk
+	LEFT JOIN properties p ON p.owner = 'edge' AND p.owner_pk = e.pk
+	WHERE ? = '' OR e.label = ?
+	ORDER BY e.label, e._id, l._id, r._id
+	`, label, label)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	edges := make([]*EdgeInfo, 0)
+	for rows.Next() {
+		var edgeLabel, id, data, leftLabel, leftId, rightLabel, rightId string
+		if err := rows.Scan(&edgeLabel, &id, &data, &leftLabel, &leftId, &rightLabel, &rightId); err != nil {
+			return nil, err
+		}
+		props, err := decodeProps(data)
+		if err != nil {
+			return nil, err
+		}
+		edges = append(edges, &EdgeInfo{
+			Label: edgeLabel,
+			Id:    id,
+			Attrs: &props,
+			Left:  &NodeInfo{Label: leftLabel, Id: leftId},
+			Right: &NodeInfo{Label: rightLabel, Id: rightId},
+		})
+	}
+	return edges, rows.Err()
+}
+
+// ReadGraph implements GraphReader.
+func (s *SqliteGraphService) ReadGraph(ctx context.Context) ([]*NodeInfo, []*EdgeInfo, error) {
+	var nodes []*NodeInfo
+	var edges []*EdgeInfo
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		if nodes, err = sqliteFindNodes(ctx, tx, ""); err != nil {
+			return err
+		}
+		edges, err = sqliteFindEdges(ctx, tx, "")
+		return err
+	})
+	return nodes, edges, err
+}
+
+// Ping implements GraphDbService.
+func (s *SqliteGraphService) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// ApplySchema implements GraphDbService. The unique (label, _id) indexes are part of the table
+// definitions, so this creates indexes for the other declared properties and validates the stored graph.
+func (s *SqliteGraphService) ApplySchema(ctx context.Context, schema *Schema) error {
+	for _, labelSchema := range schema.Labels {
+		for _, prop := range labelSchema.Indexed {
+			if prop == "_id" {
+				continue
+			}
+			_, err := s.db.ExecContext(ctx, fmt.Sprintf(
+				"CREATE INDEX IF NOT EXISTS %s ON properties (json_extract(props, '$.%s')) WHERE owner = 'node'",
+				schemaObjectName("index", labelSchema.Label, prop), prop,
+			))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return validateSchema(ctx, s, schema)
+}
+
+func OpenSqliteGraphService(path string, timeout time.Duration) (*SqliteGraphService, error) {
+	db, err := sql.Open("sqlite", fmt.Sprintf("file:%s?_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)", path))
+	if err != nil {
+		return nil, err
+	}
+	// download callbacks write concurrently, sqlite only has a single writer
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &SqliteGraphService{db: db, timeout: timeout}, nil
+}
+
+func NewSqliteGraphService(lifecycle fx.Lifecycle, cfg *config.Config) (GraphDbService, error) {
+	svc, err := OpenSqliteGraphService(cfg.GraphDb.SqlitePath, cfg.GraphDb.QueryTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	lifecycle.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			fmt.Println("Application is stopping. closed sqlite graph database")
+			return svc.db.Close()
+		},
+	})
+
+	return svc, nil
+}
@@ -0,0 +1,312 @@
+package graphdb
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// temporal properties, _validFrom and _validTo bound the lifetime of the node or edge itself and
+// _history holds a JSON list of HistoryEntry since neo4j can't store maps as properties
+const PROP_VALID_FROM = "_validFrom"
+const PROP_VALID_TO = "_validTo"
+const PROP_HISTORY = "_history"
+
+// HistoryEntry is the value of one attribute over a period, ValidTo is empty while it is current
+type HistoryEntry struct {
+	Attr      string      `json:"attr"`
+	Value     interface{} `json:"value"`
+	ValidFrom string      `json:"validFrom"`
+	ValidTo   string      `json:"validTo,omitempty"`
+}
+
+// TemporalGraphService is a GraphDbService decorator recording attribute changes in a history
+// instead of losing the overwritten values. A change is valid from the _validFrom attribute of the
+// write if given, otherwise from the fetch time of its provenance, otherwise from now.
+// The history is rewritten from the stored one, so concurrent writes of a same entity must go through
+// a SerializedGraphService.
+type TemporalGraphService struct {
+	GraphDbService
+	now func() time.Time
+}
+
+func formatTimestamp(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+func parseTimestamp(value interface{}) (time.Time, bool) {
+	text, ok := value.(string)
+	if !ok || text == "" {
+		return time.Time{}, false
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, text); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func isSystemAttr(key string) bool {
+	return strings.HasPrefix(key, "_")
+}
+
+func sameValue(a interface{}, b interface{}) bool {
+	aJson, aErr := json.Marshal(a)
+	bJson, bErr := json.Marshal(b)
+	return aErr == nil && bErr == nil && string(aJson) == string(bJson)
+}
+
+// ParseHistory reads the history stored in attrs
+func ParseHistory(attrs *map[string]interface{}) []*HistoryEntry {
+	history := make([]*HistoryEntry, 0)
+	if attrs == nil {
+		return history
+	}
+	if data, ok := (*attrs)[PROP_HISTORY].(string); ok {
+		json.Unmarshal([]byte(data), &history)
+	}
+	return history
+}
+
+func (t *TemporalGraphService) validFrom(attrs *map[string]interface{}, provenance *Provenance) time.Time {
+	if attrs != nil {
+		if at, ok := parseTimestamp((*attrs)[PROP_VALID_FROM]); ok {
+			return at
+		}
+	}
+	if provenance != nil && !provenance.FetchedAt.IsZero() {
+		return provenance.FetchedAt
+	}
+	return t.now()
+}
+
+// version returns a copy of attrs with the history updated for the attributes that changed
+// compared to existing, the stored properties of the entity or nil when it is new. The entity keeps
+// the _validFrom it was first written with, and a write of a closed entity not closing it reopens it.
+func (t *TemporalGraphService) version(attrs *map[string]interface{}, provenance *Provenance, existing *map[string]interface{}) *map[string]interface{} {
+	versioned := copyAttrs(attrs)
+	at := formatTimestamp(t.validFrom(attrs, provenance))
+	history := ParseHistory(existing)
+
+	open := make(map[string]*HistoryEntry)
+	for _, entry := range history {
+		if entry.ValidTo == "" {
+			open[entry.Attr] = entry
+		}
+	}
+	for key, value := range versioned {
+		if isSystemAttr(key) {
+			continue
+		}
+		if current, found := open[key]; found {
+			if sameValue(current.Value, value) {
+				continue
+			}
+			current.ValidTo = at
+		}
+		history = append(history, &HistoryEntry{Attr: key, Value: value, ValidFrom: at})
+	}
+
+	data, _ := json.Marshal(history)
+	versioned[PROP_HISTORY] = string(data)
+	if existing != nil && (*existing)[PROP_VALID_FROM] != nil {
+		delete(versioned, PROP_VALID_FROM)
+	} else if _, given := versioned[PROP_VALID_FROM]; !given {
+		versioned[PROP_VALID_FROM] = at
+	}
+	if _, closing := versioned[PROP_VALID_TO]; !closing && existing != nil && (*existing)[PROP_VALID_TO] != nil {
+		versioned[PROP_VALID_TO] = nil
+	}
+	return &versioned
+}
+
+// CreateNode implements GraphDbService.
+func (t *TemporalGraphService) CreateNode(ctx context.Context, node *NodeInfo) error {
+	versioned := *node
+	versioned.Attrs = t.version(node.Attrs, node.Provenance, nil)
+	return t.GraphDbService.CreateNode(ctx, &versioned)
+}
+
+func (t *TemporalGraphService) versionNode(ctx context.Context, node *NodeInfo) (*NodeInfo, error) {
+	existing, err := storedNode(ctx, t.GraphDbService, node)
+	if err != nil {
+		return nil, err
+	}
+	var existingAttrs *map[string]interface{}
+	if existing != nil {
+		existingAttrs = existing.Attrs
+	}
+	versioned := *node
+	versioned.Attrs = t.version(node.Attrs, node.Provenance, existingAttrs)
+	return &versioned, nil
+}
+
+func (t *TemporalGraphService) versionEdge(ctx context.Context, edge *EdgeInfo) (*EdgeInfo, error) {
+	existing, err := storedEdge(ctx, t.GraphDbService, edge)
+	if err != nil {
+		return nil, err
+	}
+	var existingAttrs *map[string]interface{}
+	if existing != nil {
+		existingAttrs = existing.Attrs
+	}
+	versioned := *edge
+	versioned.Attrs = t.version(edge.Attrs, edge.Provenance, existingAttrs)
+	return &versioned, nil
+}
+
+// UpdateNode implements GraphDbService.
+func (t *TemporalGraphService) UpdateNode(ctx context.Context, node *NodeInfo, allowUpsert bool) error {
+	versioned, err := t.versionNode(ctx, node)
+	if err != nil {
+		return err
+	}
+	return t.GraphDbService.UpdateNode(ctx, versioned, allowUpsert)
+}
+
+// UpdateNodes implements GraphDbService.
+func (t *TemporalGraphService) UpdateNodes(ctx context.Context, nodes []*NodeInfo, allowUpsert bool) error {
+	versioned := make([]*NodeInfo, 0, len(nodes))
+	for _, node := range nodes {
+		versionedNode, err := t.versionNode(ctx, node)
+		if err != nil {
+			return err
+		}
+		versioned = append(versioned, versionedNode)
+	}
+	return t.GraphDbService.UpdateNodes(ctx, versioned, allowUpsert)
+}
+
+// UpdateEdge implements GraphDbService.
+func (t *TemporalGraphService) UpdateEdge(ctx context.Context, edge *EdgeInfo, allowUpsert bool) error {
+	versioned, err := t.versionEdge(ctx, edge)
+	if err != nil {
+		return err
+	}
+	return t.GraphDbService.UpdateEdge(ctx, versioned, allowUpsert)
+}
+
+// UpdateEdges implements GraphDbService.
+func (t *TemporalGraphService) UpdateEdges(ctx context.Context, edges []*EdgeInfo, allowUpsert bool) error {
+	versioned := make([]*EdgeInfo, 0, len(edges))
+	for _, edge := range edges {
+		versionedEdge, err := t.versionEdge(ctx, edge)
+		if err != nil {
+			return err
+		}
+		versioned = append(versioned, versionedEdge)
+	}
+	return t.GraphDbService.UpdateEdges(ctx, versioned, allowUpsert)
+}
+
+// CloseEdge ends the lifetime of edge at the given time, e.g. a committee seat missing from the current
+// membership, as of reads leave it out from then on. A later write of the edge reopens it.
+func CloseEdge(ctx context.Context, svc GraphDbService, edge *EdgeInfo, at time.Time) error {
+	closed := *edge
+	closed.Attrs = &map[string]interface{}{PROP_VALID_TO: formatTimestamp(at)}
+	return svc.UpdateEdge(ctx, &closed, false)
+}
+
+// AsOf returns the attributes attrs had at the given time, or nil if the entity did not exist then.
+// Attributes without a history are returned as stored.
+func AsOf(attrs *map[string]interface{}, at time.Time) *map[string]interface{} {
+	if attrs == nil {
+		return nil
+	}
+	if validFrom, ok := parseTimestamp((*attrs)[PROP_VALID_FROM]); ok && at.Before(validFrom) {
+		return nil
+	}
+	if validTo, ok := parseTimestamp((*attrs)[PROP_VALID_TO]); ok && !at.Before(validTo) {
+		return nil
+	}
+
+	result := copyAttrs(attrs)
+	history := ParseHistory(attrs)
+	versionedAttrs := make(map[string]bool)
+	for _, entry := range history {
+		versionedAttrs[entry.Attr] = true
+	}
+	for attr := range versionedAttrs {
+		delete(result, attr)
+	}
+	for _, entry := range history {
+		validFrom, _ := parseTimestamp(entry.ValidFrom)
+		validTo, closed := parseTimestamp(entry.ValidTo)
+		if !at.Before(validFrom) && (!closed || at.Before(validTo)) {
+			result[entry.Attr] = entry.Value
+		}
+	}
+	return &result
+}
+
+// GetNodeAsOf returns node as it was at the given time, or nil if it did not exist then
+func GetNodeAsOf(ctx context.Context, svc GraphDbService, node *NodeInfo, at time.Time) (*NodeInfo, error) {
+	found, err := svc.GetNode(ctx, node)
+	if err != nil || found == nil {
+		return nil, err
+	}
+	if found.Attrs = AsOf(found.Attrs, at); found.Attrs == nil {
+		return nil, nil
+	}
+	return found, nil
+}
+
+// GetEdgeAsOf returns edge as it was at the given time, or nil if it did not exist then
+func GetEdgeAsOf(ctx context.Context, svc GraphDbService, edge *EdgeInfo, at time.Time) (*EdgeInfo, error) {
+	found, err := svc.GetEdge(ctx, edge)
+	if err != nil || found == nil {
+		return nil, err
+	}
+	if found.Attrs = AsOf(found.Attrs, at); found.Attrs == nil {
+		return nil, nil
+	}
+	return found, nil
+}
+
+// FindNodesAsOf returns the nodes with the given label which existed at the given time, as they were then
+func FindNodesAsOf(ctx context.Context, svc GraphDbService, label string, at time.Time) ([]*NodeInfo, error) {
+	nodes, err := svc.FindNodes(ctx, label)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*NodeInfo, 0, len(nodes))
+	for _, node := range nodes {
+		if node.Attrs = AsOf(node.Attrs, at); node.Attrs != nil {
+			result = append(result, node)
+		}
+	}
+	return result, nil
+}
+
+// FindEdgesAsOf returns the edges with the given label which existed at the given time, as they were then,
+// e.g. the SERVES_ON edges of a committee in a given year
+func FindEdgesAsOf(ctx context.Context, svc GraphDbService, label string, at time.Time) ([]*EdgeInfo, error) {
+	edges, err := svc.FindEdges(ctx, label)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*EdgeInfo, 0, len(edges))
+	for _, edge := range edges {
+		if edge.Attrs = AsOf(edge.Attrs, at); edge.Attrs != nil {
+			result = append(result, edge)
+		}
+	}
+	return result, nil
+}
+
+// Unwrap returns the decorated service
+func (t *TemporalGraphService) Unwrap() GraphDbService {
+	return t.GraphDbService
+}
+
+func NewTemporalGraphService(svc GraphDbService, now func() time.Time) *TemporalGraphService {
+	if now == nil {
+		now = time.Now
+	}
+	return &TemporalGraphService{
+		GraphDbService: svc,
+		now:            now,
+	}
+}
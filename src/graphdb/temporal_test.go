@@ -0,0 +1,286 @@
+package graphdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func day(date string) time.Time {
+	at, _ := time.Parse("2006-01-02", date)
+	return at
+}
+
+// temporalGraph returns a memory graph keeping history, with a clock set by the returned function
+func temporalGraph() (*TemporalGraphService, func(date string)) {
+	now := day("2023-01-01")
+	svc := NewTemporalGraphService(NewMemoryGraphService(), func() time.Time { return now })
+	return svc, func(date string) { now = day(date) }
+}
+
+func TestTemporalVersion(t *testing.T) {
+	tests := []struct {
+		name   string
+		writes []map[string]interface{}
+		want   []HistoryEntry
+	}{
+		{"first write opens every attribute", []map[string]interface{}{
+			{"party": "D", "state": "OR"},
+		}, []HistoryEntry{
+			{Attr: "party", Value: "D", ValidFrom: "2023-01-01T00:00:00Z"},
+			{Attr: "state", Value: "OR", ValidFrom: "2023-01-01T00:00:00Z"},
+		}},
+		{"same value keeps the entry open", []map[string]interface{}{
+			{"party": "D"},
+			{"party": "D"},
+		}, []HistoryEntry{
+			{Attr: "party", Value: "D", ValidFrom: "2023-01-01T00:00:00Z"},
+		}},
+		{"changed value closes the entry", []map[string]interface{}{
+			{"party": "R"},
+			{"party": "I"},
+			{"party": "D"},
+		}, []HistoryEntry{
+			{Attr: "party", Value: "R", ValidFrom: "2023-01-01T00:00:00Z", ValidTo: "2024-01-01T00:00:00Z"},
+			{Attr: "party", Value: "I", ValidFrom: "2024-01-01T00:00:00Z", ValidTo: "2025-01-01T00:00:00Z"},
+			{Attr: "party", Value: "D", ValidFrom: "2025-01-01T00:00:00Z"},
+		}},
+		{"valid from of a later write dates the change only", []map[string]interface{}{
+			{"party": "R"},
+			{"party": "D", PROP_VALID_FROM: "2023-06-01"},
+		}, []HistoryEntry{
+			{Attr: "party", Value: "R", ValidFrom: "2023-01-01T00:00:00Z", ValidTo: "2023-06-01T00:00:00Z"},
+			{Attr: "party", Value: "D", ValidFrom: "2023-06-01T00:00:00Z"},
+		}},
+		{"system attributes not versioned", []map[string]interface{}{
+			{"party": "D", "_sourceUrl": "https://api.congress.gov"},
+		}, []HistoryEntry{
+			{Attr: "party", Value: "D", ValidFrom: "2023-01-01T00:00:00Z"},
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			svc, setDay := temporalGraph()
+			for i, attrs := range test.writes {
+				setDay(fmt.Sprintf("%d-01-01", 2023+i))
+				if err := svc.UpdateNode(ctx, &NodeInfo{Label: "Person", Id: "W000779", Attrs: &attrs}, true); err != nil {
+					t.Fatal(err)
+				}
+			}
+			stored, err := svc.GetNode(ctx, &NodeInfo{Label: "Person", Id: "W000779"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make(map[HistoryEntry]bool)
+			for _, entry := range ParseHistory(stored.Attrs) {
+				got[*entry] = true
+			}
+			want := make(map[HistoryEntry]bool)
+			for _, entry := range test.want {
+				want[entry] = true
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("history %v, want %v", got, want)
+			}
+			if (*stored.Attrs)[PROP_VALID_FROM] != "2023-01-01T00:00:00Z" {
+				t.Fatalf("valid from %v, want the first write", (*stored.Attrs)[PROP_VALID_FROM])
+			}
+		})
+	}
+}
+
+func TestAsOf(t *testing.T) {
+	attrs := &map[string]interface{}{
+		"name":          "Ron Wyden",
+		PROP_VALID_FROM: "2020-01-01T00:00:00Z",
+		PROP_VALID_TO:   "2025-01-01T00:00:00Z",
+		PROP_HISTORY: `[{"attr":"party","value":"R","validFrom":"2020-01-01T00:00:00Z","validTo":"2022-01-01T00:00:00Z"},` +
+			`{"attr":"party","value":"D","validFrom":"2022-01-01T00:00:00Z"}]`,
+	}
+	tests := []struct {
+		name      string
+		at        string
+		wantParty interface{}
+		exists    bool
+	}{
+		{"before it existed", "2019-06-01", nil, false},
+		{"first value", "2020-01-01", "R", true},
+		{"until the change", "2021-12-31", "R", true},
+		{"from the change", "2022-01-01", "D", true},
+		{"open value", "2024-12-31", "D", true},
+		{"after it was closed", "2025-01-01", nil, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := AsOf(attrs, day(test.at))
+			if (got != nil) != test.exists {
+				t.Fatalf("got %v, want existing %v", got, test.exists)
+			}
+			if got == nil {
+				return
+			}
+			if (*got)["party"] != test.wantParty {
+				t.Fatalf("party %v, want %v", (*got)["party"], test.wantParty)
+			}
+			if (*got)["name"] != "Ron Wyden" {
+				t.Fatalf("name %v, want the stored unversioned value", (*got)["name"])
+			}
+		})
+	}
+
+	if AsOf(nil, day("2022-01-01")) != nil {
+		t.Fatal("AsOf of a missing entity should be nil")
+	}
+}
+
+func TestAsOfHelpers(t *testing.T) {
+	ctx := context.Background()
+	svc, setDay := temporalGraph()
+	member := &NodeInfo{Label: "Person", Id: "W000779", Attrs: &map[string]interface{}{"party": "R"}}
+	committee := &NodeInfo{Label: "Committee", Id: "ssfi00", Attrs: &map[string]interface{}{"name": "Finance"}}
+	svc.UpdateNode(ctx, member, true)
+	svc.UpdateNode(ctx, committee, true)
+	setDay("2024-01-01")
+	svc.UpdateNode(ctx, &NodeInfo{Label: "Person", Id: "W000779", Attrs: &map[string]interface{}{"party": "D"}}, true)
+	svc.UpdateEdge(ctx, &EdgeInfo{Label: "SERVES_ON", Id: "W000779-ssfi00", Left: member, Right: committee,
+		Attrs: &map[string]interface{}{"rank": 1}}, true)
+	svc.UpdateEdge(ctx, &EdgeInfo{Label: "SERVES_ON", Id: "W000779-ssfi01", Left: member, Right: committee,
+		Attrs: &map[string]interface{}{"rank": 2, PROP_VALID_TO: "2024-06-01T00:00:00Z"}}, true)
+
+	tests := []struct {
+		name      string
+		at        string
+		wantParty interface{}
+		wantEdges []string
+	}{
+		{"before the graph existed", "2022-06-01", nil, []string{}},
+		{"before the change", "2023-06-01", "R", []string{}},
+		{"while both seats are held", "2024-03-01", "D", []string{"W000779-ssfi00", "W000779-ssfi01"}},
+		{"after a seat was closed", "2024-07-01", "D", []string{"W000779-ssfi00"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			at := day(test.at)
+			node, err := GetNodeAsOf(ctx, svc, &NodeInfo{Label: "Person", Id: "W000779"}, at)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var party interface{}
+			if node != nil {
+				party = (*node.Attrs)["party"]
+			}
+			if party != test.wantParty {
+				t.Fatalf("party %v, want %v", party, test.wantParty)
+			}
+
+			nodes, err := FindNodesAsOf(ctx, svc, "Person", at)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(nodes) != map[bool]int{true: 1, false: 0}[test.wantParty != nil] {
+				t.Fatalf("found %d people", len(nodes))
+			}
+
+			edges, err := FindEdgesAsOf(ctx, svc, "SERVES_ON", at)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids := make([]string, 0)
+			for _, edge := range edges {
+				ids = append(ids, edge.Id)
+			}
+			if !sameIds(ids, test.wantEdges) {
+				t.Fatalf("edges %v, want %v", ids, test.wantEdges)
+			}
+			for _, id := range test.wantEdges {
+				edge, err := GetEdgeAsOf(ctx, svc, &EdgeInfo{Label: "SERVES_ON", Id: id, Left: member, Right: committee}, at)
+				if err != nil || edge == nil {
+					t.Fatalf("edge %s not found as of %s: %v", id, test.at, err)
+				}
+			}
+		})
+	}
+}
+
+func sameIds(got []string, want []string) bool {
+	seen := make(map[string]int)
+	for _, id := range got {
+		seen[id]++
+	}
+	for _, id := range want {
+		seen[id]--
+	}
+	for _, count := range seen {
+		if count != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestCloseEdge(t *testing.T) {
+	ctx := context.Background()
+	member := &NodeInfo{Label: "Person", Id: "W000779", Attrs: &map[string]interface{}{}}
+	committee := &NodeInfo{Label: "Committee", Id: "ssfi00", Attrs: &map[string]interface{}{}}
+	seat := func() *EdgeInfo {
+		return &EdgeInfo{Label: "SERVES_ON", Id: "W000779-ssfi00", Left: member, Right: committee, Attrs: &map[string]interface{}{"rank": 1}}
+	}
+
+	tests := []struct {
+		name        string
+		closedAt    string
+		rewrittenAt string // day the crawl reports the seat again, if it does
+		at          string
+		want        bool
+	}{
+		{"before the seat", "", "", "2022-06-01", false},
+		{"never closed", "", "", "2030-01-01", true},
+		{"until the closing time", "2024-01-01", "", "2023-12-31", true},
+		{"from the closing time", "2024-01-01", "", "2024-01-01", false},
+		{"after it was closed", "2024-01-01", "", "2024-06-01", false},
+		{"reopened by a later write", "2024-01-01", "2025-01-01", "2025-06-01", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			svc, setDay := temporalGraph()
+			svc.UpdateNode(ctx, member, true)
+			svc.UpdateNode(ctx, committee, true)
+			if err := svc.UpdateEdge(ctx, seat(), true); err != nil {
+				t.Fatal(err)
+			}
+			if test.closedAt != "" {
+				if err := CloseEdge(ctx, svc, seat(), day(test.closedAt)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if test.rewrittenAt != "" {
+				setDay(test.rewrittenAt)
+				if err := svc.UpdateEdge(ctx, seat(), true); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			edges, err := FindEdgesAsOf(ctx, svc, "SERVES_ON", day(test.at))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (len(edges) == 1) != test.want {
+				t.Fatalf("%d seats as of %s, want held %t", len(edges), test.at, test.want)
+			}
+		})
+	}
+
+	svc, _ := temporalGraph()
+	svc.UpdateNode(ctx, member, true)
+	svc.UpdateNode(ctx, committee, true)
+	if err := CloseEdge(ctx, svc, seat(), day("2024-01-01")); !errors.Is(err, ErrEdgeNotFound) {
+		t.Fatalf("closing a missing edge returned %v, want %v", err, ErrEdgeNotFound)
+	}
+}
@@ -0,0 +1,78 @@
+package graphio
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/nedvisol/go-connectdots/graphdb"
+)
+
+// TestNeo4jAdminCsvRoundTrip checks a graph exported with WriteNeo4jAdminCsv imports back with the same ids,
+// edges and typed attributes
+func TestNeo4jAdminCsvRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	title := "An act, \"quoted\""
+	source := &Graph{
+		Nodes: []*graphdb.NodeInfo{
+			testNode("Person", "a", map[string]interface{}{"first": "Ron", "last": "Wyden", "birthYear": int64(1949), "currentMember": true}),
+			testNode("Person", "b", map[string]interface{}{"first": "Jeff", "last": "Merkley", "missing": nil}),
+			testNode("Bill", "x", map[string]interface{}{"title": &title, "congress": 118, "score": 0.5, "subjects": []string{"Energy", "Solar energy"}}),
+		},
+		Edges: []*graphdb.EdgeInfo{
+			testEdge("SPONSORED", "a-x", testNode("Person", "a", nil), testNode("Bill", "x", nil), map[string]interface{}{"date": "2023-01-05"}),
+			testEdge("COSPONSORED", "b-x", testNode("Person", "b", nil), testNode("Bill", "x", nil), map[string]interface{}{"isOriginal": false}),
+		},
+	}
+	dir := t.TempDir()
+	if err := WriteNeo4jAdminCsv(dir, source); err != nil {
+		t.Fatal(err)
+	}
+
+	svc := graphdb.NewMemoryGraphService()
+	stats, err := NewImporter(svc, 0, nil).ImportNeo4jCsv(ctx, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Nodes != 3 || stats.Edges != 2 || stats.Failed != 0 {
+		t.Fatalf("imported %d nodes and %d edges with errors %v", stats.Nodes, stats.Edges, stats.Errors)
+	}
+
+	want := map[string]map[string]interface{}{
+		"Person/a":        {"first": "Ron", "last": "Wyden", "birthYear": int64(1949), "currentMember": true},
+		"Person/b":        {"first": "Jeff", "last": "Merkley"},
+		"Bill/x":          {"title": title, "congress": int64(118), "score": 0.5, "subjects": []interface{}{"Energy", "Solar energy"}},
+		"SPONSORED/a-x":   {"date": "2023-01-05"},
+		"COSPONSORED/b-x": {"isOriginal": false},
+	}
+	imported, err := LoadGraph(ctx, svc, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]map[string]interface{})
+	for _, node := range imported.Nodes {
+		got[node.Label+"/"+node.Id] = *node.Attrs
+	}
+	for _, edge := range imported.Edges {
+		if edge.Left.Label != "Person" || edge.Right.Label != "Bill" {
+			t.Errorf("edge %s links %s %s to %s %s", edge.Id, edge.Left.Label, edge.Left.Id, edge.Right.Label, edge.Right.Id)
+		}
+		got[edge.Label+"/"+edge.Id] = *edge.Attrs
+	}
+	for key, attrs := range want {
+		if !reflect.DeepEqual(exportedAttrs(got[key]), attrs) {
+			t.Errorf("%s imported with %v, want %v", key, got[key], attrs)
+		}
+	}
+}
+
+// exportedAttrs leaves out the properties the graph services add, prefixed with _
+func exportedAttrs(attrs map[string]interface{}) map[string]interface{} {
+	exported := make(map[string]interface{})
+	for key, value := range attrs {
+		if key[0] != '_' {
+			exported[key] = value
+		}
+	}
+	return exported
+}
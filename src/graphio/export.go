@@ -0,0 +1,476 @@
+package graphio
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/nedvisol/go-connectdots/graphdb"
+)
+
+const FORMAT_GRAPHML = "graphml"
+const FORMAT_GEXF = "gexf"
+const FORMAT_JSONL = "jsonl"
+const FORMAT_NEO4J_CSV = "neo4j-csv"
+
+const KIND_NODE = "node"
+const KIND_EDGE = "edge"
+
+// RecordRef points at the node on one end of an edge record
+type RecordRef struct {
+	Label string `json:"label"`
+	Id    string `json:"_id"`
+}
+
+// Record is one line of the JSON Lines format, either a node or an edge
+type Record struct {
+	Kind  string                 `json:"kind"`
+	Label string                 `json:"label"`
+	Id    string                 `json:"_id"`
+	Attrs map[string]interface{} `json:"attrs,omitempty"`
+	Left  *RecordRef             `json:"left,omitempty"`
+	Right *RecordRef             `json:"right,omitempty"`
+}
+
+// plainValue dereferences pointers, the processors put *string values in attrs
+func plainValue(value interface{}) interface{} {
+	v := reflect.ValueOf(value)
+	for v.IsValid() && v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil
+	}
+	return v.Interface()
+}
+
+// attrType returns the neo4j-admin type name of value
+func attrType(value interface{}) string {
+	switch reflect.ValueOf(plainValue(value)).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "long"
+	case reflect.Float32, reflect.Float64:
+		return "double"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Slice, reflect.Array:
+		return "string[]"
+	default:
+		return "string"
+	}
+}
+
+// formatValue renders value as text, lists are joined with separator
+func formatValue(value interface{}, separator string) string {
+	plain := plainValue(value)
+	if plain == nil {
+		return ""
+	}
+	v := reflect.ValueOf(plain)
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+		items := make([]string, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			items = append(items, formatValue(v.Index(i).Interface(), separator))
+		}
+		return strings.Join(items, separator)
+	}
+	return fmt.Sprintf("%v", plain)
+}
+
+func plainAttrs(attrs *map[string]interface{}) map[string]interface{} {
+	plain := make(map[string]interface{})
+	if attrs == nil {
+		return plain
+	}
+	for key, value := range *attrs {
+		plain[key] = plainValue(value)
+	}
+	return plain
+}
+
+func NodeRecord(node *graphdb.NodeInfo) *Record {
+	return &Record{
+		Kind:  KIND_NODE,
+		Label: node.Label,
+		Id:    node.Id,
+		Attrs: plainAttrs(node.Attrs),
+	}
+}
+
+func EdgeRecord(edge *graphdb.EdgeInfo) *Record {
+	return &Record{
+		Kind:  KIND_EDGE,
+		Label: edge.Label,
+		Id:    edge.Id,
+		Attrs: plainAttrs(edge.Attrs),
+		Left:  &RecordRef{Label: edge.Left.Label, Id: edge.Left.Id},
+		Right: &RecordRef{Label: edge.Right.Label, Id: edge.Right.Id},
+	}
+}
+
+// WriteJsonLines writes one Record per line, nodes first so the output can be imported in order
+func WriteJsonLines(w io.Writer, graph *Graph) error {
+	encoder := json.NewEncoder(w)
+	for _, node := range graph.Nodes {
+		if err := encoder.Encode(NodeRecord(node)); err != nil {
+			return err
+		}
+	}
+	for _, edge := range graph.Edges {
+		if err := encoder.Encode(EdgeRecord(edge)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type graphmlKey struct {
+	Id       string `xml:"id,attr"`
+	For      string `xml:"for,attr"`
+	AttrName string `xml:"attr.name,attr"`
+	AttrType string `xml:"attr.type,attr"`
+}
+
+type graphmlData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+type graphmlNode struct {
+	Id   string         `xml:"id,attr"`
+	Data []*graphmlData `xml:"data"`
+}
+
+type graphmlEdge struct {
+	Id     string         `xml:"id,attr"`
+	Source string         `xml:"source,attr"`
+	Target string         `xml:"target,attr"`
+	Data   []*graphmlData `xml:"data"`
+}
+
+type graphmlGraph struct {
+	Id          string         `xml:"id,attr"`
+	EdgeDefault string         `xml:"edgedefault,attr"`
+	Nodes       []*graphmlNode `xml:"node"`
+	Edges       []*graphmlEdge `xml:"edge"`
+}
+
+type graphmlDocument struct {
+	XMLName xml.Name      `xml:"graphml"`
+	Xmlns   string        `xml:"xmlns,attr"`
+	Keys    []*graphmlKey `xml:"key"`
+	Graph   *graphmlGraph `xml:"graph"`
+}
+
+var graphmlTypes = map[string]string{
+	"long":     "long",
+	"double":   "double",
+	"boolean":  "boolean",
+	"string":   "string",
+	"string[]": "string",
+}
+
+func graphmlDataOf(prefix string, names []string, attrs *map[string]interface{}) []*graphmlData {
+	data := make([]*graphmlData, 0)
+	if attrs == nil {
+		return data
+	}
+	for _, name := range names {
+		if value, found := (*attrs)[name]; found && value != nil {
+			data = append(data, &graphmlData{Key: prefix + name, Value: formatValue(value, ";")})
+		}
+	}
+	return data
+}
+
+// WriteGraphML writes graph as GraphML. Node ids are the _id of the nodes, which is also kept as data
+// along with the label, so the file can be loaded back without losing anything.
+func WriteGraphML(w io.Writer, graph *Graph) error {
+	nodeNames, nodeTypes := attrNames(nodeAttrs(graph.Nodes))
+	edgeNames, edgeTypes := attrNames(edgeAttrs(graph.Edges))
+
+	doc := &graphmlDocument{
+		Xmlns: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []*graphmlKey{
+			{Id: "label", For: "all", AttrName: "label", AttrType: "string"},
+			{Id: "_id", For: "all", AttrName: "_id", AttrType: "string"},
+		},
+		Graph: &graphmlGraph{Id: "G", EdgeDefault: "directed"},
+	}
+	for _, name := range nodeNames {
+		doc.Keys = append(doc.Keys, &graphmlKey{Id: "n_" + name, For: "node", AttrName: name, AttrType: graphmlTypes[nodeTypes[name]]})
+	}
+	for _, name := range edgeNames {
+		doc.Keys = append(doc.Keys, &graphmlKey{Id: "e_" + name, For: "edge", AttrName: name, AttrType: graphmlTypes[edgeTypes[name]]})
+	}
+
+	for _, node := range graph.Nodes {
+		data := []*graphmlData{{Key: "label", Value: node.Label}, {Key: "_id", Value: node.Id}}
+		doc.Graph.Nodes = append(doc.Graph.Nodes, &graphmlNode{
+			Id:   node.Id,
+			Data: append(data, graphmlDataOf("n_", nodeNames, node.Attrs)...),
+		})
+	}
+	for i, edge := range graph.Edges {
+		data := []*graphmlData{{Key: "label", Value: edge.Label}, {Key: "_id", Value: edge.Id}}
+		doc.Graph.Edges = append(doc.Graph.Edges, &graphmlEdge{
+			// edge _id is not unique across endpoints, so GraphML edge ids are positional
+			Id:     fmt.Sprintf("e%d", i),
+			Source: edge.Left.Id,
+			Target: edge.Right.Id,
+			Data:   append(data, graphmlDataOf("e_", edgeNames, edge.Attrs)...),
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	return encoder.Encode(doc)
+}
+
+type gexfAttribute struct {
+	Id    string `xml:"id,attr"`
+	Title string `xml:"title,attr"`
+	Type  string `xml:"type,attr"`
+}
+
+type gexfAttributes struct {
+	Class      string           `xml:"class,attr"`
+	Attributes []*gexfAttribute `xml:"attribute"`
+}
+
+type gexfAttValue struct {
+	For   string `xml:"for,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type gexfNode struct {
+	Id        string          `xml:"id,attr"`
+	Label     string          `xml:"label,attr"`
+	AttValues []*gexfAttValue `xml:"attvalues>attvalue"`
+}
+
+type gexfEdge struct {
+	Id        string          `xml:"id,attr"`
+	Source    string          `xml:"source,attr"`
+	Target    string          `xml:"target,attr"`
+	Label     string          `xml:"label,attr"`
+	AttValues []*gexfAttValue `xml:"attvalues>attvalue"`
+}
+
+type gexfGraph struct {
+	DefaultEdgeType string            `xml:"defaultedgetype,attr"`
+	Mode            string            `xml:"mode,attr"`
+	Attributes      []*gexfAttributes `xml:"attributes"`
+	Nodes           []*gexfNode       `xml:"nodes>node"`
+	Edges           []*gexfEdge       `xml:"edges>edge"`
+}
+
+type gexfDocument struct {
+	XMLName xml.Name   `xml:"gexf"`
+	Xmlns   string     `xml:"xmlns,attr"`
+	Version string     `xml:"version,attr"`
+	Graph   *gexfGraph `xml:"graph"`
+}
+
+var gexfTypes = map[string]string{
+	"long":     "long",
+	"double":   "double",
+	"boolean":  "boolean",
+	"string":   "string",
+	"string[]": "liststring",
+}
+
+func gexfAttributesOf(class string, names []string, types map[string]string) *gexfAttributes {
+	attributes := &gexfAttributes{
+		Class:      class,
+		Attributes: []*gexfAttribute{{Id: "_id", Title: "_id", Type: "string"}},
+	}
+	for _, name := range names {
+		attributes.Attributes = append(attributes.Attributes, &gexfAttribute{Id: name, Title: name, Type: gexfTypes[types[name]]})
+	}
+	return attributes
+}
+
+func gexfAttValuesOf(id string, names []string, attrs *map[string]interface{}) []*gexfAttValue {
+	values := []*gexfAttValue{{For: "_id", Value: id}}
+	if attrs == nil {
+		return values
+	}
+	for _, name := range names {
+		if value, found := (*attrs)[name]; found && value != nil {
+			values = append(values, &gexfAttValue{For: name, Value: formatValue(value, "|")})
+		}
+	}
+	return values
+}
+
+// WriteGEXF writes graph as GEXF 1.3 for Gephi
+func WriteGEXF(w io.Writer, graph *Graph) error {
+	nodeNames, nodeTypes := attrNames(nodeAttrs(graph.Nodes))
+	edgeNames, edgeTypes := attrNames(edgeAttrs(graph.Edges))
+
+	doc := &gexfDocument{
+		Xmlns:   "http://gexf.net/1.3",
+		Version: "1.3",
+		Graph: &gexfGraph{
+			DefaultEdgeType: "directed",
+			Mode:            "static",
+			Attributes: []*gexfAttributes{
+				gexfAttributesOf("node", nodeNames, nodeTypes),
+				gexfAttributesOf("edge", edgeNames, edgeTypes),
+			},
+		},
+	}
+	for _, node := range graph.Nodes {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, &gexfNode{
+			Id:        node.Id,
+			Label:     node.Label,
+			AttValues: gexfAttValuesOf(node.Id, nodeNames, node.Attrs),
+		})
+	}
+	for i, edge := range graph.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, &gexfEdge{
+			Id:        fmt.Sprintf("%d", i),
+			Source:    edge.Left.Id,
+			Target:    edge.Right.Id,
+			Label:     edge.Label,
+			AttValues: gexfAttValuesOf(edge.Id, edgeNames, edge.Attrs),
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	return encoder.Encode(doc)
+}
+
+func writeCsvFile(path string, header []string, rows [][]string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	if err := writer.WriteAll(rows); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+func csvColumns(names []string, types map[string]string) []string {
+	columns := make([]string, 0, len(names))
+	for _, name := range names {
+		columns = append(columns, fmt.Sprintf("%s:%s", name, types[name]))
+	}
+	return columns
+}
+
+func csvValues(names []string, attrs *map[string]interface{}) []string {
+	values := make([]string, 0, len(names))
+	for _, name := range names {
+		var value interface{}
+		if attrs != nil {
+			value = (*attrs)[name]
+		}
+		values = append(values, formatValue(value, ";"))
+	}
+	return values
+}
+
+// WriteNeo4jAdminCsv writes graph into dir using the neo4j-admin database import layout,
+// one nodes_<Label>.csv per label and one relationships_<TYPE>.csv per relationship type
+func WriteNeo4jAdminCsv(dir string, graph *Graph) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	nodesByLabel := make(map[string][]*graphdb.NodeInfo)
+	for _, node := range graph.Nodes {
+		nodesByLabel[node.Label] = append(nodesByLabel[node.Label], node)
+	}
+	edgesByType := make(map[string][]*graphdb.EdgeInfo)
+	for _, edge := range graph.Edges {
+		edgesByType[edge.Label] = append(edgesByType[edge.Label], edge)
+	}
+
+	for label, nodes := range nodesByLabel {
+		names, types := attrNames(nodeAttrs(nodes))
+		header := append([]string{"_id:ID", ":LABEL"}, csvColumns(names, types)...)
+		rows := make([][]string, 0, len(nodes))
+		for _, node := range nodes {
+			rows = append(rows, append([]string{node.Id, node.Label}, csvValues(names, node.Attrs)...))
+		}
+		if err := writeCsvFile(filepath.Join(dir, fmt.Sprintf("nodes_%s.csv", label)), header, rows); err != nil {
+			return err
+		}
+	}
+
+	for edgeType, edges := range edgesByType {
+		names, types := attrNames(edgeAttrs(edges))
+		header := append([]string{":START_ID", ":END_ID", ":TYPE", "_id"}, csvColumns(names, types)...)
+		rows := make([][]string, 0, len(edges))
+		for _, edge := range edges {
+			rows = append(rows, append([]string{edge.Left.Id, edge.Right.Id, edge.Label, edge.Id}, csvValues(names, edge.Attrs)...))
+		}
+		if err := writeCsvFile(filepath.Join(dir, fmt.Sprintf("relationships_%s.csv", edgeType)), header, rows); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var Formats = []string{FORMAT_GRAPHML, FORMAT_GEXF, FORMAT_JSONL, FORMAT_NEO4J_CSV}
+
+// Export writes graph to path in the given format. For FORMAT_NEO4J_CSV path is a directory.
+func Export(graph *Graph, format string, path string) error {
+	if format == FORMAT_NEO4J_CSV {
+		return WriteNeo4jAdminCsv(path, graph)
+	}
+
+	var write func(w io.Writer, graph *Graph) error
+	switch format {
+	case FORMAT_GRAPHML:
+		write = WriteGraphML
+	case FORMAT_GEXF:
+		write = WriteGEXF
+	case FORMAT_JSONL:
+		write = WriteJsonLines
+	default:
+		return fmt.Errorf("unknown export format %s, expected one of %s", format, strings.Join(Formats, ", "))
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	buffered := bufio.NewWriter(file)
+	if err := write(buffered, graph); err != nil {
+		return err
+	}
+	if err := buffered.Flush(); err != nil {
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,188 @@
+package graphio
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/nedvisol/go-connectdots/graphdb"
+)
+
+// Graph is a set of nodes and the edges between them, loaded from a GraphDbService
+type Graph struct {
+	Nodes []*graphdb.NodeInfo
+	Edges []*graphdb.EdgeInfo
+}
+
+// Filter selects a subgraph. Zero values don't filter anything.
+type Filter struct {
+	Labels   []string   // keep only nodes with one of these labels
+	DateAttr string     // attribute checked against From and To, defaults to DEFAULT_DATE_ATTR
+	From     *time.Time // drop nodes and edges whose DateAttr is before From
+	To       *time.Time // drop nodes and edges whose DateAttr is after To
+	Seed     *graphdb.NodeInfo
+	Hops     int // keep only nodes within Hops edges of Seed, in either direction
+}
+
+const DEFAULT_DATE_ATTR = "date"
+
+var dateLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02", "2006-01"}
+
+func parseDate(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, true
+	case *string:
+		if v != nil {
+			return parseDate(*v)
+		}
+	case string:
+		for _, layout := range dateLayouts {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+func nodeKey(node *graphdb.NodeInfo) string {
+	return fmt.Sprintf("%s/%s", node.Label, node.Id)
+}
+
+func (f *Filter) inDateRange(attrs *map[string]interface{}) bool {
+	if (f.From == nil && f.To == nil) || attrs == nil {
+		return true
+	}
+	dateAttr := f.DateAttr
+	if dateAttr == "" {
+		dateAttr = DEFAULT_DATE_ATTR
+	}
+	date, found := parseDate((*attrs)[dateAttr])
+	if !found {
+		// entities without a date are not time bound
+		return true
+	}
+	if f.From != nil && date.Before(*f.From) {
+		return false
+	}
+	if f.To != nil && date.After(*f.To) {
+		return false
+	}
+	return true
+}
+
+// neighborhood returns the keys of the nodes within hops edges of seed
+func neighborhood(edges []*graphdb.EdgeInfo, seed *graphdb.NodeInfo, hops int) map[string]bool {
+	adjacent := make(map[string][]string)
+	for _, edge := range edges {
+		left, right := nodeKey(edge.Left), nodeKey(edge.Right)
+		adjacent[left] = append(adjacent[left], right)
+		adjacent[right] = append(adjacent[right], left)
+	}
+
+	reached := map[string]bool{nodeKey(seed): true}
+	frontier := []string{nodeKey(seed)}
+	for hop := 0; hop < hops && len(frontier) > 0; hop++ {
+		next := make([]string, 0)
+		for _, key := range frontier {
+			for _, neighbor := range adjacent[key] {
+				if !reached[neighbor] {
+					reached[neighbor] = true
+					next = append(next, neighbor)
+				}
+			}
+		}
+		frontier = next
+	}
+	return reached
+}
+
+// Apply returns the subgraph of graph selected by the filter. Edges are kept only when both ends are kept.
+func (f *Filter) Apply(graph *Graph) *Graph {
+	var reached map[string]bool
+	if f.Seed != nil {
+		reached = neighborhood(graph.Edges, f.Seed, f.Hops)
+	}
+	labels := make(map[string]bool)
+	for _, label := range f.Labels {
+		labels[label] = true
+	}
+
+	result := &Graph{
+		Nodes: make([]*graphdb.NodeInfo, 0),
+		Edges: make([]*graphdb.EdgeInfo, 0),
+	}
+	kept := make(map[string]bool)
+	for _, node := range graph.Nodes {
+		if len(labels) > 0 && !labels[node.Label] {
+			continue
+		}
+		if reached != nil && !reached[nodeKey(node)] {
+			continue
+		}
+		if !f.inDateRange(node.Attrs) {
+			continue
+		}
+		kept[nodeKey(node)] = true
+		result.Nodes = append(result.Nodes, node)
+	}
+	for _, edge := range graph.Edges {
+		if kept[nodeKey(edge.Left)] && kept[nodeKey(edge.Right)] && f.inDateRange(edge.Attrs) {
+			result.Edges = append(result.Edges, edge)
+		}
+	}
+	return result
+}
+
+// LoadGraph reads the whole graph from svc in one read transaction, see graphdb.ReadGraph,
+// and applies filter when it is not nil
+func LoadGraph(ctx context.Context, svc graphdb.GraphDbService, filter *Filter) (*Graph, error) {
+	nodes, edges, err := graphdb.ReadGraph(ctx, svc)
+	if err != nil {
+		return nil, err
+	}
+	graph := &Graph{Nodes: nodes, Edges: edges}
+	if filter != nil {
+		graph = filter.Apply(graph)
+	}
+	return graph, nil
+}
+
+// attrNames returns the sorted attribute names used across attrs, with the type of the first value seen
+func attrNames(attrsList []*map[string]interface{}) ([]string, map[string]string) {
+	types := make(map[string]string)
+	for _, attrs := range attrsList {
+		if attrs == nil {
+			continue
+		}
+		for key, value := range *attrs {
+			if _, found := types[key]; !found && value != nil {
+				types[key] = attrType(value)
+			}
+		}
+	}
+	names := make([]string, 0, len(types))
+	for name := range types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, types
+}
+
+func nodeAttrs(nodes []*graphdb.NodeInfo) []*map[string]interface{} {
+	attrsList := make([]*map[string]interface{}, 0, len(nodes))
+	for _, node := range nodes {
+		attrsList = append(attrsList, node.Attrs)
+	}
+	return attrsList
+}
+
+func edgeAttrs(edges []*graphdb.EdgeInfo) []*map[string]interface{} {
+	attrsList := make([]*map[string]interface{}, 0, len(edges))
+	for _, edge := range edges {
+		attrsList = append(attrsList, edge.Attrs)
+	}
+	return attrsList
+}
@@ -0,0 +1,75 @@
+package graphio
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nedvisol/go-connectdots/graphdb"
+)
+
+func testNode(label string, id string, attrs map[string]interface{}) *graphdb.NodeInfo {
+	return &graphdb.NodeInfo{Label: label, Id: id, Attrs: &attrs}
+}
+
+func testEdge(label string, id string, left *graphdb.NodeInfo, right *graphdb.NodeInfo, attrs map[string]interface{}) *graphdb.EdgeInfo {
+	return &graphdb.EdgeInfo{Label: label, Id: id, Left: left, Right: right, Attrs: &attrs}
+}
+
+// testGraph is a sponsor of a bill voted on, another sponsor of an older bill and a Person without edges
+func testGraph() *Graph {
+	older := "2021-03-01"
+	a := testNode("Person", "a", nil)
+	b := testNode("Person", "b", nil)
+	c := testNode("Person", "c", nil)
+	x := testNode("Bill", "x", map[string]interface{}{"date": "2023-01-05", "introducedDate": "2022-12-20"})
+	y := testNode("Bill", "y", map[string]interface{}{"date": &older})
+	v := testNode("Vote", "v", map[string]interface{}{"date": "2023-03-01T15:04:05Z"})
+	return &Graph{
+		Nodes: []*graphdb.NodeInfo{a, b, c, x, y, v},
+		Edges: []*graphdb.EdgeInfo{
+			testEdge("SPONSORED", "a-x", a, x, map[string]interface{}{"date": "2023-01-05"}),
+			testEdge("SPONSORED", "b-y", b, y, map[string]interface{}{"date": "2021-03-01"}),
+			testEdge("CONCERNS", "v-x", v, x, nil),
+			testEdge("CAST_VOTE", "a-v", a, v, map[string]interface{}{"position": "Yea"}),
+		},
+	}
+}
+
+func TestFilterApply(t *testing.T) {
+	date := func(value string) *time.Time {
+		parsed, _ := time.Parse("2006-01-02", value)
+		return &parsed
+	}
+	seed := testNode("Person", "a", nil)
+	tests := []struct {
+		name      string
+		filter    *Filter
+		wantNodes []string
+		wantEdges []string
+	}{
+		{"no filter", &Filter{}, []string{"a", "b", "c", "x", "y", "v"}, []string{"a-x", "b-y", "v-x", "a-v"}},
+		{"labels", &Filter{Labels: []string{"Person", "Bill"}}, []string{"a", "b", "c", "x", "y"}, []string{"a-x", "b-y"}},
+		{"from", &Filter{From: date("2022-01-01")}, []string{"a", "b", "c", "x", "v"}, []string{"a-x", "v-x", "a-v"}},
+		{"to", &Filter{To: date("2022-01-01")}, []string{"a", "b", "c", "y"}, []string{"b-y"}},
+		{"date attribute", &Filter{DateAttr: "introducedDate", To: date("2022-12-31")}, []string{"a", "b", "c", "x", "y", "v"}, []string{"a-x", "b-y", "v-x", "a-v"}},
+		{"seed only", &Filter{Seed: seed}, []string{"a"}, []string{}},
+		{"one hop", &Filter{Seed: seed, Hops: 1}, []string{"a", "x", "v"}, []string{"a-x", "v-x", "a-v"}},
+		{"one hop of people and bills", &Filter{Seed: seed, Hops: 1, Labels: []string{"Person", "Bill"}}, []string{"a", "x"}, []string{"a-x"}},
+		{"unknown seed", &Filter{Seed: testNode("Person", "z", nil), Hops: 3}, []string{}, []string{}},
+	}
+	for _, test := range tests {
+		result := test.filter.Apply(testGraph())
+		nodes := make([]string, 0, len(result.Nodes))
+		for _, node := range result.Nodes {
+			nodes = append(nodes, node.Id)
+		}
+		edges := make([]string, 0, len(result.Edges))
+		for _, edge := range result.Edges {
+			edges = append(edges, edge.Id)
+		}
+		if !reflect.DeepEqual(nodes, test.wantNodes) || !reflect.DeepEqual(edges, test.wantEdges) {
+			t.Errorf("%s: kept nodes %v and edges %v, want %v and %v", test.name, nodes, edges, test.wantNodes, test.wantEdges)
+		}
+	}
+}
@@ -0,0 +1,384 @@
+package graphio
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nedvisol/go-connectdots/graphdb"
+)
+
+const DEFAULT_BATCH_SIZE = 500
+const IMPORT_SOURCE_SYSTEM = "import"
+
+// ImportStats counts what an import stored and collects the errors of what it could not
+type ImportStats struct {
+	Nodes  int
+	Edges  int
+	Failed int
+	Errors []error
+}
+
+func (s *ImportStats) fail(err error) {
+	s.Failed++
+	s.Errors = append(s.Errors, err)
+}
+
+// Importer loads node and edge records into a GraphDbService through the batched upserts.
+// A failing batch is retried one record at a time so only the bad records are reported.
+type Importer struct {
+	svc       graphdb.GraphDbService
+	batchSize int
+	progress  func(stats *ImportStats)
+
+	stats      *ImportStats
+	provenance *graphdb.Provenance
+	nodes      []*graphdb.NodeInfo
+	edges      []*graphdb.EdgeInfo
+	nodeLabel  map[string]string // _id to label of imported nodes, neo4j-admin edges only carry ids
+}
+
+func (i *Importer) flushNodes(ctx context.Context) {
+	if len(i.nodes) == 0 {
+		return
+	}
+	if err := i.svc.UpdateNodes(ctx, i.nodes, true); err != nil {
+		for _, node := range i.nodes {
+			if err := i.svc.UpdateNode(ctx, node, true); err != nil {
+				i.stats.fail(fmt.Errorf("node %s %s: %w", node.Label, node.Id, err))
+			} else {
+				i.stats.Nodes++
+			}
+		}
+	} else {
+		i.stats.Nodes += len(i.nodes)
+	}
+	i.nodes = i.nodes[:0]
+	i.reportProgress()
+}
+
+func (i *Importer) flushEdges(ctx context.Context) {
+	// edges can only be stored once their nodes are
+	i.flushNodes(ctx)
+	if len(i.edges) == 0 {
+		return
+	}
+	if err := i.svc.UpdateEdges(ctx, i.edges, true); err != nil {
+		for _, edge := range i.edges {
+			if err := i.svc.UpdateEdge(ctx, edge, true); err != nil {
+				i.stats.fail(fmt.Errorf("edge %s %s: %w", edge.Label, edge.Id, err))
+			} else {
+				i.stats.Edges++
+			}
+		}
+	} else {
+		i.stats.Edges += len(i.edges)
+	}
+	i.edges = i.edges[:0]
+	i.reportProgress()
+}
+
+func (i *Importer) reportProgress() {
+	if i.progress != nil {
+		i.progress(i.stats)
+	}
+}
+
+func (i *Importer) addNode(ctx context.Context, node *graphdb.NodeInfo) {
+	i.nodeLabel[node.Id] = node.Label
+	i.nodes = append(i.nodes, node)
+	if len(i.nodes) >= i.batchSize {
+		i.flushNodes(ctx)
+	}
+}
+
+func (i *Importer) addEdge(ctx context.Context, edge *graphdb.EdgeInfo) {
+	i.edges = append(i.edges, edge)
+	if len(i.edges) >= i.batchSize {
+		i.flushEdges(ctx)
+	}
+}
+
+// AddRecord queues a record, writing a batch once it is full
+func (i *Importer) AddRecord(ctx context.Context, record *Record) error {
+	if record.Label == "" || record.Id == "" {
+		return fmt.Errorf("record without label or _id")
+	}
+	attrs := record.Attrs
+	if attrs == nil {
+		attrs = make(map[string]interface{})
+	}
+
+	switch record.Kind {
+	case KIND_NODE:
+		i.addNode(ctx, &graphdb.NodeInfo{Label: record.Label, Id: record.Id, Attrs: &attrs, Provenance: i.provenance})
+	case KIND_EDGE:
+		if record.Left == nil || record.Right == nil {
+			return fmt.Errorf("edge %s %s without left or right node", record.Label, record.Id)
+		}
+		i.addEdge(ctx, &graphdb.EdgeInfo{
+			Label:      record.Label,
+			Id:         record.Id,
+			Attrs:      &attrs,
+			Left:       &graphdb.NodeInfo{Label: record.Left.Label, Id: record.Left.Id},
+			Right:      &graphdb.NodeInfo{Label: record.Right.Label, Id: record.Right.Id},
+			Provenance: i.provenance,
+		})
+	default:
+		return fmt.Errorf("unknown record kind %s", record.Kind)
+	}
+	return nil
+}
+
+// Finish writes the queued records and returns the stats of the import
+func (i *Importer) Finish(ctx context.Context) *ImportStats {
+	i.flushEdges(ctx)
+	return i.stats
+}
+
+// ImportJsonLines reads the records written by WriteJsonLines. Lines that can't be parsed are
+// reported in the stats and skipped.
+func (i *Importer) ImportJsonLines(ctx context.Context, r io.Reader) (*ImportStats, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var record Record
+		decoder := json.NewDecoder(strings.NewReader(text))
+		decoder.UseNumber()
+		if err := decoder.Decode(&record); err != nil {
+			i.stats.fail(fmt.Errorf("line %d: %w", line, err))
+			continue
+		}
+		for key, value := range record.Attrs {
+			record.Attrs[key] = jsonValue(value)
+		}
+		if err := i.AddRecord(ctx, &record); err != nil {
+			i.stats.fail(fmt.Errorf("line %d: %w", line, err))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return i.Finish(ctx), err
+	}
+	return i.Finish(ctx), nil
+}
+
+// jsonValue turns json.Number into int64 or float64 so integers round trip
+func jsonValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	case []interface{}:
+		for i := range v {
+			v[i] = jsonValue(v[i])
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// csvColumn is a neo4j-admin header field, name:type
+type csvColumn struct {
+	name  string
+	kind  string
+	index int
+}
+
+func parseCsvHeader(header []string) map[string]*csvColumn {
+	columns := make(map[string]*csvColumn)
+	for index, field := range header {
+		name, kind, _ := strings.Cut(field, ":")
+		if name == "" {
+			// :LABEL, :START_ID, :END_ID and :TYPE
+			name = ":" + kind
+			kind = ""
+		}
+		columns[name] = &csvColumn{name: name, kind: kind, index: index}
+	}
+	return columns
+}
+
+func parseCsvValue(value string, kind string) (interface{}, error) {
+	if strings.HasSuffix(kind, "[]") {
+		items := make([]interface{}, 0)
+		if value == "" {
+			return items, nil
+		}
+		for _, item := range strings.Split(value, ";") {
+			parsed, err := parseCsvValue(item, strings.TrimSuffix(kind, "[]"))
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, parsed)
+		}
+		return items, nil
+	}
+	switch kind {
+	case "long", "int", "short", "byte":
+		return strconv.ParseInt(value, 10, 64)
+	case "double", "float":
+		return strconv.ParseFloat(value, 64)
+	case "boolean":
+		return strconv.ParseBool(value)
+	default:
+		return value, nil
+	}
+}
+
+// csvAttrs reads the property columns of row, empty cells are left out like neo4j-admin does
+func csvAttrs(columns map[string]*csvColumn, row []string) (map[string]interface{}, error) {
+	attrs := make(map[string]interface{})
+	for name, column := range columns {
+		if strings.HasPrefix(name, ":") || name == "_id" || column.index >= len(row) || row[column.index] == "" {
+			continue
+		}
+		value, err := parseCsvValue(row[column.index], column.kind)
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %w", name, err)
+		}
+		attrs[name] = value
+	}
+	return attrs, nil
+}
+
+func csvCell(columns map[string]*csvColumn, row []string, name string) string {
+	column, found := columns[name]
+	if !found || column.index >= len(row) {
+		return ""
+	}
+	return row[column.index]
+}
+
+func (i *Importer) importCsvFile(ctx context.Context, path string, kind string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(bufio.NewReader(file))
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	columns := parseCsvHeader(header)
+
+	line := 1
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			i.stats.fail(fmt.Errorf("%s line %d: %w", path, line, err))
+			continue
+		}
+		attrs, err := csvAttrs(columns, row)
+		if err != nil {
+			i.stats.fail(fmt.Errorf("%s line %d: %w", path, line, err))
+			continue
+		}
+
+		record := &Record{Kind: kind, Attrs: attrs}
+		if kind == KIND_NODE {
+			record.Id = csvCell(columns, row, "_id")
+			record.Label, _, _ = strings.Cut(csvCell(columns, row, ":LABEL"), ";")
+		} else {
+			startId, endId := csvCell(columns, row, ":START_ID"), csvCell(columns, row, ":END_ID")
+			record.Id = csvCell(columns, row, "_id")
+			record.Label = csvCell(columns, row, ":TYPE")
+			record.Left = &RecordRef{Label: i.nodeLabel[startId], Id: startId}
+			record.Right = &RecordRef{Label: i.nodeLabel[endId], Id: endId}
+			if record.Left.Label == "" || record.Right.Label == "" {
+				i.stats.fail(fmt.Errorf("%s line %d: unknown node %s or %s", path, line, startId, endId))
+				continue
+			}
+		}
+		if err := i.AddRecord(ctx, record); err != nil {
+			i.stats.fail(fmt.Errorf("%s line %d: %w", path, line, err))
+		}
+	}
+	return nil
+}
+
+// ImportNeo4jCsv reads a directory written by WriteNeo4jAdminCsv, all nodes_*.csv files before
+// the relationships_*.csv files since edges are matched to the imported nodes by _id
+func (i *Importer) ImportNeo4jCsv(ctx context.Context, dir string) (*ImportStats, error) {
+	for _, pattern := range []string{"nodes_*.csv", "relationships_*.csv"} {
+		paths, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return i.Finish(ctx), err
+		}
+		sort.Strings(paths)
+		kind := KIND_NODE
+		if pattern != "nodes_*.csv" {
+			kind = KIND_EDGE
+			i.flushNodes(ctx)
+		}
+		for _, path := range paths {
+			if err := i.importCsvFile(ctx, path, kind); err != nil {
+				return i.Finish(ctx), err
+			}
+		}
+	}
+	return i.Finish(ctx), nil
+}
+
+// Import reads path in the given format, FORMAT_JSONL or FORMAT_NEO4J_CSV
+func (i *Importer) Import(ctx context.Context, format string, path string) (*ImportStats, error) {
+	i.provenance = &graphdb.Provenance{
+		SourceSystem: IMPORT_SOURCE_SYSTEM,
+		SourceUrl:    path,
+		FetchedAt:    time.Now(),
+	}
+
+	switch format {
+	case FORMAT_JSONL:
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		return i.ImportJsonLines(ctx, file)
+	case FORMAT_NEO4J_CSV:
+		return i.ImportNeo4jCsv(ctx, path)
+	default:
+		return nil, fmt.Errorf("unknown import format %s, expected %s or %s", format, FORMAT_JSONL, FORMAT_NEO4J_CSV)
+	}
+}
+
+func NewImporter(svc graphdb.GraphDbService, batchSize int, progress func(stats *ImportStats)) *Importer {
+	if batchSize <= 0 {
+		batchSize = DEFAULT_BATCH_SIZE
+	}
+	return &Importer{
+		svc:       svc,
+		batchSize: batchSize,
+		progress:  progress,
+		stats:     &ImportStats{Errors: make([]error, 0)},
+		nodes:     make([]*graphdb.NodeInfo, 0, batchSize),
+		edges:     make([]*graphdb.EdgeInfo, 0, batchSize),
+		nodeLabel: make(map[string]string),
+	}
+}
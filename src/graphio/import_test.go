@@ -0,0 +1,47 @@
+package graphio
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nedvisol/go-connectdots/graphdb"
+)
+
+const importLines = `{"kind": "node", "label": "Person", "_id": "a"}
+{"kind": "node", "label": "Person", "_id": "b"}
+{"kind": "edge", "label": "CAST_VOTE", "_id": "a-b", "left": {"label": "Person", "_id": "a"}, "right": {"label": "Person", "_id": "b"}}
+{"kind": "edge", "label": "CAST_VOTE", "_id": "a-missing", "left": {"label": "Person", "_id": "a"}, "right": {"label": "Person", "_id": "missing"}}
+`
+
+// TestImportCountsWrittenEdges checks an edge the backend refuses for its missing node is reported, not counted
+func TestImportCountsWrittenEdges(t *testing.T) {
+	backends := map[string]func(t *testing.T) graphdb.GraphDbService{
+		"memory": func(t *testing.T) graphdb.GraphDbService {
+			return graphdb.NewMemoryGraphService()
+		},
+		"sqlite": func(t *testing.T) graphdb.GraphDbService {
+			svc, err := graphdb.OpenSqliteGraphService(filepath.Join(t.TempDir(), "graph.db"), 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return svc
+		},
+	}
+	for name, open := range backends {
+		t.Run(name, func(t *testing.T) {
+			stats, err := NewImporter(open(t), DEFAULT_BATCH_SIZE, nil).ImportJsonLines(context.Background(), strings.NewReader(importLines))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stats.Nodes != 2 || stats.Edges != 1 || stats.Failed != 1 {
+				t.Fatalf("imported %d nodes and %d edges with %d failed, want 2, 1 and 1", stats.Nodes, stats.Edges, stats.Failed)
+			}
+			if !errors.Is(stats.Errors[0], graphdb.ErrNodeNotFound) {
+				t.Fatalf("got %v, want %v", stats.Errors[0], graphdb.ErrNodeNotFound)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package graphio
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/nedvisol/go-connectdots/graphdb"
+)
+
+// SNAPSHOT_FORMAT_VERSION is bumped whenever the snapshot layout changes incompatibly
+const SNAPSHOT_FORMAT_VERSION = 1
+
+var ErrGraphNotEmpty = errors.New("graph is not empty")
+
+// SnapshotHeader is the first line of a snapshot, the node and edge records follow as JSON Lines
+type SnapshotHeader struct {
+	FormatVersion    int    `json:"formatVersion"`
+	CreatedAt        string `json:"createdAt"`
+	RunId            string `json:"runId,omitempty"`
+	MigrationVersion int    `json:"migrationVersion"`
+	Nodes            int    `json:"nodes"`
+	Edges            int    `json:"edges"`
+}
+
+// TakeSnapshot reads the whole graph of svc in one read transaction, so that a run writing the graph
+// meanwhile can't leave edges in the snapshot without their nodes.
+func TakeSnapshot(ctx context.Context, svc graphdb.GraphDbService, runId string) (*SnapshotHeader, *Graph, error) {
+	graph, err := LoadGraph(ctx, svc, &Filter{})
+	if err != nil {
+		return nil, nil, err
+	}
+	version, err := graphdb.NewMigrator(svc, graphdb.Migrations).AppliedVersion(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	header := &SnapshotHeader{
+		FormatVersion:    SNAPSHOT_FORMAT_VERSION,
+		CreatedAt:        time.Now().UTC().Format(time.RFC3339),
+		RunId:            runId,
+		MigrationVersion: version,
+		Nodes:            len(graph.Nodes),
+		Edges:            len(graph.Edges),
+	}
+	return header, graph, nil
+}
+
+// WriteSnapshot writes header and graph gzip compressed
+func WriteSnapshot(w io.Writer, header *SnapshotHeader, graph *Graph) error {
+	zw := gzip.NewWriter(w)
+	if err := json.NewEncoder(zw).Encode(header); err != nil {
+		return err
+	}
+	if err := WriteJsonLines(zw, graph); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// ReadSnapshotHeader reads the header of a snapshot written by WriteSnapshot and returns the reader
+// of the records which follow it
+func ReadSnapshotHeader(r io.Reader) (*SnapshotHeader, io.Reader, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	records := bufio.NewReader(zr)
+	line, err := records.ReadBytes('\n')
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading snapshot header: %w", err)
+	}
+	var header SnapshotHeader
+	if err := json.Unmarshal(line, &header); err != nil {
+		return nil, nil, fmt.Errorf("reading snapshot header: %w", err)
+	}
+	if header.FormatVersion != SNAPSHOT_FORMAT_VERSION {
+		return nil, nil, fmt.Errorf("unsupported snapshot format version %d, expected %d", header.FormatVersion, SNAPSHOT_FORMAT_VERSION)
+	}
+	return &header, records, nil
+}
+
+// RestoreSnapshot loads the snapshot read from r into svc, which must be empty, and checks that
+// every node and edge of the header was restored
+func RestoreSnapshot(ctx context.Context, svc graphdb.GraphDbService, r io.Reader, batchSize int, progress func(stats *ImportStats)) (*SnapshotHeader, *ImportStats, error) {
+	nodes, err := svc.FindNodes(ctx, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(nodes) > 0 {
+		return nil, nil, fmt.Errorf("%w: %d nodes found, restore needs an empty graph", ErrGraphNotEmpty, len(nodes))
+	}
+
+	header, records, err := ReadSnapshotHeader(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	stats, err := NewImporter(svc, batchSize, progress).ImportJsonLines(ctx, records)
+	if err != nil {
+		return header, stats, err
+	}
+	if stats.Nodes != header.Nodes || stats.Edges != header.Edges {
+		return header, stats, fmt.Errorf("restored %d nodes and %d edges, snapshot holds %d nodes and %d edges",
+			stats.Nodes, stats.Edges, header.Nodes, header.Edges)
+	}
+	return header, stats, nil
+}
+
+// SaveSnapshot takes a snapshot of svc and writes it to path
+func SaveSnapshot(ctx context.Context, svc graphdb.GraphDbService, runId string, path string) (*SnapshotHeader, error) {
+	header, graph, err := TakeSnapshot(ctx, svc, runId)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	if err := WriteSnapshot(file, header, graph); err != nil {
+		return nil, err
+	}
+	return header, file.Close()
+}
@@ -0,0 +1,136 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nedvisol/go-connectdots/config"
+	"go.uber.org/fx"
+)
+
+const STATUS_UP = "up"
+const STATUS_DOWN = "down"
+
+// CHECK_TIMEOUT bounds every dependency check of a readiness report
+const CHECK_TIMEOUT = time.Second * 5
+
+// Check returns an error when the dependency it checks is not usable
+type Check func(ctx context.Context) error
+
+type CheckStatus struct {
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+	LatencyMs int64  `json:"latencyMs"`
+}
+
+// Report is the readiness of the application, up only when every dependency is
+type Report struct {
+	Status string                  `json:"status"`
+	Checks map[string]*CheckStatus `json:"checks"`
+}
+
+// Registry holds the dependency checks reported by /healthz
+type Registry struct {
+	mu     sync.Mutex
+	checks map[string]Check
+}
+
+func (r *Registry) Register(name string, check Check) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks[name] = check
+}
+
+// Report runs all checks concurrently
+func (r *Registry) Report(ctx context.Context) *Report {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.checks))
+	checks := make([]Check, 0, len(r.checks))
+	for name := range r.checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		checks = append(checks, r.checks[name])
+	}
+	r.mu.Unlock()
+
+	statuses := make([]*CheckStatus, len(names))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			statuses[i] = runCheck(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	report := &Report{Status: STATUS_UP, Checks: make(map[string]*CheckStatus)}
+	for i, name := range names {
+		report.Checks[name] = statuses[i]
+		if statuses[i].Status != STATUS_UP {
+			report.Status = STATUS_DOWN
+		}
+	}
+	return report
+}
+
+func runCheck(ctx context.Context, check Check) *CheckStatus {
+	ctx, cancel := context.WithTimeout(ctx, CHECK_TIMEOUT)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+	status := &CheckStatus{Status: STATUS_UP, LatencyMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		status.Status = STATUS_DOWN
+		status.Error = err.Error()
+	}
+	return status
+}
+
+// ServeHTTP writes the report as JSON, with status 503 when a dependency is down
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	report := r.Report(req.Context())
+	w.Header().Set("Content-Type", "application/json")
+	if report.Status != STATUS_UP {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(report)
+}
+
+func NewRegistry() *Registry {
+	return &Registry{checks: make(map[string]Check)}
+}
+
+// StartHealthServer serves the registry at /healthz on config.HealthAddr for the lifetime of the app
+func StartHealthServer(lifecycle fx.Lifecycle, cfg *config.Config, registry *Registry) {
+	if cfg.HealthAddr == "" {
+		return
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/healthz", registry)
+	server := &http.Server{Addr: cfg.HealthAddr, Handler: mux}
+
+	lifecycle.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			go func() {
+				if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					fmt.Printf("health server stopped: %s\n", err)
+				}
+			}()
+			fmt.Printf("serving health checks at %s/healthz\n", cfg.HealthAddr)
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			return server.Shutdown(ctx)
+		},
+	})
+}
@@ -0,0 +1,334 @@
+package ids
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// NAMESPACE prefixes the identifiers of entities defined by the US Congress
+const NAMESPACE = "us-congress"
+
+// kinds of identifiers
+const KIND_MEMBER = "member"
+const KIND_BILL = "bill"
+const KIND_BILL_ACTION = "bill-action"
+const KIND_AMENDMENT = "amendment"
+const KIND_VOTE = "vote"
+const KIND_CAST_VOTE = "cast-vote"
+const KIND_SPONSORSHIP = "sponsorship"
+const KIND_COSPONSORSHIP = "cosponsorship"
+const KIND_COMMITTEE = "committee"
+const KIND_COMMITTEE_REPORT = "committee-report"
+const KIND_COMMITTEE_MEMBERSHIP = "committee-membership"
+const KIND_REFERRAL = "referral"
+const KIND_SUBJECT = "subject"
+const KIND_POLICY_AREA = "policy-area"
+const KIND_BILL_SUBJECT = "bill-subject"
+const KIND_BILL_POLICY_AREA = "bill-policy-area"
+const KIND_STATE = "state"
+const KIND_DISTRICT = "district"
+const KIND_PARTY = "party"
+const KIND_TERM = "term"
+const KIND_PARTY_MEMBERSHIP = "party-membership"
+
+var ErrInvalidId = errors.New("invalid id")
+
+// Id is a URN style identifier, namespace:kind:part:part..., stable across runs and readable
+type Id struct {
+	Namespace string
+	Kind      string
+	Parts     []string
+}
+
+// escapePart keeps the separator out of the parts
+func escapePart(part string) string {
+	return strings.NewReplacer("%", "%25", ":", "%3A").Replace(part)
+}
+
+func unescapePart(part string) string {
+	return strings.NewReplacer("%3A", ":", "%25", "%").Replace(part)
+}
+
+func (id *Id) String() string {
+	fields := make([]string, 0, len(id.Parts)+2)
+	fields = append(fields, id.Namespace, id.Kind)
+	for _, part := range id.Parts {
+		fields = append(fields, escapePart(part))
+	}
+	return strings.Join(fields, ":")
+}
+
+// Format returns the identifier of the given kind in NAMESPACE
+func Format(kind string, parts ...string) string {
+	return (&Id{Namespace: NAMESPACE, Kind: kind, Parts: parts}).String()
+}
+
+// Parse splits an identifier written by Format
+func Parse(value string) (*Id, error) {
+	fields := strings.Split(value, ":")
+	if len(fields) < 3 || fields[0] == "" || fields[1] == "" {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidId, value)
+	}
+	id := &Id{Namespace: fields[0], Kind: fields[1], Parts: make([]string, 0, len(fields)-2)}
+	for _, part := range fields[2:] {
+		id.Parts = append(id.Parts, unescapePart(part))
+	}
+	return id, nil
+}
+
+// IsId tells identifiers written by Format apart from the hashed ids of earlier versions
+func IsId(value string) bool {
+	return strings.HasPrefix(value, NAMESPACE+":")
+}
+
+// parseKind parses value and checks its namespace, kind and number of parts
+func parseKind(value string, kind string, parts int) (*Id, error) {
+	id, err := Parse(value)
+	if err != nil {
+		return nil, err
+	}
+	if id.Namespace != NAMESPACE || id.Kind != kind || len(id.Parts) != parts {
+		return nil, fmt.Errorf("%w: %s is not a %s id", ErrInvalidId, value, kind)
+	}
+	return id, nil
+}
+
+// Member identifies a member of congress by bioguide id, e.g. us-congress:member:B000574
+func Member(bioguideId string) string {
+	return Format(KIND_MEMBER, strings.ToUpper(bioguideId))
+}
+
+// ParseMember returns the bioguide id of a Member id
+func ParseMember(value string) (string, error) {
+	id, err := parseKind(value, KIND_MEMBER, 1)
+	if err != nil {
+		return "", err
+	}
+	return id.Parts[0], nil
+}
+
+// Bill identifies a bill by congress, type and number, e.g. us-congress:bill:118:hr:1234
+func Bill(congress int, billType string, number string) string {
+	return Format(KIND_BILL, strconv.Itoa(congress), strings.ToLower(billType), number)
+}
+
+// ParseBill returns the congress, type and number of a Bill id
+func ParseBill(value string) (int, string, string, error) {
+	id, err := parseKind(value, KIND_BILL, 3)
+	if err != nil {
+		return 0, "", "", err
+	}
+	congress, err := strconv.Atoi(id.Parts[0])
+	if err != nil {
+		return 0, "", "", fmt.Errorf("%w: %s has an invalid congress", ErrInvalidId, value)
+	}
+	return congress, id.Parts[1], id.Parts[2], nil
+}
+
+// Amendment identifies an amendment by congress, type and number, e.g. us-congress:amendment:118:samdt:1234
+func Amendment(congress int, amendmentType string, number string) string {
+	return Format(KIND_AMENDMENT, strconv.Itoa(congress), strings.ToLower(amendmentType), number)
+}
+
+// BillAction identifies an action taken on a bill on a date, e.g. us-congress:bill-action:118:hr:1234:2023-05-01
+func BillAction(congress int, billType string, number string, actionDate string) string {
+	return Format(KIND_BILL_ACTION, strconv.Itoa(congress), strings.ToLower(billType), number, actionDate)
+}
+
+// BillActionOf returns the BillAction id of an action dated actionDate on the bill with the given Bill id
+func BillActionOf(billId string, actionDate string) (string, error) {
+	congress, billType, number, err := ParseBill(billId)
+	if err != nil {
+		return "", err
+	}
+	return BillAction(congress, billType, number, actionDate), nil
+}
+
+// Vote identifies a roll call by congress, chamber, session and roll number, e.g. us-congress:vote:118:house:1:123
+func Vote(congress int, chamber string, session int, rollNumber int) string {
+	return Format(KIND_VOTE, strconv.Itoa(congress), strings.ToLower(chamber), strconv.Itoa(session), strconv.Itoa(rollNumber))
+}
+
+// memberOf identifies the relation of a member to the entity of id, which has the given kind and
+// number of parts, by the parts of both
+func memberOf(relationKind string, id string, kind string, parts int, memberId string) (string, error) {
+	parsed, err := parseKind(id, kind, parts)
+	if err != nil {
+		return "", err
+	}
+	bioguideId, err := ParseMember(memberId)
+	if err != nil {
+		return "", err
+	}
+	return Format(relationKind, append(parsed.Parts, bioguideId)...), nil
+}
+
+// CastVote identifies the position a member took on a roll call, e.g. us-congress:cast-vote:118:house:1:123:B000574
+func CastVote(voteId string, memberId string) (string, error) {
+	return memberOf(KIND_CAST_VOTE, voteId, KIND_VOTE, 4, memberId)
+}
+
+// Sponsorship identifies the sponsorship of a bill or amendment by a member, e.g. us-congress:sponsorship:118:hr:1234:B000574
+func Sponsorship(id string, memberId string) (string, error) {
+	kind := KIND_BILL
+	if parsed, err := Parse(id); err == nil && parsed.Kind == KIND_AMENDMENT {
+		kind = KIND_AMENDMENT
+	}
+	return memberOf(KIND_SPONSORSHIP, id, kind, 3, memberId)
+}
+
+// Cosponsorship identifies the cosponsorship of a bill by a member, e.g. us-congress:cosponsorship:118:hr:1234:B000574
+func Cosponsorship(billId string, memberId string) (string, error) {
+	return memberOf(KIND_COSPONSORSHIP, billId, KIND_BILL, 3, memberId)
+}
+
+// Committee identifies a committee or subcommittee by its congress.gov system code, e.g. us-congress:committee:hsag00
+func Committee(systemCode string) string {
+	return Format(KIND_COMMITTEE, strings.ToLower(systemCode))
+}
+
+// CommitteeReport identifies a committee report by congress, type and number, e.g. us-congress:committee-report:117:hrpt:1
+func CommitteeReport(congress int, reportType string, number string) string {
+	return Format(KIND_COMMITTEE_REPORT, strconv.Itoa(congress), strings.ToLower(reportType), number)
+}
+
+// ParseCommitteeReport returns the congress, type and number of a CommitteeReport id
+func ParseCommitteeReport(value string) (int, string, string, error) {
+	id, err := parseKind(value, KIND_COMMITTEE_REPORT, 3)
+	if err != nil {
+		return 0, "", "", err
+	}
+	congress, err := strconv.Atoi(id.Parts[0])
+	if err != nil {
+		return 0, "", "", fmt.Errorf("%w: %s has an invalid congress", ErrInvalidId, value)
+	}
+	return congress, id.Parts[1], id.Parts[2], nil
+}
+
+// CommitteeMembership identifies the seat of a member on a committee, e.g. us-congress:committee-membership:hsag00:T000467
+func CommitteeMembership(committeeId string, memberId string) (string, error) {
+	return memberOf(KIND_COMMITTEE_MEMBERSHIP, committeeId, KIND_COMMITTEE, 1, memberId)
+}
+
+// Referral identifies the referral of a bill to a committee, e.g. us-congress:referral:118:hr:1234:hsag00
+func Referral(billId string, committeeId string) (string, error) {
+	bill, err := parseKind(billId, KIND_BILL, 3)
+	if err != nil {
+		return "", err
+	}
+	committee, err := parseKind(committeeId, KIND_COMMITTEE, 1)
+	if err != nil {
+		return "", err
+	}
+	return Format(KIND_REFERRAL, append(bill.Parts, committee.Parts...)...), nil
+}
+
+// Subject identifies a CRS legislative subject by name, e.g. us-congress:subject:Solar energy
+func Subject(name string) string {
+	return Format(KIND_SUBJECT, name)
+}
+
+// PolicyArea identifies a CRS policy area by name, e.g. us-congress:policy-area:Energy
+func PolicyArea(name string) string {
+	return Format(KIND_POLICY_AREA, name)
+}
+
+// billTopic identifies the relation of a bill to a subject or policy area by the parts of both
+func billTopic(relationKind string, billId string, topicId string, topicKind string) (string, error) {
+	bill, err := parseKind(billId, KIND_BILL, 3)
+	if err != nil {
+		return "", err
+	}
+	topic, err := parseKind(topicId, topicKind, 1)
+	if err != nil {
+		return "", err
+	}
+	return Format(relationKind, append(bill.Parts, topic.Parts...)...), nil
+}
+
+// BillSubject identifies the subject of a bill, e.g. us-congress:bill-subject:118:hr:1234:Solar energy
+func BillSubject(billId string, subjectId string) (string, error) {
+	return billTopic(KIND_BILL_SUBJECT, billId, subjectId, KIND_SUBJECT)
+}
+
+// BillPolicyArea identifies the policy area of a bill, e.g. us-congress:bill-policy-area:118:hr:1234:Energy
+func BillPolicyArea(billId string, policyAreaId string) (string, error) {
+	return billTopic(KIND_BILL_POLICY_AREA, billId, policyAreaId, KIND_POLICY_AREA)
+}
+
+// State identifies a state or territory by postal code, e.g. us-congress:state:OR
+func State(code string) string {
+	return Format(KIND_STATE, strings.ToUpper(code))
+}
+
+// District identifies a congressional district by state and number, 0 for at-large, e.g. us-congress:district:OR:3
+func District(stateCode string, number int) string {
+	return Format(KIND_DISTRICT, strings.ToUpper(stateCode), strconv.Itoa(number))
+}
+
+// Party identifies a political party by name, e.g. us-congress:party:Democratic
+func Party(name string) string {
+	return Format(KIND_PARTY, name)
+}
+
+// Term identifies the term of a member in a chamber during a congress, e.g. us-congress:term:B000574:118:house
+func Term(memberId string, congress int, chamber string) (string, error) {
+	bioguideId, err := ParseMember(memberId)
+	if err != nil {
+		return "", err
+	}
+	return Format(KIND_TERM, bioguideId, strconv.Itoa(congress), strings.ToLower(chamber)), nil
+}
+
+// PartyMembership identifies the affiliation of a member to a party from a year,
+// e.g. us-congress:party-membership:B000574:Democratic:1996
+func PartyMembership(memberId string, partyId string, startYear int) (string, error) {
+	bioguideId, err := ParseMember(memberId)
+	if err != nil {
+		return "", err
+	}
+	party, err := parseKind(partyId, KIND_PARTY, 1)
+	if err != nil {
+		return "", err
+	}
+	return Format(KIND_PARTY_MEMBERSHIP, bioguideId, party.Parts[0], strconv.Itoa(startYear)), nil
+}
+
+var memberUrlRegex = regexp.MustCompile(`/member/([A-Za-z]\d{6})(?:[/?]|$)`)
+var billUrlRegex = regexp.MustCompile(`/bill/(\d+)/([a-zA-Z]+)/(\d+)(?:[/?]|$)`)
+var committeeReportUrlRegex = regexp.MustCompile(`/committee-report/(\d+)/([a-zA-Z]+)/(\d+)(?:[/?]|$)`)
+
+// MemberFromUrl returns the Member id of a congress.gov member url, e.g.
+// https://api.congress.gov/v3/member/B000574?format=json
+func MemberFromUrl(url string) (string, bool) {
+	match := memberUrlRegex.FindStringSubmatch(url)
+	if match == nil {
+		return "", false
+	}
+	return Member(match[1]), true
+}
+
+// BillFromUrl returns the Bill id of a congress.gov bill url, e.g.
+// https://api.congress.gov/v3/bill/118/hr/1234?format=json
+func BillFromUrl(url string) (string, bool) {
+	match := billUrlRegex.FindStringSubmatch(url)
+	if match == nil {
+		return "", false
+	}
+	congress, _ := strconv.Atoi(match[1])
+	return Bill(congress, match[2], match[3]), true
+}
+
+// CommitteeReportFromUrl returns the CommitteeReport id of a congress.gov committee report url, e.g.
+// https://api.congress.gov/v3/committee-report/117/HRPT/1?format=json
+func CommitteeReportFromUrl(url string) (string, bool) {
+	match := committeeReportUrlRegex.FindStringSubmatch(url)
+	if match == nil {
+		return "", false
+	}
+	congress, _ := strconv.Atoi(match[1])
+	return CommitteeReport(congress, match[2], match[3]), true
+}
@@ -0,0 +1,137 @@
+package ids
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		got  string
+		want string
+	}{
+		{Member("b000574"), "us-congress:member:B000574"},
+		{Bill(118, "HR", "1234"), "us-congress:bill:118:hr:1234"},
+		{Amendment(118, "SAMDT", "12"), "us-congress:amendment:118:samdt:12"},
+		{BillAction(118, "hr", "1234", "2023-05-01"), "us-congress:bill-action:118:hr:1234:2023-05-01"},
+		{Vote(118, "House", 1, 123), "us-congress:vote:118:house:1:123"},
+		{Committee("HSAG00"), "us-congress:committee:hsag00"},
+		{Subject("Solar energy"), "us-congress:subject:Solar energy"},
+		{Subject("Water: supply"), "us-congress:subject:Water%3A supply"},
+		{Party("100%"), "us-congress:party:100%25"},
+		{State("or"), "us-congress:state:OR"},
+		{District("or", 0), "us-congress:district:OR:0"},
+	}
+	for _, test := range tests {
+		if test.got != test.want {
+			t.Errorf("got %s, want %s", test.got, test.want)
+		}
+	}
+}
+
+func TestParseRoundTrip(t *testing.T) {
+	tests := []struct {
+		kind  string
+		parts []string
+	}{
+		{KIND_MEMBER, []string{"B000574"}},
+		{KIND_BILL, []string{"118", "hr", "1234"}},
+		{KIND_SUBJECT, []string{"Water: supply"}},
+		{KIND_PARTY, []string{"100% Party"}},
+		{KIND_SUBJECT, []string{"%3A"}},
+	}
+	for _, test := range tests {
+		value := Format(test.kind, test.parts...)
+		id, err := Parse(value)
+		if err != nil {
+			t.Errorf("Parse(%q): %s", value, err)
+			continue
+		}
+		if id.Namespace != NAMESPACE || id.Kind != test.kind || !reflect.DeepEqual(id.Parts, test.parts) {
+			t.Errorf("Parse(%q) = %v, want %s %v", value, id, test.kind, test.parts)
+		}
+		if id.String() != value {
+			t.Errorf("Parse(%q).String() = %q", value, id.String())
+		}
+	}
+}
+
+func TestParseKinds(t *testing.T) {
+	if bioguideId, err := ParseMember(Member("B000574")); err != nil || bioguideId != "B000574" {
+		t.Errorf("ParseMember = %q, %v", bioguideId, err)
+	}
+	if congress, billType, number, err := ParseBill(Bill(118, "HR", "1234")); err != nil || congress != 118 || billType != "hr" || number != "1234" {
+		t.Errorf("ParseBill = %d %s %s, %v", congress, billType, number, err)
+	}
+	if congress, reportType, number, err := ParseCommitteeReport(CommitteeReport(117, "HRPT", "1")); err != nil || congress != 117 || reportType != "hrpt" || number != "1" {
+		t.Errorf("ParseCommitteeReport = %d %s %s, %v", congress, reportType, number, err)
+	}
+
+	invalid := []struct {
+		name  string
+		parse func() error
+	}{
+		{"member of a bill id", func() error { _, err := ParseMember(Bill(118, "hr", "1")); return err }},
+		{"bill with a part missing", func() error { _, _, _, err := ParseBill("us-congress:bill:118:hr"); return err }},
+		{"bill of another namespace", func() error { _, _, _, err := ParseBill("other:bill:118:hr:1"); return err }},
+		{"bill with an invalid congress", func() error { _, _, _, err := ParseBill("us-congress:bill:xx:hr:1"); return err }},
+		{"hashed id of earlier versions", func() error { _, err := Parse("5f2b9c0e"); return err }},
+	}
+	for _, test := range invalid {
+		if err := test.parse(); !errors.Is(err, ErrInvalidId) {
+			t.Errorf("%s: got %v, want %v", test.name, err, ErrInvalidId)
+		}
+	}
+}
+
+func TestRelationIds(t *testing.T) {
+	member := Member("B000574")
+	tests := []struct {
+		name string
+		id   func() (string, error)
+		want string
+	}{
+		{"cast vote", func() (string, error) { return CastVote(Vote(118, "house", 1, 123), member) }, "us-congress:cast-vote:118:house:1:123:B000574"},
+		{"bill sponsorship", func() (string, error) { return Sponsorship(Bill(118, "hr", "1234"), member) }, "us-congress:sponsorship:118:hr:1234:B000574"},
+		{"amendment sponsorship", func() (string, error) { return Sponsorship(Amendment(118, "hamdt", "7"), member) }, "us-congress:sponsorship:118:hamdt:7:B000574"},
+		{"cosponsorship", func() (string, error) { return Cosponsorship(Bill(118, "hr", "1234"), member) }, "us-congress:cosponsorship:118:hr:1234:B000574"},
+		{"committee membership", func() (string, error) { return CommitteeMembership(Committee("hsag00"), member) }, "us-congress:committee-membership:hsag00:B000574"},
+		{"referral", func() (string, error) { return Referral(Bill(118, "hr", "1234"), Committee("hsag00")) }, "us-congress:referral:118:hr:1234:hsag00"},
+		{"bill subject", func() (string, error) { return BillSubject(Bill(118, "hr", "1234"), Subject("Solar energy")) }, "us-congress:bill-subject:118:hr:1234:Solar energy"},
+		{"bill action", func() (string, error) { return BillActionOf(Bill(118, "hr", "1234"), "2023-05-01") }, "us-congress:bill-action:118:hr:1234:2023-05-01"},
+		{"term", func() (string, error) { return Term(member, 118, "House") }, "us-congress:term:B000574:118:house"},
+		{"party membership", func() (string, error) { return PartyMembership(member, Party("Democratic"), 1996) }, "us-congress:party-membership:B000574:Democratic:1996"},
+		{"cast vote of a bill", func() (string, error) { return CastVote(Bill(118, "hr", "1234"), member) }, ""},
+		{"term of a committee", func() (string, error) { return Term(Committee("hsag00"), 118, "house") }, ""},
+	}
+	for _, test := range tests {
+		got, err := test.id()
+		if test.want == "" && !errors.Is(err, ErrInvalidId) {
+			t.Errorf("%s: got %q, %v, want %v", test.name, got, err, ErrInvalidId)
+		}
+		if test.want != "" && (err != nil || got != test.want) {
+			t.Errorf("%s: got %q, %v, want %q", test.name, got, err, test.want)
+		}
+	}
+}
+
+func TestFromUrl(t *testing.T) {
+	tests := []struct {
+		name  string
+		parse func(url string) (string, bool)
+		url   string
+		want  string
+	}{
+		{"member", MemberFromUrl, "https://api.congress.gov/v3/member/B000574?format=json", Member("B000574")},
+		{"bill", BillFromUrl, "https://api.congress.gov/v3/bill/118/hr/1234/actions?format=json", Bill(118, "hr", "1234")},
+		{"committee report", CommitteeReportFromUrl, "https://api.congress.gov/v3/committee-report/117/HRPT/1?format=json", CommitteeReport(117, "hrpt", "1")},
+		{"bill list", BillFromUrl, "https://api.congress.gov/v3/bill/118?format=json", ""},
+	}
+	for _, test := range tests {
+		got, ok := test.parse(test.url)
+		if got != test.want || ok != (test.want != "") {
+			t.Errorf("%s: got %q, %t, want %q", test.name, got, ok, test.want)
+		}
+	}
+}
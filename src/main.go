@@ -2,34 +2,53 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
+	"log"
+	"os"
 
 	"github.com/nedvisol/go-connectdots/cacheditem"
 	"github.com/nedvisol/go-connectdots/config"
 	"github.com/nedvisol/go-connectdots/downloadmgr"
+	"github.com/nedvisol/go-connectdots/entityres"
 	"github.com/nedvisol/go-connectdots/graphdb"
+	"github.com/nedvisol/go-connectdots/health"
 	"github.com/nedvisol/go-connectdots/processor"
+	"github.com/nedvisol/go-connectdots/syncstate"
+	"github.com/nedvisol/go-connectdots/util"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.uber.org/fx"
 )
 
-func NewMongoClient(ctx context.Context, config *config.Config) *mongo.Client {
+// NewMongoClient connects to MongoDB, retrying the ping up to config.StartupRetries times before returning an error
+func NewMongoClient(lifecycle fx.Lifecycle, ctx context.Context, config *config.Config) (*mongo.Client, error) {
 	clientOptions := options.Client().ApplyURI(config.MongoUrl) // Replace with your MongoDB URI
 
 	// Connect to MongoDB
 	client, err := mongo.Connect(ctx, clientOptions)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
 	// Ping the database to ensure it's connected
-	err = client.Ping(ctx, nil)
+	err = util.Retry("mongodb ping", config.StartupRetries, config.StartupRetryDelay, func() error {
+		pingCtx, cancel := context.WithTimeout(ctx, health.CHECK_TIMEOUT)
+		defer cancel()
+		return client.Ping(pingCtx, nil)
+	})
 	if err != nil {
-		panic(err)
+		client.Disconnect(ctx)
+		return nil, fmt.Errorf("mongodb at %s is not reachable: %w", config.MongoUrl, err)
 	}
 	fmt.Println("Connected to MongoDB!")
-	return client
+
+	lifecycle.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			return client.Disconnect(ctx)
+		},
+	})
+	return client, nil
 }
 
 func NewMongoDatabase(client *mongo.Client, config *config.Config) *mongo.Database {
@@ -44,11 +63,57 @@ func NewDownloadManagerOptions(db *mongo.Database, config *config.Config) *downl
 	}
 }
 
-func AppStart(lifecycle fx.Lifecycle, ctx context.Context, congressGov *processor.CongressGovProcessor) {
+// RegisterHealthChecks reports the stores the processors depend on at /healthz
+func RegisterHealthChecks(registry *health.Registry, client *mongo.Client, graphdbsvc graphdb.GraphDbService) {
+	registry.Register("mongodb", func(ctx context.Context) error {
+		return client.Ping(ctx, nil)
+	})
+	registry.Register("graphdb", graphdbsvc.Ping)
+}
+
+func ApplyGraphSchema(ctx context.Context, graphdbsvc graphdb.GraphDbService) error {
+	err := graphdbsvc.ApplySchema(ctx, graphdb.DefaultSchema)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Graph schema applied!")
+	return nil
+}
+
+// MigrateGraph migrates the graph below the provenance and history decorators, which would stamp the migrated
+// entities as crawled, before the schema is applied. With MigrateDryRun or the dryrun write mode the pending
+// migrations are only listed, as nothing is written the processors still run.
+func MigrateGraph(ctx context.Context, graphdbsvc graphdb.GraphDbService, cfg *config.Config) error {
+	migrator := graphdb.NewMigrator(graphdb.Unwrapped(graphdbsvc), graphdb.Migrations)
+	if cfg.GraphDb.MigrateDryRun || cfg.GraphDb.WriteMode == config.WRITE_MODE_DRYRUN {
+		pending, err := migrator.Pending(ctx)
+		if err != nil {
+			return err
+		}
+		for _, migration := range pending {
+			fmt.Printf("pending graph migration %d %s\n", migration.Version, migration.Name)
+		}
+		return nil
+	}
+	if cfg.GraphDb.MigrateOnStart {
+		migrations, err := migrator.Migrate(ctx, false)
+		for _, migration := range migrations {
+			fmt.Printf("applied graph migration %d %s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	// refuse to run the processors against an out-of-date graph
+	return migrator.CheckUpToDate(ctx)
+}
+
+func AppStart(lifecycle fx.Lifecycle, ctx context.Context, congressGov *processor.CongressGovProcessor, changes *graphdb.ChangeStream) {
 
 	lifecycle.Append(fx.Hook{
 		OnStop: func(ctx context.Context) error {
 			fmt.Println("Application is stopping. Cleaning up resources...")
+			changes.PrintSummary()
 			// Perform cleanup actions here (close connections, release resources, etc.)
 			return nil
 		},
@@ -57,7 +122,60 @@ func AppStart(lifecycle fx.Lifecycle, ctx context.Context, congressGov *processo
 	congressGov.Start()
 }
 
+// crawlScopeFlags parses the flags narrowing the crawl scope of the run or making it incremental, and returns the decorator
+// applying them to the config
+func crawlScopeFlags(args []string) func(*config.Config) *config.Config {
+	flags := flag.NewFlagSet("connectdots", flag.ExitOnError)
+	congresses := flags.String("congress", "", "congress numbers or ranges to crawl, e.g. 117-118, instead of the latest ones")
+	latest := flags.Int("latest-congresses", -1, "number of most recent congresses to crawl, 0 for all")
+	billTypes := flags.String("bill-types", "", "comma separated bill types to crawl, e.g. hr,s,hjres")
+	chambers := flags.String("chambers", "", "comma separated chambers to crawl, house or senate")
+	historical := flags.Bool("historical-members", false, "crawl every member of congress instead of the current ones")
+	from := flags.String("from", "", "crawl bills updated and actions taken on or after this date, YYYY-MM-DD")
+	to := flags.String("to", "", "crawl bills updated and actions taken on or before this date, YYYY-MM-DD")
+	incremental := flags.Bool("incremental", false, "crawl only the members and bills updated since the last sync")
+	flags.Parse(args)
+
+	return func(cfg *config.Config) *config.Config {
+		scope := cfg.Scope
+		if *congresses != "" {
+			parsed, err := config.ParseCongresses(*congresses)
+			if err != nil {
+				log.Fatalf("invalid -congress: %s", err)
+			}
+			scope.Congresses = parsed
+		}
+		if *latest >= 0 {
+			scope.LatestCongresses = *latest
+		}
+		if *billTypes != "" {
+			scope.BillTypes = splitList(*billTypes)
+		}
+		if *chambers != "" {
+			scope.Chambers = splitList(*chambers)
+		}
+		if *historical {
+			scope.HistoricalMembers = true
+		}
+		if date := parseDateFlag("from", *from); date != nil {
+			scope.FromDate = date
+		}
+		if date := parseDateFlag("to", *to); date != nil {
+			scope.ToDate = date
+		}
+		if *incremental {
+			cfg.CongressGov.Incremental = true
+		}
+		return cfg
+	}
+}
+
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "graph" {
+		runGraphCommand(os.Args[2:])
+		return
+	}
+	applyCrawlScope := crawlScopeFlags(os.Args[1:])
 
 	//ctx, _ := context.WithCancel(context.Background())
 
@@ -69,9 +187,19 @@ func main() {
 			NewMongoDatabase,
 			NewDownloadManagerOptions,
 			downloadmgr.NewDownloadManager,
-			graphdb.NewNeo4jGraphService,
+			graphdb.NewChangeStream,
+			graphdb.NewGraphDbService,
+			entityres.NewCrosswalk,
+			entityres.NewResolver,
+			syncstate.NewSyncStateMongoRepository,
 			processor.NewCongressGovProcessor,
+			health.NewRegistry,
 		),
+		fx.Decorate(applyCrawlScope),
+		fx.Invoke(RegisterHealthChecks),
+		fx.Invoke(health.StartHealthServer),
+		fx.Invoke(MigrateGraph),
+		fx.Invoke(ApplyGraphSchema),
 		fx.Invoke(AppStart),
 	)
 
@@ -0,0 +1,59 @@
+package model
+
+// CongressApiAmendmentsResponse lists amendments, e.g. https://api.congress.gov/v3/amendment/118?format=json
+type CongressApiAmendmentsResponse struct {
+	Amendments []*CongressApiAmendment `json:"amendments"`
+	Pagination *CongressApiPagination  `json:"pagination"`
+	Request    *CongressApiRequest     `json:"request"`
+}
+
+type CongressApiAmendment struct {
+	Congress     int                `json:"congress"`
+	Description  *string            `json:"description,omitempty"`
+	LatestAction *CongressApiAction `json:"latestAction"`
+	Number       *string            `json:"number"`
+	Purpose      *string            `json:"purpose,omitempty"`
+	Type         *string            `json:"type"` // HAMDT, SAMDT or SUAMDT
+	UpdateDate   *string            `json:"updateDate"`
+	URL          *string            `json:"url"`
+}
+
+// CongressApiAmendmentDetailResponse is the response of the amendment detail endpoint, e.g.
+// https://api.congress.gov/v3/amendment/118/samdt/1234?format=json
+type CongressApiAmendmentDetailResponse struct {
+	Amendment *CongressApiAmendmentDetail `json:"amendment"`
+	Request   *CongressApiRequest         `json:"request"`
+}
+
+type CongressApiAmendmentDetail struct {
+	AmendedAmendment *CongressApiAmendedAmendment `json:"amendedAmendment,omitempty"`
+	AmendedBill      *CongressApiAmendedBill      `json:"amendedBill,omitempty"`
+	Chamber          *string                      `json:"chamber"`
+	Congress         int                          `json:"congress"`
+	Description      *string                      `json:"description,omitempty"`
+	Number           *string                      `json:"number"`
+	ProposedDate     *string                      `json:"proposedDate,omitempty"`
+	Purpose          *string                      `json:"purpose,omitempty"`
+	Sponsors         []*CongressApiSponsor        `json:"sponsors"`
+	SubmittedDate    *string                      `json:"submittedDate"`
+	Type             *string                      `json:"type"`
+	UpdateDate       *string                      `json:"updateDate"`
+	URL              *string                      `json:"url"`
+}
+
+type CongressApiAmendedBill struct {
+	Congress      int     `json:"congress"`
+	Number        *string `json:"number"`
+	OriginChamber *string `json:"originChamber"`
+	Title         *string `json:"title"`
+	Type          *string `json:"type"`
+	URL           *string `json:"url"`
+}
+
+type CongressApiAmendedAmendment struct {
+	Congress int     `json:"congress"`
+	Number   *string `json:"number"`
+	Purpose  *string `json:"purpose,omitempty"`
+	Type     *string `json:"type"`
+	URL      *string `json:"url"`
+}
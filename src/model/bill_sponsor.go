@@ -0,0 +1,55 @@
+package model
+
+// CongressApiBillDetailResponse is the response of the bill detail endpoint, e.g.
+// https://api.congress.gov/v3/bill/118/hr/1234?format=json
+type CongressApiBillDetailResponse struct {
+	Bill    *CongressApiBillDetail `json:"bill"`
+	Request *CongressApiRequest    `json:"request"`
+}
+
+type CongressApiBillDetail struct {
+	Congress       int                   `json:"congress"`
+	Number         *string               `json:"number"`
+	Type           *string               `json:"type"`
+	Title          *string               `json:"title"`
+	IntroducedDate *string               `json:"introducedDate"`
+	UpdateDate     *string               `json:"updateDate"`
+	Sponsors       []*CongressApiSponsor `json:"sponsors"`
+	URL            *string               `json:"url"`
+}
+
+type CongressApiSponsor struct {
+	BioguideID  *string `json:"bioguideId"`
+	District    *int    `json:"district,omitempty"`
+	FirstName   *string `json:"firstName"`
+	FullName    *string `json:"fullName"`
+	IsByRequest *string `json:"isByRequest"`
+	LastName    *string `json:"lastName"`
+	MiddleName  *string `json:"middleName,omitempty"`
+	Party       *string `json:"party"`
+	State       *string `json:"state"`
+	URL         *string `json:"url"`
+}
+
+// CongressApiCosponsorsResponse lists the cosponsors of a bill, including the withdrawn ones, e.g.
+// https://api.congress.gov/v3/bill/118/hr/1234/cosponsors?format=json
+type CongressApiCosponsorsResponse struct {
+	Cosponsors []*CongressApiCosponsor `json:"cosponsors"`
+	Pagination *CongressApiPagination  `json:"pagination"`
+	Request    *CongressApiRequest     `json:"request"`
+}
+
+type CongressApiCosponsor struct {
+	BioguideID               *string `json:"bioguideId"`
+	District                 *int    `json:"district,omitempty"`
+	FirstName                *string `json:"firstName"`
+	FullName                 *string `json:"fullName"`
+	IsOriginalCosponsor      bool    `json:"isOriginalCosponsor"`
+	LastName                 *string `json:"lastName"`
+	MiddleName               *string `json:"middleName,omitempty"`
+	Party                    *string `json:"party"`
+	SponsorshipDate          *string `json:"sponsorshipDate"`
+	SponsorshipWithdrawnDate *string `json:"sponsorshipWithdrawnDate,omitempty"`
+	State                    *string `json:"state"`
+	URL                      *string `json:"url"`
+}
@@ -0,0 +1,35 @@
+package model
+
+// CongressApiBillSubjectsResponse lists the subjects and policy area of a bill, e.g.
+// https://api.congress.gov/v3/bill/118/hr/1234/subjects?format=json
+type CongressApiBillSubjectsResponse struct {
+	Subjects   *CongressApiBillSubjects `json:"subjects"`
+	Pagination *CongressApiPagination   `json:"pagination"`
+	Request    *CongressApiRequest      `json:"request"`
+}
+
+type CongressApiBillSubjects struct {
+	LegislativeSubjects []*CongressApiSubject `json:"legislativeSubjects"`
+	PolicyArea          *CongressApiSubject   `json:"policyArea,omitempty"`
+}
+
+type CongressApiSubject struct {
+	Name       *string `json:"name"`
+	UpdateDate *string `json:"updateDate,omitempty"`
+}
+
+// CongressApiBillSummariesResponse lists the CRS summaries of the versions of a bill, e.g.
+// https://api.congress.gov/v3/bill/118/hr/1234/summaries?format=json
+type CongressApiBillSummariesResponse struct {
+	Summaries  []*CongressApiBillSummary `json:"summaries"`
+	Pagination *CongressApiPagination    `json:"pagination"`
+	Request    *CongressApiRequest       `json:"request"`
+}
+
+type CongressApiBillSummary struct {
+	ActionDate  *string `json:"actionDate"`
+	ActionDesc  *string `json:"actionDesc"`
+	Text        *string `json:"text"`
+	UpdateDate  *string `json:"updateDate"`
+	VersionCode *string `json:"versionCode"`
+}
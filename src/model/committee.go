@@ -0,0 +1,77 @@
+package model
+
+// CongressApiCommitteesResponse lists committees, e.g. https://api.congress.gov/v3/committee/house?format=json
+type CongressApiCommitteesResponse struct {
+	Committees []*CongressApiCommittee `json:"committees"`
+	Pagination *CongressApiPagination  `json:"pagination"`
+	Request    *CongressApiRequest     `json:"request"`
+}
+
+type CongressApiCommittee struct {
+	Chamber           *string                    `json:"chamber"`
+	CommitteeTypeCode *string                    `json:"committeeTypeCode"`
+	Name              *string                    `json:"name"`
+	Parent            *CongressApiCommitteeRef   `json:"parent,omitempty"`
+	Subcommittees     []*CongressApiCommitteeRef `json:"subcommittees,omitempty"`
+	SystemCode        *string                    `json:"systemCode"`
+	UpdateDate        *string                    `json:"updateDate"`
+	URL               *string                    `json:"url"`
+}
+
+type CongressApiCommitteeRef struct {
+	Name       *string `json:"name"`
+	SystemCode *string `json:"systemCode"`
+	URL        *string `json:"url"`
+}
+
+// CongressApiBillCommitteesResponse lists the committees a bill was referred to, e.g.
+// https://api.congress.gov/v3/bill/118/hr/1234/committees?format=json
+type CongressApiBillCommitteesResponse struct {
+	Committees []*CongressApiBillCommittee `json:"committees"`
+	Pagination *CongressApiPagination      `json:"pagination"`
+	Request    *CongressApiRequest         `json:"request"`
+}
+
+type CongressApiBillCommittee struct {
+	Activities    []*CongressApiCommitteeActivity `json:"activities"`
+	Chamber       *string                         `json:"chamber"`
+	Name          *string                         `json:"name"`
+	Subcommittees []*CongressApiBillCommittee     `json:"subcommittees,omitempty"`
+	SystemCode    *string                         `json:"systemCode"`
+	Type          *string                         `json:"type"`
+	URL           *string                         `json:"url"`
+}
+
+type CongressApiCommitteeActivity struct {
+	Date *string `json:"date"`
+	Name *string `json:"name"`
+}
+
+// CongressApiCommitteeReportsResponse lists the reports of a committee, e.g.
+// https://api.congress.gov/v3/committee/house/hsag00/reports?format=json
+type CongressApiCommitteeReportsResponse struct {
+	Reports    []*CongressApiCommitteeReportRef `json:"reports"`
+	Pagination *CongressApiPagination           `json:"pagination"`
+	Request    *CongressApiRequest              `json:"request"`
+}
+
+type CongressApiCommitteeReportRef struct {
+	Citation   *string `json:"citation"`
+	UpdateDate *string `json:"updateDate"`
+	URL        *string `json:"url"`
+}
+
+// CommitteeMembership is a committee-membership-current.json file of the unitedstates/congress-legislators
+// project, the members of each committee and subcommittee by thomas id, e.g. HSAG and HSAG15
+type CommitteeMembership map[string][]*CommitteeMember
+
+type CommitteeMember struct {
+	Name     string `json:"name"`
+	Bioguide string `json:"bioguide"`
+	Party    string `json:"party"` // majority or minority
+	Rank     int    `json:"rank"`
+	Title    string `json:"title,omitempty"` // e.g. Chair, Ranking Member, empty for members
+	// the current membership file leaves the dates out, historical extracts carry them
+	StartDate string `json:"start_date,omitempty"`
+	EndDate   string `json:"end_date,omitempty"`
+}
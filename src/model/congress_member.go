@@ -38,6 +38,11 @@ type CongressApiPagination struct {
 	Next  *string `json:"next"`
 }
 
+// CongressApiPage is the part every list response of congress.gov shares
+type CongressApiPage struct {
+	Pagination *CongressApiPagination `json:"pagination"`
+}
+
 type CongressApiRequest struct {
 	ContentType string `json:"contentType"`
 	Format      string `json:"format"`
@@ -143,7 +148,10 @@ type CongressApiHouseVoteMetadata struct {
 	ActionDate   *string                     `xml:"action-date"`
 	ActionTime   *CongressApiHouseActionTime `xml:"action-time"`
 	VoteDesc     *string                     `xml:"vote-desc"`
-	VoteTotals   *CongressApiHouseVoteTotals `xml:"vote-totals"`
+	// amendment votes number the amendment in the order of the rule, not as congress.gov does
+	AmendmentNum    *string                     `xml:"amendment-num"`
+	AmendmentAuthor *string                     `xml:"amendment-author"`
+	VoteTotals      *CongressApiHouseVoteTotals `xml:"vote-totals"`
 }
 
 type CongressApiHouseActionTime struct {
@@ -0,0 +1,48 @@
+package model
+
+// CongressApiMemberDetailResponse is the response of the member detail endpoint, e.g.
+// https://api.congress.gov/v3/member/B000574?format=json
+type CongressApiMemberDetailResponse struct {
+	Member  *CongressApiMemberDetail `json:"member"`
+	Request *CongressApiRequest      `json:"request"`
+}
+
+type CongressApiMemberDetail struct {
+	BioguideID         string                      `json:"bioguideId"`
+	BirthYear          *string                     `json:"birthYear,omitempty"`
+	CurrentMember      bool                        `json:"currentMember"`
+	Depiction          *CongressApiMemberDepiction `json:"depiction,omitempty"`
+	DirectOrderName    *string                     `json:"directOrderName"`
+	FirstName          *string                     `json:"firstName"`
+	LastName           *string                     `json:"lastName"`
+	Leadership         []*CongressApiLeadership    `json:"leadership,omitempty"`
+	OfficialWebsiteUrl *string                     `json:"officialWebsiteUrl,omitempty"`
+	PartyHistory       []*CongressApiPartyHistory  `json:"partyHistory"`
+	Terms              []*CongressApiMemberTerm    `json:"terms"`
+	UpdateDate         *string                     `json:"updateDate"`
+}
+
+type CongressApiLeadership struct {
+	Congress int     `json:"congress"`
+	Current  *bool   `json:"current,omitempty"`
+	Type     *string `json:"type"`
+}
+
+type CongressApiPartyHistory struct {
+	EndYear           *int    `json:"endYear,omitempty"`
+	PartyAbbreviation *string `json:"partyAbbreviation"`
+	PartyName         *string `json:"partyName"`
+	StartYear         int     `json:"startYear"`
+}
+
+// CongressApiMemberTerm is a term served in a chamber during a congress, representatives have a district
+type CongressApiMemberTerm struct {
+	Chamber    *string `json:"chamber"`
+	Congress   int     `json:"congress"`
+	District   *int    `json:"district,omitempty"`
+	EndYear    *int    `json:"endYear,omitempty"`
+	MemberType *string `json:"memberType"`
+	StartYear  int     `json:"startYear"`
+	StateCode  *string `json:"stateCode"`
+	StateName  *string `json:"stateName"`
+}
@@ -0,0 +1,69 @@
+package model
+
+import "encoding/xml"
+
+// CongressApiSenateRollCallVote is a roll_call_vote XML document of senate.gov, e.g.
+// https://www.senate.gov/legislative/LIS/roll_call_votes/vote1181/vote_118_1_00005.xml
+type CongressApiSenateRollCallVote struct {
+	XMLName             xml.Name                         `xml:"roll_call_vote"`
+	Congress            *string                          `xml:"congress"`
+	Session             *string                          `xml:"session"`
+	CongressYear        *string                          `xml:"congress_year"`
+	VoteNumber          *string                          `xml:"vote_number"`
+	VoteDate            *string                          `xml:"vote_date"`
+	ModifyDate          *string                          `xml:"modify_date"`
+	VoteQuestionText    *string                          `xml:"vote_question_text"`
+	VoteDocumentText    *string                          `xml:"vote_document_text"`
+	VoteResultText      *string                          `xml:"vote_result_text"`
+	Question            *string                          `xml:"question"`
+	VoteTitle           *string                          `xml:"vote_title"`
+	MajorityRequirement *string                          `xml:"majority_requirement"`
+	VoteResult          *string                          `xml:"vote_result"`
+	Document            *CongressApiSenateVoteDocument   `xml:"document"`
+	Amendment           *CongressApiSenateVoteAmendment  `xml:"amendment"`
+	Count               *CongressApiSenateVoteCount      `xml:"count"`
+	TieBreaker          *CongressApiSenateVoteTieBreaker `xml:"tie_breaker"`
+	Members             []*CongressApiSenateVoteMember   `xml:"members>member"`
+}
+
+type CongressApiSenateVoteDocument struct {
+	DocumentCongress   *string `xml:"document_congress"`
+	DocumentType       *string `xml:"document_type"`
+	DocumentNumber     *string `xml:"document_number"`
+	DocumentName       *string `xml:"document_name"`
+	DocumentTitle      *string `xml:"document_title"`
+	DocumentShortTitle *string `xml:"document_short_title"`
+}
+
+type CongressApiSenateVoteAmendment struct {
+	AmendmentNumber                       *string `xml:"amendment_number"`
+	AmendmentToAmendmentNumber            *string `xml:"amendment_to_amendment_number"`
+	AmendmentToAmendmentToAmendmentNumber *string `xml:"amendment_to_amendment_to_amendment_number"`
+	AmendmentToDocumentNumber             *string `xml:"amendment_to_document_number"`
+	AmendmentToDocumentShortTitle         *string `xml:"amendment_to_document_short_title"`
+	AmendmentPurpose                      *string `xml:"amendment_purpose"`
+}
+
+type CongressApiSenateVoteCount struct {
+	Yeas    *string `xml:"yeas"`
+	Nays    *string `xml:"nays"`
+	Present *string `xml:"present"`
+	Absent  *string `xml:"absent"`
+}
+
+type CongressApiSenateVoteTieBreaker struct {
+	ByWhom         *string `xml:"by_whom"`
+	TieBreakerVote *string `xml:"tie_breaker_vote"`
+}
+
+// CongressApiSenateVoteMember is the vote of a senator, identified by the LIS member id of the Senate
+// instead of a bioguide id
+type CongressApiSenateVoteMember struct {
+	MemberFull  *string `xml:"member_full"`
+	LastName    *string `xml:"last_name"`
+	FirstName   *string `xml:"first_name"`
+	Party       *string `xml:"party"`
+	State       *string `xml:"state"`
+	VoteCast    *string `xml:"vote_cast"`
+	LisMemberId *string `xml:"lis_member_id"`
+}
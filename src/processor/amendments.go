@@ -0,0 +1,250 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/nedvisol/go-connectdots/config"
+	"github.com/nedvisol/go-connectdots/downloadmgr"
+	"github.com/nedvisol/go-connectdots/graphdb"
+	"github.com/nedvisol/go-connectdots/ids"
+	"github.com/nedvisol/go-connectdots/model"
+)
+
+const AMENDMENTS_URL = "https://api.congress.gov/v3/amendment/%d?format=json&limit=250"
+const AMENDMENT_ACTIONS_URL_QUERY = "/actions?format=json&limit=250"
+
+const amendmentContextKey key = 4
+
+// amendment numbers of roll calls, e.g. H AMDT 123 in a clerk.house.gov legis-num, S.Amdt. 1234 or
+// S.Up.Amdt. 12 in a senate.gov amendment_number
+var houseAmendmentRegex = regexp.MustCompile(`(?i)^H\.?\s*AMDT\.?\s*(\d+)$`)
+var senateAmendmentRegex = regexp.MustCompile(`(?i)^S\.?\s*(UP\.?\s*)?AMDT\.?\s*(\d+)$`)
+
+// houseAmendmentQuestionRegex matches the questions of House roll calls on amendments, whose legis-num
+// is the amended bill
+var houseAmendmentQuestionRegex = regexp.MustCompile(`(?i)^On Agreeing to the .*Amendment`)
+
+// amendmentChamber returns the chamber of an amendment type, HAMDT are House amendments
+func amendmentChamber(amendmentType string) string {
+	if strings.EqualFold(amendmentType, "HAMDT") {
+		return config.CHAMBER_HOUSE
+	}
+	return config.CHAMBER_SENATE
+}
+
+// parseAmendmentRef returns the type and number of the amendment named by a roll call
+func parseAmendmentRef(ref string) (string, string, bool) {
+	ref = strings.TrimSpace(ref)
+	if match := houseAmendmentRegex.FindStringSubmatch(ref); match != nil {
+		return "hamdt", match[1], true
+	}
+	if match := senateAmendmentRegex.FindStringSubmatch(ref); match != nil {
+		if match[1] != "" {
+			return "suamdt", match[2], true
+		}
+		return "samdt", match[2], true
+	}
+	return "", "", false
+}
+
+// createAmendmentNode creates or updates the Amendment and returns its id, attrs adds to the
+// congress, type and number
+func (c *CongressGovProcessor) createAmendmentNode(
+	ctx context.Context,
+	congress int,
+	amendmentType string,
+	number string,
+	attrs map[string]interface{},
+) string {
+	attrs["congress"] = congress
+	attrs["amendmentType"] = strings.ToLower(amendmentType)
+	attrs["number"] = number
+	amendmentNode := &graphdb.NodeInfo{
+		Id:         ids.Amendment(congress, amendmentType, number),
+		Label:      "Amendment",
+		Attrs:      &attrs,
+		Provenance: c.provenance(ctx),
+	}
+	err := c.graphdbsvc.UpdateNode(ctx, amendmentNode, true)
+	if err != nil {
+		panic(err)
+	}
+	return amendmentNode.Id
+}
+
+// voteSubject returns the node the roll call recorded in ctx concerns: the amendment whose actions
+// recorded it, the amendment named by amendmentRef, or the bill in ctx. It returns nil for votes
+// onAmendment whose amendment is not named, the crawl of the amendment actions records them.
+func (c *CongressGovProcessor) voteSubject(ctx context.Context, amendmentRef string, onAmendment bool) *graphdb.NodeInfo {
+	if amendmentId, found := ctx.Value(amendmentContextKey).(string); found {
+		return &graphdb.NodeInfo{Id: amendmentId, Label: "Amendment"}
+	}
+	if amendmentType, number, found := parseAmendmentRef(amendmentRef); found {
+		recordedVote := ctx.Value(recordedVoteContextKey).(*model.CongressApiRecordedVote)
+		amendmentId := c.createAmendmentNode(ctx, *recordedVote.Congress, amendmentType, number, map[string]interface{}{})
+		return &graphdb.NodeInfo{Id: amendmentId, Label: "Amendment"}
+	}
+	if onAmendment {
+		return nil
+	}
+	bill := ctx.Value(billContextKey).(*model.CongressApiBill)
+	return &graphdb.NodeInfo{Id: ids.Bill(bill.Congress, *bill.Type, *bill.Number), Label: "Bill"}
+}
+
+// downloadAmendments downloads the amendments of congress
+func (c *CongressGovProcessor) downloadAmendments(ctx context.Context, congress int) {
+	c.downloadUpdates(
+		ctx,
+		ENDPOINT_AMENDMENT,
+		fmt.Sprintf(AMENDMENTS_URL, congress),
+		c.config.Scope.FromDate,
+		c.config.Scope.ToDate,
+		c.processAmendments,
+		nil,
+	)
+}
+
+func (c *CongressGovProcessor) processAmendments(ctx context.Context, data []byte) {
+	fmt.Printf("processing amendments %d bytes\n", len(data))
+
+	var result model.CongressApiAmendmentsResponse
+
+	// Parse (unmarshal) the JSON into the map
+	err := json.Unmarshal(data, &result)
+	if err != nil {
+		log.Fatalf("Error parsing JSON: %s", err)
+	}
+
+	var cnt = 0
+	for _, amendment := range result.Amendments {
+		if amendment.Type == nil || amendment.Number == nil || amendment.URL == nil ||
+			!c.config.Scope.HasChamber(amendmentChamber(*amendment.Type)) {
+			continue
+		}
+		amendmentId := c.createAmendmentNode(ctx, amendment.Congress, *amendment.Type, *amendment.Number, map[string]interface{}{
+			"description": amendment.Description,
+			"purpose":     amendment.Purpose,
+			"updateDate":  amendment.UpdateDate,
+			"url":         amendment.URL,
+		})
+
+		//download amended bill, sponsors and actions
+		amendmentCtx := context.WithValue(ctx, amendmentContextKey, amendmentId)
+		c.download(
+			amendmentCtx,
+			downloadmgr.NewHttpGetRequest(c.applyApiToken(*amendment.URL)),
+			c.processAmendmentDetail,
+			c.detailCacheOption(),
+		)
+		c.downloadPages(
+			amendmentCtx,
+			ENDPOINT_AMENDMENT_ACTIONS,
+			strings.ReplaceAll(*amendment.URL, "?format=json", AMENDMENT_ACTIONS_URL_QUERY),
+			c.processAmendmentActions,
+			c.detailCacheOption(),
+		)
+		cnt++
+	}
+	fmt.Printf("updated %d amendments\n", cnt)
+}
+
+// createAmendsEdge links an amendment to the bill or amendment it amends
+func (c *CongressGovProcessor) createAmendsEdge(ctx context.Context, amendmentId string, amended *graphdb.NodeInfo) {
+	amends := &graphdb.EdgeInfo{
+		Label: "AMENDS",
+		Id:    amendmentId,
+		Left: &graphdb.NodeInfo{
+			Id:    amendmentId,
+			Label: "Amendment",
+		},
+		Right:      amended,
+		Attrs:      &map[string]interface{}{},
+		Provenance: c.provenance(ctx),
+	}
+	err := c.graphdbsvc.UpdateEdge(ctx, amends, true)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (c *CongressGovProcessor) processAmendmentDetail(ctx context.Context, data []byte) {
+	fmt.Printf("processing amendment detail %d bytes\n", len(data))
+
+	var result model.CongressApiAmendmentDetailResponse
+
+	// Parse (unmarshal) the JSON into the map
+	err := json.Unmarshal(data, &result)
+	if err != nil {
+		log.Fatalf("Error parsing JSON: %s", err)
+	}
+	amendment := result.Amendment
+	if amendment == nil || amendment.Type == nil || amendment.Number == nil {
+		return
+	}
+
+	amendmentId := c.createAmendmentNode(ctx, amendment.Congress, *amendment.Type, *amendment.Number, map[string]interface{}{
+		"chamber":       amendment.Chamber,
+		"submittedDate": amendment.SubmittedDate,
+	})
+
+	// amendments to amendments name the amended bill too, the amendment they amend comes first
+	if amended := amendment.AmendedAmendment; amended != nil && amended.Type != nil && amended.Number != nil {
+		amendedId := c.createAmendmentNode(ctx, amended.Congress, *amended.Type, *amended.Number, map[string]interface{}{
+			"url": amended.URL,
+		})
+		c.createAmendsEdge(ctx, amendmentId, &graphdb.NodeInfo{Id: amendedId, Label: "Amendment"})
+	} else if amended := amendment.AmendedBill; amended != nil && amended.Type != nil && amended.Number != nil {
+		c.createBillNode(ctx, &model.CongressApiBill{
+			Congress:      amended.Congress,
+			Number:        amended.Number,
+			OriginChamber: amended.OriginChamber,
+			Title:         amended.Title,
+			Type:          amended.Type,
+			URL:           amended.URL,
+		})
+		c.createAmendsEdge(ctx, amendmentId, &graphdb.NodeInfo{
+			Id:    ids.Bill(amended.Congress, *amended.Type, *amended.Number),
+			Label: "Bill",
+		})
+	}
+
+	for _, sponsor := range amendment.Sponsors {
+		if sponsor.BioguideID == nil {
+			continue
+		}
+		personId, ok := c.createSponsorNode(ctx, *sponsor.BioguideID, sponsor.FirstName, sponsor.LastName, sponsor.FullName)
+		if !ok {
+			continue
+		}
+		c.createSponsorshipEdge(ctx, "SPONSORED", ids.Sponsorship, &graphdb.NodeInfo{Id: amendmentId, Label: "Amendment"}, personId, map[string]interface{}{
+			"date": amendment.SubmittedDate,
+		})
+	}
+	fmt.Printf("updated amendment %s\n", amendmentId)
+}
+
+// processAmendmentActions downloads the roll calls on the amendment, they concern the amendment
+// rather than the amended bill
+func (c *CongressGovProcessor) processAmendmentActions(ctx context.Context, data []byte) {
+	fmt.Printf("processing amendment actions %d bytes\n", len(data))
+
+	var result model.CongressApiBillActionsPayload
+
+	// Parse (unmarshal) the JSON into the map
+	err := json.Unmarshal(data, &result)
+	if err != nil {
+		log.Fatalf("Error parsing JSON: %s", err)
+	}
+
+	for _, action := range result.Actions {
+		if action.ActionDate != nil && !c.config.Scope.InWindow(*action.ActionDate) {
+			continue
+		}
+		c.downloadRecordedVotes(ctx, action)
+	}
+}
@@ -0,0 +1,81 @@
+package processor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nedvisol/go-connectdots/graphdb"
+	"github.com/nedvisol/go-connectdots/ids"
+	"github.com/nedvisol/go-connectdots/model"
+)
+
+func TestParseAmendmentRef(t *testing.T) {
+	tests := []struct {
+		ref        string
+		wantType   string
+		wantNumber string
+		wantFound  bool
+	}{
+		{"H AMDT 123", "hamdt", "123", true},
+		{"H.Amdt. 45", "hamdt", "45", true},
+		{" h amdt 7 ", "hamdt", "7", true},
+		{"S.Amdt. 1234", "samdt", "1234", true},
+		{"S AMDT 12", "samdt", "12", true},
+		{"S.Up.Amdt. 3", "suamdt", "3", true},
+		{"SUP AMDT 3", "suamdt", "3", true},
+		{"H R 1234", "", "", false},
+		{"S. 5", "", "", false},
+		{"", "", "", false},
+		{"H AMDT", "", "", false},
+	}
+	for _, test := range tests {
+		amendmentType, number, found := parseAmendmentRef(test.ref)
+		if amendmentType != test.wantType || number != test.wantNumber || found != test.wantFound {
+			t.Errorf("parseAmendmentRef(%q) = %q, %q, %t, want %q, %q, %t",
+				test.ref, amendmentType, number, found, test.wantType, test.wantNumber, test.wantFound)
+		}
+	}
+}
+
+func TestVoteSubject(t *testing.T) {
+	congress, billType, number := 118, "hr", "1234"
+	bill := &model.CongressApiBill{Congress: congress, Type: &billType, Number: &number}
+	recordedVote := &model.CongressApiRecordedVote{Congress: &congress}
+	voteCtx := context.WithValue(context.WithValue(context.Background(), billContextKey, bill), recordedVoteContextKey, recordedVote)
+	amendmentCtx := context.WithValue(voteCtx, amendmentContextKey, ids.Amendment(congress, "samdt", "9"))
+
+	tests := []struct {
+		name         string
+		ctx          context.Context
+		amendmentRef string
+		onAmendment  bool
+		want         *graphdb.NodeInfo
+	}{
+		{"bill", voteCtx, "H R 1234", false, &graphdb.NodeInfo{Label: "Bill", Id: ids.Bill(congress, billType, number)}},
+		{"named amendment", voteCtx, "H AMDT 12", true, &graphdb.NodeInfo{Label: "Amendment", Id: ids.Amendment(congress, "hamdt", "12")}},
+		{"named amendment of a senate vote", voteCtx, "S.Up.Amdt. 3", true, &graphdb.NodeInfo{Label: "Amendment", Id: ids.Amendment(congress, "suamdt", "3")}},
+		{"amendment not named", voteCtx, "H R 1234", true, nil},
+		{"recorded by the amendment actions", amendmentCtx, "", false, &graphdb.NodeInfo{Label: "Amendment", Id: ids.Amendment(congress, "samdt", "9")}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			svc := graphdb.NewMemoryGraphService()
+			c := &CongressGovProcessor{graphdbsvc: svc}
+			got := c.voteSubject(test.ctx, test.amendmentRef, test.onAmendment)
+			switch {
+			case test.want == nil && got != nil:
+				t.Fatalf("got %s %s, want none", got.Label, got.Id)
+			case test.want == nil:
+				return
+			case got == nil || got.Label != test.want.Label || got.Id != test.want.Id:
+				t.Fatalf("got %v, want %s %s", got, test.want.Label, test.want.Id)
+			}
+			// the amendment named by the roll call is created for the vote to concern it
+			if test.amendmentRef != "" && got.Label == "Amendment" {
+				if stored, _ := svc.GetNode(context.Background(), got); stored == nil {
+					t.Errorf("amendment %s not created", got.Id)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,442 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/nedvisol/go-connectdots/config"
+	"github.com/nedvisol/go-connectdots/graphdb"
+	"github.com/nedvisol/go-connectdots/ids"
+	"github.com/nedvisol/go-connectdots/model"
+)
+
+const COMMITTEES_URL = "https://api.congress.gov/v3/committee?format=json&limit=250"
+const CHAMBER_COMMITTEES_URL = "https://api.congress.gov/v3/committee/%s?format=json&limit=250"
+const COMMITTEE_REPORTS_URL = "https://api.congress.gov/v3/committee/%s/%s/reports?format=json&limit=250"
+const BILL_COMMITTEES_URL_QUERY = "/committees?format=json&limit=250"
+
+// committee membership roles, the file gives no title to plain members
+const ROLE_MEMBER = "Member"
+
+const committeeContextKey key = 3
+
+// createCommitteeNode creates or updates the Committee with the given system code and returns its id,
+// missing fields leave the stored ones as is. A Committee requires a name, ok is false when name is
+// missing and the committee is not in the graph yet.
+func (c *CongressGovProcessor) createCommitteeNode(
+	ctx context.Context,
+	systemCode string,
+	name *string,
+	chamber *string,
+	committeeType *string,
+) (committeeId string, ok bool) {
+	attrs := map[string]interface{}{
+		"systemCode": strings.ToLower(systemCode),
+	}
+	if name != nil {
+		attrs["name"] = name
+	}
+	if chamber != nil {
+		attrs["chamber"] = chamber
+	}
+	if committeeType != nil {
+		attrs["committeeType"] = committeeType
+	}
+	committeeNode := &graphdb.NodeInfo{
+		Id:         ids.Committee(systemCode),
+		Label:      "Committee",
+		Attrs:      &attrs,
+		Provenance: c.provenance(ctx),
+	}
+	err := c.graphdbsvc.UpdateNode(ctx, committeeNode, name != nil)
+	if errors.Is(err, graphdb.ErrNodeNotFound) {
+		fmt.Printf("skipped committee %s, no name and not in the graph\n", committeeNode.Id)
+		return committeeNode.Id, false
+	}
+	if err != nil {
+		panic(err)
+	}
+	return committeeNode.Id, true
+}
+
+func (c *CongressGovProcessor) createCommitteeEdge(
+	ctx context.Context,
+	label string,
+	id string,
+	left *graphdb.NodeInfo,
+	right *graphdb.NodeInfo,
+	attrs map[string]interface{},
+) error {
+	edge := &graphdb.EdgeInfo{
+		Label:      label,
+		Id:         id,
+		Left:       left,
+		Right:      right,
+		Attrs:      &attrs,
+		Provenance: c.provenance(ctx),
+	}
+	return c.graphdbsvc.UpdateEdge(ctx, edge, true)
+}
+
+// createSubcommitteeEdge links a subcommittee to its parent committee
+func (c *CongressGovProcessor) createSubcommitteeEdge(ctx context.Context, subcommitteeId string, parentId string) {
+	err := c.createCommitteeEdge(
+		ctx,
+		"SUBCOMMITTEE_OF",
+		subcommitteeId,
+		&graphdb.NodeInfo{Id: subcommitteeId, Label: "Committee"},
+		&graphdb.NodeInfo{Id: parentId, Label: "Committee"},
+		map[string]interface{}{},
+	)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// downloadCommittees downloads the committees of the chambers of the crawl scope, done is called once
+// every committee is in the graph
+func (c *CongressGovProcessor) downloadCommittees(ctx context.Context, done func()) {
+	chambers := c.config.Scope.Chambers
+	if len(chambers) == 0 {
+		c.downloadUpdates(ctx, ENDPOINT_COMMITTEE, COMMITTEES_URL, nil, nil, c.processCommittees, done)
+		return
+	}
+
+	pending := len(chambers)
+	finished := make(chan struct{}, pending)
+	for _, chamber := range chambers {
+		chamberUrl := fmt.Sprintf(CHAMBER_COMMITTEES_URL, config.ChamberOf(chamber))
+		c.downloadUpdates(ctx, ENDPOINT_COMMITTEE, chamberUrl, nil, nil, c.processCommittees, func() {
+			finished <- struct{}{}
+		})
+	}
+	go func() {
+		for ; pending > 0; pending-- {
+			<-finished
+		}
+		done()
+	}()
+}
+
+func (c *CongressGovProcessor) processCommittees(ctx context.Context, data []byte) {
+	fmt.Printf("processing committees %d bytes\n", len(data))
+
+	var result model.CongressApiCommitteesResponse
+
+	// Parse (unmarshal) the JSON into the map
+	err := json.Unmarshal(data, &result)
+	if err != nil {
+		log.Fatalf("Error parsing JSON: %s", err)
+	}
+
+	var cnt = 0
+	for _, committee := range result.Committees {
+		if committee.SystemCode == nil || (committee.Chamber != nil && !c.config.Scope.HasChamber(*committee.Chamber)) {
+			continue
+		}
+		committeeId, ok := c.createCommitteeNode(ctx, *committee.SystemCode, committee.Name, committee.Chamber, committee.CommitteeTypeCode)
+		if !ok {
+			continue
+		}
+
+		if parent := committee.Parent; parent != nil && parent.SystemCode != nil {
+			if parentId, ok := c.createCommitteeNode(ctx, *parent.SystemCode, parent.Name, committee.Chamber, nil); ok {
+				c.createSubcommitteeEdge(ctx, committeeId, parentId)
+			}
+		}
+		for _, subcommittee := range committee.Subcommittees {
+			if subcommittee.SystemCode == nil {
+				continue
+			}
+			if subcommitteeId, ok := c.createCommitteeNode(ctx, *subcommittee.SystemCode, subcommittee.Name, committee.Chamber, nil); ok {
+				c.createSubcommitteeEdge(ctx, subcommitteeId, committeeId)
+			}
+		}
+
+		// reports are issued by full committees
+		if committee.Parent == nil && committee.Chamber != nil {
+			c.downloadPages(
+				context.WithValue(ctx, committeeContextKey, committeeId),
+				ENDPOINT_COMMITTEE_REPORTS,
+				fmt.Sprintf(COMMITTEE_REPORTS_URL, config.ChamberOf(*committee.Chamber), strings.ToLower(*committee.SystemCode)),
+				c.processCommitteeReports,
+			)
+		}
+		cnt++
+	}
+	fmt.Printf("updated %d committees\n", cnt)
+}
+
+func (c *CongressGovProcessor) processCommitteeReports(ctx context.Context, data []byte) {
+	fmt.Printf("processing committee reports %d bytes\n", len(data))
+
+	var result model.CongressApiCommitteeReportsResponse
+
+	// Parse (unmarshal) the JSON into the map
+	err := json.Unmarshal(data, &result)
+	if err != nil {
+		log.Fatalf("Error parsing JSON: %s", err)
+	}
+
+	committeeId := ctx.Value(committeeContextKey).(string)
+
+	var cnt = 0
+	for _, report := range result.Reports {
+		// a CommitteeReport requires its citation
+		if report.URL == nil || report.Citation == nil {
+			continue
+		}
+		reportId, found := ids.CommitteeReportFromUrl(*report.URL)
+		if !found {
+			continue
+		}
+		congress, _, _, err := ids.ParseCommitteeReport(reportId)
+		if err != nil || !c.config.Scope.HasCongress(congress) {
+			continue
+		}
+
+		reportNode := &graphdb.NodeInfo{
+			Id:    reportId,
+			Label: "CommitteeReport",
+			Attrs: &map[string]interface{}{
+				"citation":   report.Citation,
+				"congress":   congress,
+				"updateDate": report.UpdateDate,
+				"url":        report.URL,
+			},
+			Provenance: c.provenance(ctx),
+		}
+		err = c.graphdbsvc.UpdateNode(ctx, reportNode, true)
+		if err != nil {
+			panic(err)
+		}
+		err = c.createCommitteeEdge(
+			ctx,
+			"ISSUED",
+			reportId,
+			&graphdb.NodeInfo{Id: committeeId, Label: "Committee"},
+			&graphdb.NodeInfo{Id: reportId, Label: "CommitteeReport"},
+			map[string]interface{}{},
+		)
+		if err != nil {
+			panic(err)
+		}
+		cnt++
+	}
+	fmt.Printf("updated %d reports of committee %s\n", cnt, committeeId)
+}
+
+// referralDate returns the date of the Referred To activity of a committee, or of its earliest activity
+func referralDate(activities []*model.CongressApiCommitteeActivity) *string {
+	var date *string
+	for _, activity := range activities {
+		if activity.Date == nil {
+			continue
+		}
+		if activity.Name != nil && strings.EqualFold(*activity.Name, "Referred To") {
+			return activity.Date
+		}
+		if date == nil || *activity.Date < *date {
+			date = activity.Date
+		}
+	}
+	return date
+}
+
+// createReferralEdges links the bill to the committee and its subcommittees it was referred to,
+// subcommittees are in the chamber of their committee
+func (c *CongressGovProcessor) createReferralEdges(
+	ctx context.Context,
+	billId string,
+	committee *model.CongressApiBillCommittee,
+	chamber *string,
+) {
+	if committee.SystemCode == nil {
+		return
+	}
+	if committee.Chamber != nil {
+		chamber = committee.Chamber
+	}
+	if committeeId, ok := c.createCommitteeNode(ctx, *committee.SystemCode, committee.Name, chamber, nil); ok {
+		referralId, err := ids.Referral(billId, committeeId)
+		if err != nil {
+			panic(err)
+		}
+		err = c.createCommitteeEdge(
+			ctx,
+			"REFERRED_TO",
+			referralId,
+			&graphdb.NodeInfo{Id: billId, Label: "Bill"},
+			&graphdb.NodeInfo{Id: committeeId, Label: "Committee"},
+			map[string]interface{}{
+				"date": referralDate(committee.Activities),
+			},
+		)
+		if err != nil {
+			panic(err)
+		}
+	}
+	for _, subcommittee := range committee.Subcommittees {
+		c.createReferralEdges(ctx, billId, subcommittee, chamber)
+	}
+}
+
+func (c *CongressGovProcessor) processBillCommittees(ctx context.Context, data []byte) {
+	fmt.Printf("processing bill committees %d bytes\n", len(data))
+
+	var result model.CongressApiBillCommitteesResponse
+
+	// Parse (unmarshal) the JSON into the map
+	err := json.Unmarshal(data, &result)
+	if err != nil {
+		log.Fatalf("Error parsing JSON: %s", err)
+	}
+
+	bill := ctx.Value(billContextKey).(*model.CongressApiBill)
+	billId := ids.Bill(bill.Congress, *bill.Type, *bill.Number)
+
+	for _, committee := range result.Committees {
+		c.createReferralEdges(ctx, billId, committee, nil)
+	}
+	fmt.Printf("updated %d committees of bill %s\n", len(result.Committees), billId)
+}
+
+// committeeSystemCode turns the thomas id of a committee-membership file into a congress.gov system code,
+// full committees like HSAG become hsag00 and subcommittees like HSAG15 hsag15
+func committeeSystemCode(thomasId string) string {
+	systemCode := strings.ToLower(thomasId)
+	if len(systemCode) == 4 {
+		systemCode += "00"
+	}
+	return systemCode
+}
+
+// committeeChamber returns the chamber of a committee by the first letter of its thomas id
+func committeeChamber(thomasId string) string {
+	switch strings.ToUpper(thomasId[:1]) {
+	case "H":
+		return config.CHAMBER_HOUSE
+	case "S":
+		return config.CHAMBER_SENATE
+	}
+	return "joint"
+}
+
+// loadCommitteeMembership creates the SERVES_ON edges of the committee membership file, once the members
+// and committees are in the graph. Seats of people or committees missing from the graph are skipped.
+// In temporal mode the seats the file no longer lists are closed, see closeVacatedSeats.
+func (c *CongressGovProcessor) loadCommitteeMembership(ctx context.Context) {
+	path := c.config.CongressGov.CommitteeMembershipPath
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		fmt.Printf("no committee membership file at %s\n", path)
+		return
+	}
+	if err != nil {
+		panic(err)
+	}
+	defer file.Close()
+
+	var membership model.CommitteeMembership
+	err = json.NewDecoder(file).Decode(&membership)
+	if err != nil {
+		log.Fatalf("Error parsing JSON: %s", err)
+	}
+
+	var cnt, skipped = 0, 0
+	committees := make(map[string]bool)
+	seats := make(map[string]bool)
+	for thomasId, members := range membership {
+		if thomasId == "" || !c.config.Scope.HasChamber(committeeChamber(thomasId)) {
+			continue
+		}
+		committeeId := ids.Committee(committeeSystemCode(thomasId))
+		committees[committeeId] = true
+		for _, member := range members {
+			if member.Bioguide == "" {
+				continue
+			}
+			personId := ids.Member(member.Bioguide)
+			membershipId, err := ids.CommitteeMembership(committeeId, personId)
+			if err != nil {
+				panic(err)
+			}
+			role := member.Title
+			if role == "" {
+				role = ROLE_MEMBER
+			}
+			attrs := map[string]interface{}{
+				"role": role,
+				"rank": member.Rank,
+				"side": member.Party,
+			}
+			if member.StartDate != "" {
+				attrs["start"] = member.StartDate
+			}
+			if member.EndDate != "" {
+				attrs["end"] = member.EndDate
+			}
+			err = c.createCommitteeEdge(
+				ctx,
+				"SERVES_ON",
+				membershipId,
+				&graphdb.NodeInfo{Id: personId, Label: "Person"},
+				&graphdb.NodeInfo{Id: committeeId, Label: "Committee"},
+				attrs,
+			)
+			// every backend reports a person or committee missing from the graph, see GraphDbService
+			if errors.Is(err, graphdb.ErrNodeNotFound) {
+				skipped++
+				continue
+			}
+			if err != nil {
+				panic(err)
+			}
+			seats[membershipId] = true
+			cnt++
+		}
+	}
+	fmt.Printf("updated %d committee seats, skipped %d of people or committees not in the graph\n", cnt, skipped)
+	if c.config.GraphDb != nil && c.config.GraphDb.Temporal {
+		c.closeVacatedSeats(ctx, committees, seats)
+	}
+}
+
+// closeVacatedSeats closes the open SERVES_ON edges of the committees listed by the membership file which
+// are not among its seats, the file only gives the current membership. Committees missing from the file
+// keep their seats.
+func (c *CongressGovProcessor) closeVacatedSeats(ctx context.Context, committees map[string]bool, seats map[string]bool) {
+	edges, err := c.graphdbsvc.FindEdges(ctx, "SERVES_ON")
+	if err != nil {
+		panic(err)
+	}
+	now := time.Now()
+	var cnt = 0
+	for _, edge := range edges {
+		if !committees[edge.Right.Id] || seats[edge.Id] {
+			continue
+		}
+		if edge.Attrs != nil && (*edge.Attrs)[graphdb.PROP_VALID_TO] != nil {
+			continue
+		}
+		seat := &graphdb.EdgeInfo{
+			Label:      edge.Label,
+			Id:         edge.Id,
+			Left:       edge.Left,
+			Right:      edge.Right,
+			Provenance: c.provenance(ctx),
+		}
+		err := graphdb.CloseEdge(ctx, c.graphdbsvc, seat, now)
+		if err != nil {
+			panic(err)
+		}
+		cnt++
+	}
+	fmt.Printf("closed %d committee seats no longer in the membership\n", cnt)
+}
@@ -0,0 +1,172 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nedvisol/go-connectdots/config"
+	"github.com/nedvisol/go-connectdots/graphdb"
+	"github.com/nedvisol/go-connectdots/ids"
+)
+
+func TestLoadCommitteeMembershipClosesVacatedSeats(t *testing.T) {
+	tests := []struct {
+		temporal bool
+		want     []string
+	}{
+		{true, []string{"W000779:hsag00", "M001176:ssfi00"}},
+		{false, []string{"W000779:hsag00", "S000033:hsag00", "M001176:ssfi00"}},
+	}
+	for _, test := range tests {
+		t.Run(map[bool]string{true: "temporal", false: "current"}[test.temporal], func(t *testing.T) {
+			ctx := context.Background()
+			svc := graphdb.NewTemporalGraphService(graphdb.NewMemoryGraphService(), nil)
+			for _, bioguideId := range []string{"W000779", "S000033", "M001176"} {
+				svc.UpdateNode(ctx, &graphdb.NodeInfo{Label: "Person", Id: ids.Member(bioguideId), Attrs: &map[string]interface{}{}}, true)
+			}
+			for _, systemCode := range []string{"hsag00", "ssfi00"} {
+				svc.UpdateNode(ctx, &graphdb.NodeInfo{Label: "Committee", Id: ids.Committee(systemCode), Attrs: &map[string]interface{}{}}, true)
+			}
+			path := filepath.Join(t.TempDir(), "committee-membership-current.json")
+			c := &CongressGovProcessor{
+				graphdbsvc: svc,
+				config: &config.Config{
+					CongressGov: &config.CongressGovConfig{CommitteeMembershipPath: path},
+					Scope:       &config.CrawlScopeConfig{},
+					GraphDb:     &config.GraphDbConfig{Temporal: test.temporal},
+				},
+			}
+
+			// Sanders leaves the agriculture committee, the finance committee is missing from the second file
+			memberships := []string{
+				`{"HSAG": [{"bioguide": "W000779", "rank": 1}, {"bioguide": "S000033", "rank": 2}], "SSFI": [{"bioguide": "M001176", "rank": 1}]}`,
+				`{"HSAG": [{"bioguide": "W000779", "rank": 1}]}`,
+			}
+			for _, membership := range memberships {
+				if err := os.WriteFile(path, []byte(membership), 0644); err != nil {
+					t.Fatal(err)
+				}
+				c.loadCommitteeMembership(ctx)
+			}
+
+			seats, err := graphdb.FindEdgesAsOf(ctx, svc, "SERVES_ON", time.Now().Add(time.Minute))
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := make(map[string]bool)
+			for _, seat := range test.want {
+				bioguideId, systemCode, _ := strings.Cut(seat, ":")
+				id, _ := ids.CommitteeMembership(ids.Committee(systemCode), ids.Member(bioguideId))
+				want[id] = true
+			}
+			if len(seats) != len(want) {
+				t.Errorf("%d seats held, want %v", len(seats), test.want)
+			}
+			for _, seat := range seats {
+				if !want[seat.Id] {
+					t.Errorf("seat %s still held", seat.Id)
+				}
+			}
+		})
+	}
+}
+
+// congressGovTransport sends the requests to api.congress.gov to a test server
+type congressGovTransport struct {
+	server *httptest.Server
+}
+
+func (t congressGovTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if request.URL.Host == "api.congress.gov" {
+		request = request.Clone(request.Context())
+		request.URL.Scheme, request.URL.Host = "http", strings.TrimPrefix(t.server.URL, "http://")
+	}
+	return t.server.Client().Transport.RoundTrip(request)
+}
+
+// TestProcessCommitteesSchema crawls committees and reports missing their name or citation, the graph
+// must still hold to the DefaultSchema
+func TestProcessCommitteesSchema(t *testing.T) {
+	reports, err := os.ReadFile("testdata/committee_reports.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v3/committee/house/hsag00/reports" {
+			w.Write(reports)
+			return
+		}
+		fmt.Fprint(w, `{"reports": []}`)
+	}))
+	t.Cleanup(server.Close)
+	defaultTransport := http.DefaultTransport
+	http.DefaultTransport = congressGovTransport{server: server}
+	t.Cleanup(func() { http.DefaultTransport = defaultTransport })
+
+	svc := graphdb.NewMemoryGraphService()
+	c := &CongressGovProcessor{
+		graphdbsvc: svc,
+		dmgr:       testDownloadManager(t),
+		config: &config.Config{
+			CongressGov: &config.CongressGovConfig{},
+			Scope:       &config.CrawlScopeConfig{},
+		},
+	}
+	data, err := os.ReadFile("testdata/committees.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pending := &sync.WaitGroup{}
+	c.processCommittees(context.WithValue(context.Background(), pendingContextKey, pending), data)
+	crawled := make(chan struct{})
+	go func() {
+		pending.Wait()
+		close(crawled)
+	}()
+	select {
+	case <-crawled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("committee reports not crawled")
+	}
+
+	ctx := context.Background()
+	if err := svc.ApplySchema(ctx, graphdb.DefaultSchema); err != nil {
+		t.Fatalf("committees break the schema: %v", err)
+	}
+	for _, test := range []struct {
+		label string
+		id    string
+		want  bool
+	}{
+		{"Committee", ids.Committee("hsag00"), true},
+		{"Committee", ids.Committee("hsag14"), true},
+		{"Committee", ids.Committee("hsag16"), true},
+		{"Committee", ids.Committee("hsag03"), false},
+		{"Committee", ids.Committee("ssfi00"), false},
+		{"CommitteeReport", ids.CommitteeReport(118, "HRPT", "12"), true},
+		{"CommitteeReport", ids.CommitteeReport(118, "HRPT", "13"), false},
+	} {
+		node, err := svc.GetNode(ctx, &graphdb.NodeInfo{Label: test.label, Id: test.id})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (node != nil) != test.want {
+			t.Errorf("%s %s created is %v, want %v", test.label, test.id, node != nil, test.want)
+		}
+	}
+	edges, err := svc.FindEdges(ctx, "SUBCOMMITTEE_OF")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(edges) != 2 {
+		t.Errorf("%d subcommittees linked, want hsag14 and hsag16", len(edges))
+	}
+}
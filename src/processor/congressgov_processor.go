@@ -4,58 +4,88 @@ import (
 	"context"
 	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"log"
+	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/nedvisol/go-connectdots/config"
 	"github.com/nedvisol/go-connectdots/downloadmgr"
+	"github.com/nedvisol/go-connectdots/entityres"
 	"github.com/nedvisol/go-connectdots/graphdb"
+	"github.com/nedvisol/go-connectdots/ids"
 	"github.com/nedvisol/go-connectdots/model"
-	"github.com/nedvisol/go-connectdots/util"
+	"github.com/nedvisol/go-connectdots/syncstate"
 )
 
 type key int
 
 const billContextKey key = 0
 const billActionContextKey key = 1
+const recordedVoteContextKey key = 2
 
 type CongressGovProcessor struct {
 	ctx        context.Context
 	dmgr       *downloadmgr.DownloadManager
 	apiToken   string
+	config     *config.Config
 	graphdbsvc graphdb.GraphDbService
+	crosswalk  *entityres.Crosswalk
+	resolver   *entityres.Resolver
+	syncStates syncstate.SyncStateRepository
 }
 
+const PROCESSOR_NAME = "CongressGovProcessor"
+const PROCESSOR_VERSION = "1"
+
 const TEN_YEARS = time.Hour * 24 * 3650
 const MEMBERS_URL = "https://api.congress.gov/v3/member?format=json&currentMember=true&limit=250"
+const ALL_MEMBERS_URL = "https://api.congress.gov/v3/member?format=json&limit=250"
 const CONGRESS_URL = "https://api.congress.gov/v3/congress?format=json"
-const BILLS_URL = "https://api.congress.gov//v3/bill/%s?format=json&limit=250"
-
-func getPersonIdByBioguideId(bioguideId string) string {
-	return util.GetSHA512(fmt.Sprintf("%s-congress", bioguideId))
-}
+const LATEST_CONGRESSES_URL = "https://api.congress.gov/v3/congress?format=json&limit=%d"
+const BILLS_URL = "https://api.congress.gov//v3/bill/%d?format=json&limit=250"
+const BILLS_OF_TYPE_URL = "https://api.congress.gov/v3/bill/%d/%s?format=json&limit=250"
+const BILL_ACTIONS_URL_QUERY = "/actions?format=json&limit=250"
 
-func getBillIdByBillNumber(congress int, originChamberCode string, billNumber string) string {
-	return util.GetSHA512(fmt.Sprintf("%d/bill/%s/%s", congress, originChamberCode, billNumber))
+func (c *CongressGovProcessor) applyApiToken(url string) string {
+	return fmt.Sprintf("%s&api_key=%s", url, c.apiToken)
 }
 
-func getVotedEdgeIdByBillAction(bill *model.CongressApiBill, billAction *model.CongressApiBillAction) string {
-	return util.GetSHA512(fmt.Sprintf("%d/bill/%s/%s/action/%s", bill.Congress, *bill.OriginChamberCode, *bill.Number, *billAction.ActionDate))
+// sourceSystemOf names the system a url belongs to by its host, e.g. congress.gov or clerk.house.gov
+func sourceSystemOf(sourceUrl string) string {
+	parsed, err := url.Parse(sourceUrl)
+	if err != nil {
+		return ""
+	}
+	host := strings.TrimPrefix(parsed.Hostname(), "www.")
+	return strings.TrimPrefix(host, "api.")
 }
 
-func (c *CongressGovProcessor) applyApiToken(url string) string {
-	return fmt.Sprintf("%s&api_key=%s", url, c.apiToken)
+// provenance describes the download being processed in ctx
+func (c *CongressGovProcessor) provenance(ctx context.Context) *graphdb.Provenance {
+	provenance := &graphdb.Provenance{
+		Processor:        PROCESSOR_NAME,
+		ProcessorVersion: PROCESSOR_VERSION,
+	}
+	if info := downloadmgr.DownloadInfoFromContext(ctx); info != nil {
+		provenance.SourceSystem = sourceSystemOf(info.Url)
+		provenance.SourceUrl = info.Url
+		provenance.FetchedAt = info.FetchedAt
+	}
+	return provenance
 }
 
-func (c *CongressGovProcessor) createMemberNodeInfo(member *model.CongressApiMember) *graphdb.NodeInfo {
+func (c *CongressGovProcessor) createMemberNodeInfo(ctx context.Context, member *model.CongressApiMember) *graphdb.NodeInfo {
 	names := strings.Split(member.Name, ", ")
 	first, last := names[0], names[1]
 
 	return &graphdb.NodeInfo{
-		Id:    getPersonIdByBioguideId(member.BioguideID),
+		Id:    ids.Member(member.BioguideID),
 		Label: "Person",
 		Attrs: &map[string]interface{}{
 			"first":     first,
@@ -66,25 +96,28 @@ func (c *CongressGovProcessor) createMemberNodeInfo(member *model.CongressApiMem
 			"chamber":   member.Terms.Item[0].Chamber,
 			"sourceUrl": member.URL,
 		},
+		Provenance: c.provenance(ctx),
 	}
 }
 
-func (c *CongressGovProcessor) createMember(member *model.CongressApiMember) {
+func (c *CongressGovProcessor) createMember(ctx context.Context, member *model.CongressApiMember) {
 	var err error
-	personNode := c.createMemberNodeInfo(member)
+	personNode := c.createMemberNodeInfo(ctx, member)
 
-	err = c.graphdbsvc.UpdateNode(personNode, true)
+	err = c.graphdbsvc.UpdateNode(ctx, personNode, true)
 
 	if err != nil {
 		panic(err)
 	}
+	// members are the canonical people other sources are resolved against
+	c.crosswalk.Add(personNode.Label, personNode.Id, entityres.SYSTEM_BIOGUIDE, member.BioguideID)
 	fmt.Printf("member added/updated %s\n", member.Name)
 }
 
-func (c *CongressGovProcessor) createBillNode(bill *model.CongressApiBill) {
+func (c *CongressGovProcessor) createBillNode(ctx context.Context, bill *model.CongressApiBill) {
 	var err error
 	billNode := &graphdb.NodeInfo{
-		Id:    getBillIdByBillNumber(bill.Congress, *bill.OriginChamberCode, *bill.Number),
+		Id:    ids.Bill(bill.Congress, *bill.Type, *bill.Number),
 		Label: "Bill",
 		Attrs: &map[string]interface{}{
 			"title":         bill.Title,
@@ -93,9 +126,10 @@ func (c *CongressGovProcessor) createBillNode(bill *model.CongressApiBill) {
 			"congress":      bill.Congress,
 			"url":           bill.URL,
 		},
+		Provenance: c.provenance(ctx),
 	}
 
-	err = c.graphdbsvc.UpdateNode(billNode, true)
+	err = c.graphdbsvc.UpdateNode(ctx, billNode, true)
 
 	if err != nil {
 		panic(err)
@@ -114,47 +148,157 @@ func (c *CongressGovProcessor) processCurrentMembers(ctx context.Context, data [
 		log.Fatalf("Error parsing JSON: %s", err)
 	}
 
-	if result.Pagination != nil && result.Pagination.Next != nil {
-		fmt.Printf("found more things to download! %s\n", *result.Pagination.Next)
-		c.dmgr.Download(
-			ctx,
-			downloadmgr.NewHttpGetRequest(c.applyApiToken(*result.Pagination.Next)),
-			c.processCurrentMembers,
-			downloadmgr.NewDownloadCacheOption(TEN_YEARS),
-		)
-	}
-
 	var cnt = 0
 	for _, member := range result.Members {
-		c.createMember(member)
+		if !c.memberInScope(member) {
+			continue
+		}
+		c.createMember(ctx, member)
+
+		//download terms, party history and leadership
+		c.download(
+			context.WithValue(ctx, memberContextKey, member),
+			downloadmgr.NewHttpGetRequest(c.applyApiToken(member.URL)),
+			c.processMemberDetail,
+			c.detailCacheOption(),
+		)
 		cnt++
 	}
 	fmt.Printf("updated %d members\n", cnt)
 }
 
-func (c *CongressGovProcessor) createVotedForEdge(
-	bill *model.CongressApiBill,
-	billAction *model.CongressApiBillAction,
-	bioguideId string,
-	vote string,
+// voteTally counts the positions taken on a roll call
+type voteTally struct {
+	yea       int
+	nay       int
+	present   int
+	notVoting int
+}
+
+func (t *voteTally) add(position string) {
+	switch position {
+	case "Yea", "Aye", "Guilty":
+		t.yea++
+	case "Nay", "No", "Not Guilty":
+		t.nay++
+	case "Present":
+		t.present++
+	default:
+		t.notVoting++
+	}
+}
+
+// setAttrs stores the tally as yea, nay, present and notVoting, prefixed e.g. democraticYea when prefix is given
+func (t *voteTally) setAttrs(attrs map[string]interface{}, prefix string) {
+	key := func(name string) string {
+		if prefix == "" {
+			return strings.ToLower(name[:1]) + name[1:]
+		}
+		return prefix + name
+	}
+	attrs[key("Yea")] = t.yea
+	attrs[key("Nay")] = t.nay
+	attrs[key("Present")] = t.present
+	attrs[key("NotVoting")] = t.notVoting
+}
+
+var nonLetterRegex = regexp.MustCompile(`[^a-zA-Z]`)
+
+// partyPrefix turns a party name into a property prefix, e.g. Democratic gives democratic
+func partyPrefix(party string) string {
+	return strings.ToLower(nonLetterRegex.ReplaceAllString(party, ""))
+}
+
+// senate.gov gives the party of a senator as a code
+var senatePartyNames = map[string]string{
+	"D":  "Democratic",
+	"R":  "Republican",
+	"I":  "Independent",
+	"ID": "Independent",
+}
+
+func atoi(value *string) int {
+	if value == nil {
+		return 0
+	}
+	number, _ := strconv.Atoi(strings.TrimSpace(*value))
+	return number
+}
+
+// createVote creates the Vote node of the roll call congress.gov recorded in ctx, linked to the bill or
+// amendment it concerns, and returns its id
+func (c *CongressGovProcessor) createVote(ctx context.Context, subject *graphdb.NodeInfo, attrs map[string]interface{}) string {
+	recordedVote := ctx.Value(recordedVoteContextKey).(*model.CongressApiRecordedVote)
+	voteId := ids.Vote(*recordedVote.Congress, *recordedVote.Chamber, *recordedVote.SessionNumber, *recordedVote.RollNumber)
+
+	attrs["chamber"] = recordedVote.Chamber
+	attrs["congress"] = recordedVote.Congress
+	attrs["session"] = recordedVote.SessionNumber
+	attrs["rollNumber"] = recordedVote.RollNumber
+	attrs["date"] = recordedVote.Date.Format(time.RFC3339)
+	attrs["url"] = recordedVote.URL
+
+	voteNode := &graphdb.NodeInfo{
+		Id:         voteId,
+		Label:      "Vote",
+		Attrs:      &attrs,
+		Provenance: c.provenance(ctx),
+	}
+	err := c.graphdbsvc.UpdateNode(ctx, voteNode, true)
+	if err != nil {
+		panic(err)
+	}
+
+	concerns := &graphdb.EdgeInfo{
+		Label: "CONCERNS",
+		Id:    voteId,
+		Left: &graphdb.NodeInfo{
+			Id:    voteId,
+			Label: "Vote",
+		},
+		Right:      subject,
+		Attrs:      &map[string]interface{}{},
+		Provenance: c.provenance(ctx),
+	}
+	err = c.graphdbsvc.UpdateEdge(ctx, concerns, true)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("vote added/updated %s\n", voteId)
+	return voteId
+}
+
+func (c *CongressGovProcessor) createCastVoteEdge(
+	ctx context.Context,
+	voteId string,
+	personId string,
+	position string,
 ) {
-	votedFor := &graphdb.EdgeInfo{
-		Label: "VOTED",
-		Id:    getVotedEdgeIdByBillAction(bill, billAction),
+	castVoteId, err := ids.CastVote(voteId, personId)
+	if err != nil {
+		panic(err)
+	}
+	castVote := &graphdb.EdgeInfo{
+		Label: "CAST_VOTE",
+		Id:    castVoteId,
 		Left: &graphdb.NodeInfo{
-			Id:    getPersonIdByBioguideId(bioguideId),
+			Id:    personId,
 			Label: "Person",
 		},
 		Right: &graphdb.NodeInfo{
-			Id:    getBillIdByBillNumber(bill.Congress, *bill.OriginChamberCode, *bill.Number),
-			Label: "Bill",
+			Id:    voteId,
+			Label: "Vote",
 		},
 		Attrs: &map[string]interface{}{
-			"vote": vote,
-			"date": billAction.ActionDate,
+			"position": position,
 		},
+		Provenance: c.provenance(ctx),
+	}
+	err = c.graphdbsvc.UpdateEdge(ctx, castVote, true)
+	if errors.Is(err, graphdb.ErrNodeNotFound) {
+		fmt.Printf("skipped vote of %s on %s, not in the graph\n", personId, voteId)
+		return
 	}
-	err := c.graphdbsvc.UpdateEdge(votedFor, true)
 	if err != nil {
 		panic(err)
 	}
@@ -171,21 +315,179 @@ func (c *CongressGovProcessor) processHouseRollCallVote(ctx context.Context, dat
 		log.Fatalf("Error parsing JSON: %s", err)
 	}
 
-	//get bill object from context
-	bill := ctx.Value(billContextKey)
-	billAction := ctx.Value(billActionContextKey)
+	attrs := make(map[string]interface{})
+	amendmentRef, onAmendment := "", false
+	if metadata := result.VoteMetadata; metadata != nil {
+		if metadata.LegisNum != nil {
+			amendmentRef = *metadata.LegisNum
+		}
+		onAmendment = metadata.VoteQuestion != nil && houseAmendmentQuestionRegex.MatchString(*metadata.VoteQuestion)
+		attrs["amendmentNum"] = metadata.AmendmentNum
+		attrs["amendmentAuthor"] = metadata.AmendmentAuthor
+		attrs["question"] = metadata.VoteQuestion
+		attrs["result"] = metadata.VoteResult
+		attrs["voteType"] = metadata.VoteType
+		attrs["description"] = metadata.VoteDesc
+		attrs["legisNum"] = metadata.LegisNum
+		if totals := metadata.VoteTotals; totals != nil {
+			for _, party := range totals.TotalsByParty {
+				if party.Party == nil {
+					continue
+				}
+				tally := &voteTally{atoi(party.YeaTotal), atoi(party.NayTotal), atoi(party.PresentTotal), atoi(party.NotVotingTotal)}
+				tally.setAttrs(attrs, partyPrefix(*party.Party))
+			}
+			if byVote := totals.TotalsByVote; byVote != nil {
+				tally := &voteTally{atoi(byVote.YeaTotal), atoi(byVote.NayTotal), atoi(byVote.PresentTotal), atoi(byVote.NotVotingTotal)}
+				tally.setAttrs(attrs, "")
+			}
+		}
+	}
+	subject := c.voteSubject(ctx, amendmentRef, onAmendment)
+	if subject == nil {
+		fmt.Printf("skipping house vote on an amendment to %s, recorded with the amendment\n", amendmentRef)
+		return
+	}
+	voteId := c.createVote(ctx, subject, attrs)
 
 	for _, recordedVote := range result.VoteData.RecordedVotes {
 		bioguideId := recordedVote.Legislator.NameID
 		vote := recordedVote.Vote
 
-		c.createVotedForEdge(bill.(*model.CongressApiBill), billAction.(*model.CongressApiBillAction), *bioguideId, *vote)
+		c.createCastVoteEdge(ctx, voteId, ids.Member(*bioguideId), *vote)
 	}
 
 }
 
+// senatorCandidate describes a senator of a senate.gov vote for entity resolution
+func senatorCandidate(member *model.CongressApiSenateVoteMember) *entityres.Candidate {
+	value := func(field *string) string {
+		if field == nil {
+			return ""
+		}
+		return *field
+	}
+	return &entityres.Candidate{
+		Label:      "Person",
+		System:     entityres.SYSTEM_LIS,
+		ExternalId: value(member.LisMemberId),
+		Name:       fmt.Sprintf("%s %s", value(member.FirstName), value(member.LastName)),
+		State:      value(member.State),
+		Party:      value(member.Party),
+	}
+}
+
 func (c *CongressGovProcessor) processSenateRollCallVote(ctx context.Context, data []byte) {
 	fmt.Printf("processing senate rollcall vote %d bytes\n", len(data))
+
+	var result model.CongressApiSenateRollCallVote
+
+	err := xml.Unmarshal(data, &result)
+	if err != nil {
+		log.Fatalf("Error parsing XML: %s", err)
+	}
+
+	// senate.gov gives no totals per party, they are counted from the members
+	attrs := map[string]interface{}{
+		"question":    result.VoteQuestionText,
+		"result":      result.VoteResultText,
+		"voteType":    result.MajorityRequirement,
+		"description": result.VoteTitle,
+	}
+	if result.Document != nil {
+		attrs["legisNum"] = result.Document.DocumentName
+	}
+	total := &voteTally{}
+	parties := make(map[string]*voteTally)
+	for _, member := range result.Members {
+		if member.VoteCast == nil {
+			continue
+		}
+		total.add(*member.VoteCast)
+		if member.Party != nil {
+			party, found := senatePartyNames[*member.Party]
+			if !found {
+				party = *member.Party
+			}
+			if parties[party] == nil {
+				parties[party] = &voteTally{}
+			}
+			parties[party].add(*member.VoteCast)
+		}
+	}
+	total.setAttrs(attrs, "")
+	for party, tally := range parties {
+		tally.setAttrs(attrs, partyPrefix(party))
+	}
+	amendmentRef := ""
+	if result.Amendment != nil && result.Amendment.AmendmentNumber != nil {
+		amendmentRef = strings.TrimSpace(*result.Amendment.AmendmentNumber)
+	}
+	subject := c.voteSubject(ctx, amendmentRef, amendmentRef != "")
+	if subject == nil {
+		fmt.Printf("skipping senate vote on an amendment of unknown number %s, recorded with the amendment\n", amendmentRef)
+		return
+	}
+	voteId := c.createVote(ctx, subject, attrs)
+
+	// senate.gov identifies senators by LIS member id, resolved to Person nodes through the crosswalk
+	candidates := make([]*entityres.Candidate, 0, len(result.Members))
+	for _, member := range result.Members {
+		candidates = append(candidates, senatorCandidate(member))
+	}
+	matches, err := c.resolver.Resolve(ctx, candidates...)
+	if err != nil {
+		panic(err)
+	}
+	if err := c.resolver.Apply(ctx, matches); err != nil {
+		panic(err)
+	}
+
+	for i, member := range result.Members {
+		match := matches[i]
+		switch {
+		case member.VoteCast == nil:
+			fmt.Printf("skipping senator %s %s, no vote cast\n", match.Candidate.ExternalId, match.Candidate.Name)
+		case match.Decision == entityres.DECISION_REVIEW:
+			fmt.Printf("skipping vote of senator %s %s, match to %s queued for review (confidence %.2f)\n",
+				match.Candidate.ExternalId, match.Candidate.Name, match.Canonical.Id, match.Confidence)
+		case match.Decision != entityres.DECISION_LINKED:
+			fmt.Printf("skipping vote of senator %s %s, no Person found\n", match.Candidate.ExternalId, match.Candidate.Name)
+		default:
+			c.createCastVoteEdge(ctx, voteId, match.Canonical.Id, *member.VoteCast)
+		}
+	}
+}
+
+// downloadRecordedVotes downloads the roll calls recorded for a floor action, clerk.house.gov and
+// senate.gov publish them in their own formats
+func (c *CongressGovProcessor) downloadRecordedVotes(ctx context.Context, action *model.CongressApiBillAction) {
+	if action.RecordedVotes == nil || action.Type == nil || *action.Type != "Floor" {
+		return
+	}
+	billActionCtx := context.WithValue(ctx, billActionContextKey, action)
+	for _, recordedVote := range action.RecordedVotes {
+		if recordedVote.URL != nil && recordedVote.Congress != nil && recordedVote.Chamber != nil &&
+			recordedVote.SessionNumber != nil && recordedVote.RollNumber != nil &&
+			c.config.Scope.HasChamber(*recordedVote.Chamber) {
+			recordedVoteCtx := context.WithValue(billActionCtx, recordedVoteContextKey, recordedVote)
+			if strings.Contains(*recordedVote.URL, "//clerk.house.gov") {
+				c.download(
+					recordedVoteCtx,
+					downloadmgr.NewHttpGetRequest(*recordedVote.URL),
+					c.processHouseRollCallVote,
+					downloadmgr.NewDownloadCacheOption(TEN_YEARS),
+				)
+			} else if strings.Contains(*recordedVote.URL, "//www.senate.gov") {
+				c.download(
+					recordedVoteCtx,
+					downloadmgr.NewHttpGetRequest(*recordedVote.URL),
+					c.processSenateRollCallVote,
+					downloadmgr.NewDownloadCacheOption(TEN_YEARS),
+				)
+			}
+		}
+	}
 }
 
 func (c *CongressGovProcessor) processBillActions(ctx context.Context, data []byte) {
@@ -201,28 +503,10 @@ func (c *CongressGovProcessor) processBillActions(ctx context.Context, data []by
 
 	var cnt = 0
 	for _, action := range result.Actions {
-		if action.RecordedVotes != nil && *action.Type == "Floor" {
-			billActionCtx := context.WithValue(ctx, billActionContextKey, action)
-			for _, recordedVote := range action.RecordedVotes {
-				if recordedVote.URL != nil {
-					if strings.Contains(*recordedVote.URL, "//clerk.house.gov") {
-						c.dmgr.Download(
-							billActionCtx,
-							downloadmgr.NewHttpGetRequest(*recordedVote.URL),
-							c.processHouseRollCallVote,
-							downloadmgr.NewDownloadCacheOption(TEN_YEARS),
-						)
-					} else if strings.Contains(*recordedVote.URL, "//www.senate.gov") {
-						c.dmgr.Download(
-							billActionCtx,
-							downloadmgr.NewHttpGetRequest(*recordedVote.URL),
-							c.processSenateRollCallVote,
-							downloadmgr.NewDownloadCacheOption(TEN_YEARS),
-						)
-					}
-				}
-			}
+		if action.ActionDate != nil && !c.config.Scope.InWindow(*action.ActionDate) {
+			continue
 		}
+		c.downloadRecordedVotes(ctx, action)
 		cnt++
 	}
 	fmt.Printf("updated %d bills\n", cnt)
@@ -241,25 +525,111 @@ func (c *CongressGovProcessor) processBills(ctx context.Context, data []byte) {
 
 	var cnt = 0
 	for _, bill := range result.Bills {
-		c.createBillNode(bill)
+		if !c.billInScope(bill) {
+			continue
+		}
+		c.createBillNode(ctx, bill)
 
 		//download and process bills
-		billActionsUrl := strings.ReplaceAll(*bill.URL, "?format=json", "/actions?format=json")
+		billActionsUrl := strings.ReplaceAll(*bill.URL, "?format=json", BILL_ACTIONS_URL_QUERY)
 
 		//create new context with value
 		billCtx := context.WithValue(ctx, billContextKey, bill)
 
-		c.dmgr.Download(
+		c.downloadPages(
 			billCtx,
-			downloadmgr.NewHttpGetRequest(c.applyApiToken(billActionsUrl)),
+			ENDPOINT_BILL_ACTIONS,
+			billActionsUrl,
 			c.processBillActions,
-			downloadmgr.NewDownloadCacheOption(TEN_YEARS),
+			c.detailCacheOption(),
+		)
+
+		//download sponsors and cosponsors
+		c.download(
+			billCtx,
+			downloadmgr.NewHttpGetRequest(c.applyApiToken(*bill.URL)),
+			c.processBillDetail,
+			c.detailCacheOption(),
+		)
+		c.downloadPages(
+			billCtx,
+			ENDPOINT_BILL_COMMITTEES,
+			strings.ReplaceAll(*bill.URL, "?format=json", BILL_COMMITTEES_URL_QUERY),
+			c.processBillCommittees,
+			c.detailCacheOption(),
+		)
+		c.downloadPages(
+			billCtx,
+			ENDPOINT_BILL_SUBJECTS,
+			strings.ReplaceAll(*bill.URL, "?format=json", BILL_SUBJECTS_URL_QUERY),
+			c.processBillSubjects,
+			c.detailCacheOption(),
+		)
+		c.download(
+			billCtx,
+			downloadmgr.NewHttpGetRequest(c.applyApiToken(strings.ReplaceAll(*bill.URL, "?format=json", BILL_SUMMARIES_URL_QUERY))),
+			c.processBillSummaries,
+			c.detailCacheOption(),
+		)
+		c.downloadPages(
+			billCtx,
+			ENDPOINT_COSPONSORS,
+			strings.ReplaceAll(*bill.URL, "?format=json", BILL_COSPONSORS_URL_QUERY),
+			c.processCosponsors,
+			c.detailCacheOption(),
 		)
 		cnt++
 	}
 	fmt.Printf("updated %d bills\n", cnt)
 }
 
+// memberInScope tells whether member served in a chamber of the crawl scope
+func (c *CongressGovProcessor) memberInScope(member *model.CongressApiMember) bool {
+	for _, term := range member.Terms.Item {
+		if c.config.Scope.HasChamber(term.Chamber) {
+			return true
+		}
+	}
+	return false
+}
+
+// billInScope tells whether bill has a type and origin chamber of the crawl scope
+func (c *CongressGovProcessor) billInScope(bill *model.CongressApiBill) bool {
+	scope := c.config.Scope
+	return (bill.Type == nil || scope.HasBillType(*bill.Type)) &&
+		(bill.OriginChamber == nil || scope.HasChamber(*bill.OriginChamber))
+}
+
+// downloadCongress downloads the bills and amendments of congress
+func (c *CongressGovProcessor) downloadCongress(ctx context.Context, congress int) {
+	c.downloadBills(ctx, congress)
+	c.downloadAmendments(ctx, congress)
+}
+
+// downloadBills downloads the bills of congress, of the bill types of the crawl scope if any
+func (c *CongressGovProcessor) downloadBills(ctx context.Context, congress int) {
+	billsUrls := []string{fmt.Sprintf(BILLS_URL, congress)}
+	if billTypes := c.config.Scope.BillTypes; len(billTypes) > 0 {
+		billsUrls = make([]string, 0, len(billTypes))
+		for _, billType := range billTypes {
+			billsUrls = append(billsUrls, fmt.Sprintf(BILLS_OF_TYPE_URL, congress, strings.ToLower(billType)))
+		}
+	}
+
+	for _, billsUrl := range billsUrls {
+		//download and process bills
+		c.downloadUpdates(
+			ctx,
+			ENDPOINT_BILL,
+			billsUrl,
+			c.config.Scope.FromDate,
+			c.config.Scope.ToDate,
+			c.processBills,
+			nil,
+		)
+	}
+}
+
 var congressUrlRegex = regexp.MustCompile(`congress/(\d+)`)
 
 func (c *CongressGovProcessor) processCongress(ctx context.Context, data []byte) {
@@ -275,40 +645,64 @@ func (c *CongressGovProcessor) processCongress(ctx context.Context, data []byte)
 
 	var cnt = 0
 	for _, congress := range result.Congresses {
-		//c.createMember(member)
 		if match := congressUrlRegex.FindStringSubmatch(*congress.URL); match != nil {
-			congressNum := match[1]
-
-			billsUrl := fmt.Sprintf(BILLS_URL, congressNum)
-
-			//download and process bills
-			c.dmgr.Download(
-				ctx,
-				downloadmgr.NewHttpGetRequest(c.applyApiToken(billsUrl)),
-				c.processBills,
-			)
+			congressNum, _ := strconv.Atoi(match[1])
+			if !c.config.Scope.HasCongress(congressNum) {
+				continue
+			}
+			c.downloadCongress(ctx, congressNum)
 		}
 		cnt++
-		if cnt > 2 {
-			break
-		}
 	}
 	fmt.Printf("updated %d congresses\n", cnt)
 }
 
 // Start implements Processor.
 func (c *CongressGovProcessor) Start() {
-	c.dmgr.Download(
-		c.ctx,
-		downloadmgr.NewHttpGetRequest(c.applyApiToken(MEMBERS_URL)),
-		c.processCurrentMembers,
-	)
+	scope := c.config.Scope
 
-	c.dmgr.Download(
+	membersUrl := MEMBERS_URL
+	if scope.HistoricalMembers {
+		membersUrl = ALL_MEMBERS_URL
+	}
+	// committee seats link members and committees, they are loaded once both are crawled
+	crawled := &sync.WaitGroup{}
+	crawled.Add(2)
+	c.downloadUpdates(
 		c.ctx,
-		downloadmgr.NewHttpGetRequest(c.applyApiToken(CONGRESS_URL)),
-		c.processCongress,
+		ENDPOINT_MEMBER,
+		membersUrl,
+		nil,
+		nil,
+		c.processCurrentMembers,
+		crawled.Done,
 	)
+	c.downloadCommittees(c.ctx, crawled.Done)
+	go func() {
+		crawled.Wait()
+		c.loadCommitteeMembership(c.ctx)
+	}()
+
+	switch {
+	case len(scope.Congresses) > 0:
+		for _, congress := range scope.Congresses {
+			c.downloadCongress(c.ctx, congress)
+		}
+	case scope.LatestCongresses > 0:
+		// congresses are listed from the most recent one
+		c.download(
+			c.ctx,
+			downloadmgr.NewHttpGetRequest(c.applyApiToken(fmt.Sprintf(LATEST_CONGRESSES_URL, scope.LatestCongresses))),
+			c.processCongress,
+		)
+	default:
+		c.downloadPages(
+			c.ctx,
+			ENDPOINT_CONGRESS,
+			CONGRESS_URL,
+			c.processCongress,
+		)
+	}
 }
 
 func NewCongressGovProcessor(
@@ -316,11 +710,18 @@ func NewCongressGovProcessor(
 	dmgr *downloadmgr.DownloadManager,
 	config *config.Config,
 	graphdbsvc graphdb.GraphDbService,
+	crosswalk *entityres.Crosswalk,
+	resolver *entityres.Resolver,
+	syncStates syncstate.SyncStateRepository,
 ) *CongressGovProcessor {
 	return &CongressGovProcessor{
 		ctx:        ctx,
 		dmgr:       dmgr,
 		apiToken:   config.CongressGovToken,
+		config:     config,
 		graphdbsvc: graphdbsvc,
+		crosswalk:  crosswalk,
+		resolver:   resolver,
+		syncStates: syncStates,
 	}
 }
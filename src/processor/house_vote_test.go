@@ -0,0 +1,106 @@
+package processor
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/nedvisol/go-connectdots/graphdb"
+	"github.com/nedvisol/go-connectdots/ids"
+	"github.com/nedvisol/go-connectdots/model"
+)
+
+func TestProcessHouseRollCallVote(t *testing.T) {
+	ctx := context.Background()
+	svc := graphdb.NewMemoryGraphService()
+	representatives := []string{"A000370", "A000055", "A000372", "A000376", "A000148"}
+	for _, bioguideId := range representatives {
+		if err := svc.UpdateNode(ctx, &graphdb.NodeInfo{Label: "Person", Id: ids.Member(bioguideId), Attrs: &map[string]interface{}{}}, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	congress, billType, number := 118, "HR", "5"
+	bill := &model.CongressApiBill{Congress: congress, Type: &billType, Number: &number}
+	billId := ids.Bill(congress, billType, number)
+	if err := svc.UpdateNode(ctx, &graphdb.NodeInfo{Label: "Bill", Id: billId, Attrs: &map[string]interface{}{}}, true); err != nil {
+		t.Fatal(err)
+	}
+	chamber, session, roll, url := "House", 1, 5, "https://clerk.house.gov/evs/2023/roll005.xml"
+	recordedVote := &model.CongressApiRecordedVote{Chamber: &chamber, Congress: &congress, SessionNumber: &session, RollNumber: &roll,
+		Date: time.Date(2023, 1, 9, 23, 52, 0, 0, time.UTC), URL: &url}
+	ctx = context.WithValue(context.WithValue(ctx, billContextKey, bill), recordedVoteContextKey, recordedVote)
+
+	c := &CongressGovProcessor{graphdbsvc: svc}
+	data, err := os.ReadFile("testdata/roll005.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.processHouseRollCallVote(ctx, data)
+
+	voteId := ids.Vote(congress, chamber, session, roll)
+	vote, err := svc.GetNode(ctx, &graphdb.NodeInfo{Label: "Vote", Id: voteId})
+	if err != nil || vote == nil {
+		t.Fatalf("vote %s not created: %v", voteId, err)
+	}
+	wantAttrs := map[string]interface{}{
+		"yea": 2, "nay": 1, "present": 1, "notVoting": 1,
+		"republicanYea": 2, "republicanNay": 0, "republicanPresent": 0, "republicanNotVoting": 0,
+		"democraticYea": 0, "democraticNay": 1, "democraticPresent": 1, "democraticNotVoting": 1,
+		"question": "On Passage", "result": "Passed", "voteType": "YEA-AND-NAY", "description": "Parents Bill of Rights Act",
+		"legisNum": "H R 5", "chamber": "House", "congress": 118, "session": 1, "rollNumber": 5,
+		"date": "2023-01-09T23:52:00Z", "url": url,
+	}
+	for attr, want := range wantAttrs {
+		if got := storedValue((*vote.Attrs)[attr]); got != want {
+			t.Errorf("vote %s is %v, want %v", attr, got, want)
+		}
+	}
+
+	concerns, err := svc.FindEdges(ctx, "CONCERNS")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(concerns) != 1 || concerns[0].Left.Id != voteId || concerns[0].Right.Id != billId {
+		t.Errorf("vote concerns %v, want %s", concerns, billId)
+	}
+
+	castVotes, err := svc.FindEdges(ctx, "CAST_VOTE")
+	if err != nil {
+		t.Fatal(err)
+	}
+	positions := make(map[string]interface{})
+	for _, edge := range castVotes {
+		castVoteId, _ := ids.CastVote(voteId, edge.Left.Id)
+		if edge.Id != castVoteId || edge.Right.Label != "Vote" || edge.Right.Id != voteId {
+			t.Errorf("cast vote %s links %s to %s %s", edge.Id, edge.Left.Id, edge.Right.Label, edge.Right.Id)
+		}
+		positions[edge.Left.Id] = (*edge.Attrs)["position"]
+	}
+	wantPositions := map[string]interface{}{
+		ids.Member("A000370"): "Nay",
+		ids.Member("A000055"): "Yea",
+		ids.Member("A000372"): "Yea",
+		ids.Member("A000376"): "Present",
+		ids.Member("A000148"): "Not Voting",
+	}
+	if len(positions) != len(wantPositions) {
+		t.Errorf("votes cast by %v, want %v", positions, wantPositions)
+	}
+	for personId, position := range wantPositions {
+		if positions[personId] != position {
+			t.Errorf("%s voted %v, want %v", personId, positions[personId], position)
+		}
+	}
+}
+
+// storedValue reads the value of an attribute stored as a pointer
+func storedValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case *string:
+		return *v
+	case *int:
+		return *v
+	}
+	return value
+}
@@ -0,0 +1,193 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nedvisol/go-connectdots/downloadmgr"
+	"github.com/nedvisol/go-connectdots/syncstate"
+)
+
+// SORT_BY_UPDATE_QUERY lists the records updated during a sync after the others instead of shifting the pages
+const SORT_BY_UPDATE_QUERY = "&sort=updateDate+asc"
+
+// dateTimeQuery returns the fromDateTime and toDateTime parameters of congress.gov list endpoints,
+// to is a date and includes the whole day
+func dateTimeQuery(from *time.Time, to *time.Time) string {
+	query := ""
+	if from != nil {
+		query += "&fromDateTime=" + from.UTC().Format("2006-01-02T15:04:05Z")
+	}
+	if to != nil {
+		query += "&toDateTime=" + to.UTC().Format("2006-01-02T23:59:59Z")
+	}
+	return query
+}
+
+// syncStateQueryParams are the query parameters of a list url left out of its sync state key, they page
+// or filter the list by date, or authenticate the request
+var syncStateQueryParams = []string{"offset", "limit", "fromDateTime", "toDateTime", "sort", "api_key"}
+
+// syncStateKey identifies the sync state of the list at listUrl crawled within the from and to scope dates,
+// the list url minus its paging and date parameters followed by the scope dates, e.g.
+// https://api.congress.gov/v3/bill/118?format=json#from=2023-01-01
+func syncStateKey(listUrl string, from *time.Time, to *time.Time) string {
+	key := listUrl
+	if parsed, err := url.Parse(listUrl); err == nil {
+		query := parsed.Query()
+		for _, param := range syncStateQueryParams {
+			query.Del(param)
+		}
+		parsed.RawQuery = query.Encode()
+		parsed.Fragment = ""
+		key = parsed.String()
+	}
+	scope := make([]string, 0, 2)
+	if from != nil {
+		scope = append(scope, "from="+from.UTC().Format(time.DateOnly))
+	}
+	if to != nil {
+		scope = append(scope, "to="+to.UTC().Format(time.DateOnly))
+	}
+	if len(scope) > 0 {
+		key += "#" + strings.Join(scope, "&")
+	}
+	return key
+}
+
+// latestUpdate keeps the latest update date of the records on the pages of a list
+type latestUpdate struct {
+	mutex sync.Mutex
+	date  *time.Time
+}
+
+// parseUpdateDate parses the updateDate of a congress.gov record, a timestamp or a date
+func parseUpdateDate(value string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// observe reads the updateDate of the records of a list page, whatever the list is named, e.g. bills or members
+func (l *latestUpdate) observe(data []byte) {
+	var page map[string]json.RawMessage
+	if err := json.Unmarshal(data, &page); err != nil {
+		return
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	for _, value := range page {
+		var records []struct {
+			UpdateDate string `json:"updateDate"`
+		}
+		if err := json.Unmarshal(value, &records); err != nil {
+			continue
+		}
+		for _, record := range records {
+			date, ok := parseUpdateDate(record.UpdateDate)
+			if ok && (l.date == nil || date.After(*l.date)) {
+				l.date = &date
+			}
+		}
+	}
+}
+
+func (l *latestUpdate) get() *time.Time {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.date
+}
+
+// downloadUpdates downloads the pages of a congress.gov list endpoint filtering on the update date of
+// its records, limited to from and to when given.
+// In incremental mode only the records updated since the high-water mark of the list are requested,
+// bypassing the cache, each list url and from and to keep their own mark, see syncStateKey. Once every page and every download started from them were handled the mark moves
+// to the latest update date of the records, unless pages were left out because of the max pages of the
+// endpoint. Records updated at the mark are requested again by the next sync, which is harmless.
+// done, if not nil, is called once every page and the downloads started from them were handled.
+func (c *CongressGovProcessor) downloadUpdates(
+	ctx context.Context,
+	endpoint string,
+	listUrl string,
+	from *time.Time,
+	to *time.Time,
+	handler downloadmgr.DownloadCallback,
+	done func(),
+	opts ...interface{},
+) {
+	if !c.config.CongressGov.Incremental {
+		var listDone func(bool)
+		if done != nil {
+			listDone = func(bool) { done() }
+		}
+		c.downloadPagesThen(ctx, endpoint, listUrl+dateTimeQuery(from, to), handler, listDone, opts...)
+		return
+	}
+
+	key := syncStateKey(listUrl, from, to)
+	state, err := c.syncStates.FindByKey(ctx, key)
+	if err != nil {
+		panic(err)
+	}
+	if state != nil && (from == nil || state.HighWaterMark.After(*from)) {
+		from = &state.HighWaterMark
+	}
+	if from != nil {
+		fmt.Printf("syncing %s updated since %s\n", key, from.Format(time.RFC3339))
+	}
+
+	query := dateTimeQuery(from, to)
+	if endpoint == ENDPOINT_BILL {
+		query += SORT_BY_UPDATE_QUERY
+	}
+	latest := &latestUpdate{}
+	c.downloadPagesThen(
+		ctx,
+		endpoint,
+		listUrl+query,
+		func(pageCtx context.Context, data []byte) {
+			handler(pageCtx, data)
+			latest.observe(data)
+		},
+		func(truncated bool) {
+			highWaterMark := latest.get()
+			switch {
+			case truncated:
+				fmt.Printf("not moving the high-water mark of %s, pages were left out by the max pages of %s\n", key, endpoint)
+			case highWaterMark == nil:
+				fmt.Printf("no update of %s to sync\n", key)
+			default:
+				err := c.syncStates.Save(ctx, &syncstate.SyncState{
+					Key:           key,
+					HighWaterMark: *highWaterMark,
+					RunId:         c.config.RunId,
+				})
+				if err != nil {
+					panic(err)
+				}
+				fmt.Printf("synced %s up to %s\n", key, highWaterMark.Format(time.RFC3339))
+			}
+			if done != nil {
+				done()
+			}
+		},
+		append(opts, downloadmgr.NewDownloadNoCacheOption())...,
+	)
+}
+
+// detailCacheOption caches the detail, actions and other lists of a bill or amendment for good, unless
+// it is synced incrementally because it was updated
+func (c *CongressGovProcessor) detailCacheOption() interface{} {
+	if c.config.CongressGov.Incremental {
+		return downloadmgr.NewDownloadNoCacheOption()
+	}
+	return downloadmgr.NewDownloadCacheOption(TEN_YEARS)
+}
@@ -0,0 +1,209 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nedvisol/go-connectdots/config"
+	"github.com/nedvisol/go-connectdots/downloadmgr"
+	"github.com/nedvisol/go-connectdots/syncstate"
+)
+
+type memorySyncStates struct {
+	mutex  sync.Mutex
+	states map[string]*syncstate.SyncState
+}
+
+func (m *memorySyncStates) FindByKey(ctx context.Context, key string) (*syncstate.SyncState, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.states[key], nil
+}
+
+func (m *memorySyncStates) Save(ctx context.Context, state *syncstate.SyncState) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.states[state.Key] = state
+	return nil
+}
+
+func TestParseUpdateDate(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Time
+		ok    bool
+	}{
+		{"2024-05-01T12:34:56Z", time.Date(2024, 5, 1, 12, 34, 56, 0, time.UTC), true},
+		{"2024-05-01T08:34:56-04:00", time.Date(2024, 5, 1, 12, 34, 56, 0, time.UTC), true},
+		{"2024-05-01", time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), true},
+		{"", time.Time{}, false},
+		{"May 1, 2024", time.Time{}, false},
+	}
+	for _, test := range tests {
+		got, ok := parseUpdateDate(test.value)
+		if ok != test.ok || !got.Equal(test.want) {
+			t.Errorf("parseUpdateDate(%q) = %s, %t, want %s, %t", test.value, got, ok, test.want, test.ok)
+		}
+	}
+}
+
+func TestLatestUpdateObserve(t *testing.T) {
+	latest := &latestUpdate{}
+	latest.observe([]byte(`{"bills": [{"updateDate": "2024-05-01"}, {"updateDate": "2024-05-03T10:00:00Z"}], "pagination": {"count": 2}}`))
+	latest.observe([]byte(`{"members": [{"updateDate": "2024-05-02T10:00:00Z"}, {"name": "no date"}]}`))
+	latest.observe([]byte(`not json`))
+
+	want := time.Date(2024, 5, 3, 10, 0, 0, 0, time.UTC)
+	if got := latest.get(); got == nil || !got.Equal(want) {
+		t.Fatalf("latest update is %v, want %s", got, want)
+	}
+}
+
+// listServer serves a list of two pages of bills and a slow detail
+func listServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/bill":
+			if r.URL.Query().Get("page") == "2" {
+				fmt.Fprint(w, `{"bills": [{"updateDate": "2024-05-02T00:00:00Z"}]}`)
+				return
+			}
+			fmt.Fprint(w, `{"bills": [{"updateDate": "2024-05-01T00:00:00Z"}], "pagination": {"next": "`+"http://"+r.Host+`/bill?format=json&page=2"}}`)
+		case "/detail":
+			time.Sleep(50 * time.Millisecond)
+			fmt.Fprint(w, `{}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestDownloadUpdatesHighWaterMark(t *testing.T) {
+	tests := []struct {
+		name     string
+		maxPages int
+		details  int32
+		want     *time.Time
+	}{
+		{"every page", 0, 2, func() *time.Time { mark := time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC); return &mark }()},
+		{"truncated", 1, 1, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := listServer(t)
+			states := &memorySyncStates{states: make(map[string]*syncstate.SyncState)}
+			c := &CongressGovProcessor{
+				dmgr: downloadmgr.NewDownloadManager(&downloadmgr.DownloadManagerOptions{Config: &config.Config{}}),
+				config: &config.Config{
+					CongressGov: &config.CongressGovConfig{DefaultMaxPages: test.maxPages, Incremental: true},
+				},
+				syncStates: states,
+			}
+
+			var details atomic.Int32
+			finished := make(chan int32)
+			listUrl := server.URL + "/bill?format=json"
+			c.downloadUpdates(context.Background(), ENDPOINT_BILL, listUrl, nil, nil, func(ctx context.Context, data []byte) {
+				// a detail download started by the page, the mark waits for it
+				c.download(ctx, downloadmgr.NewHttpGetRequest(server.URL+"/detail?format=json"), func(ctx context.Context, data []byte) {
+					details.Add(1)
+				}, downloadmgr.NewDownloadNoCacheOption())
+			}, func() {
+				finished <- details.Load()
+			})
+
+			select {
+			case handled := <-finished:
+				if handled != test.details {
+					t.Errorf("done after %d details, want %d", handled, test.details)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("done was not called")
+			}
+
+			state, _ := states.FindByKey(context.Background(), server.URL+"/bill?format=json")
+			switch {
+			case test.want == nil && state != nil:
+				t.Errorf("high-water mark moved to %s", state.HighWaterMark)
+			case test.want != nil && (state == nil || !state.HighWaterMark.Equal(*test.want)):
+				t.Errorf("high-water mark is %v, want %s", state, test.want)
+			}
+		})
+	}
+}
+
+func TestSyncStateKey(t *testing.T) {
+	from := time.Date(2023, 1, 3, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		listUrl string
+		from    *time.Time
+		to      *time.Time
+		want    string
+	}{
+		{MEMBERS_URL, nil, nil, "https://api.congress.gov/v3/member?currentMember=true&format=json"},
+		{ALL_MEMBERS_URL, nil, nil, "https://api.congress.gov/v3/member?format=json"},
+		{"https://api.congress.gov/v3/bill/118?format=json&offset=250&limit=250&sort=updateDate+asc&fromDateTime=2024-01-01T00:00:00Z&api_key=secret",
+			nil, nil, "https://api.congress.gov/v3/bill/118?format=json"},
+		{"https://api.congress.gov/v3/bill/118?format=json&limit=250", &from, nil, "https://api.congress.gov/v3/bill/118?format=json#from=2023-01-03"},
+		{"https://api.congress.gov/v3/bill/118?format=json&limit=250", &from, &to, "https://api.congress.gov/v3/bill/118?format=json#from=2023-01-03&to=2023-12-31"},
+	}
+	for _, test := range tests {
+		if got := syncStateKey(test.listUrl, test.from, test.to); got != test.want {
+			t.Errorf("syncStateKey(%s, %v, %v) = %s, want %s", test.listUrl, test.from, test.to, got, test.want)
+		}
+	}
+}
+
+// TestDownloadUpdatesKeepsStatePerList syncs two lists of a same endpoint, each must only move its own mark
+func TestDownloadUpdatesKeepsStatePerList(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("currentMember") == "true" {
+			fmt.Fprint(w, `{"members": [{"updateDate": "2024-05-02T00:00:00Z"}]}`)
+			return
+		}
+		fmt.Fprint(w, `{"members": [{"updateDate": "2024-03-01T00:00:00Z"}]}`)
+	}))
+	t.Cleanup(server.Close)
+	states := &memorySyncStates{states: make(map[string]*syncstate.SyncState)}
+	c := &CongressGovProcessor{
+		dmgr:       downloadmgr.NewDownloadManager(&downloadmgr.DownloadManagerOptions{Config: &config.Config{}}),
+		config:     &config.Config{CongressGov: &config.CongressGovConfig{Incremental: true}},
+		syncStates: states,
+	}
+
+	currentUrl := server.URL + "/member?format=json&currentMember=true&limit=250"
+	allUrl := server.URL + "/member?format=json&limit=250"
+	for _, listUrl := range []string{currentUrl, allUrl} {
+		synced := make(chan struct{})
+		c.downloadUpdates(context.Background(), ENDPOINT_MEMBER, listUrl, nil, nil, func(ctx context.Context, data []byte) {}, func() {
+			close(synced)
+		})
+		select {
+		case <-synced:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s not synced", listUrl)
+		}
+	}
+
+	want := map[string]time.Time{
+		syncStateKey(currentUrl, nil, nil): time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC),
+		syncStateKey(allUrl, nil, nil):     time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+	}
+	if len(states.states) != len(want) {
+		t.Fatalf("sync states %v, want %v", states.states, want)
+	}
+	for key, mark := range want {
+		if state := states.states[key]; state == nil || !state.HighWaterMark.Equal(mark) {
+			t.Errorf("high-water mark of %s is %v, want %s", key, state, mark)
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/nedvisol/go-connectdots/config"
+	"github.com/nedvisol/go-connectdots/graphdb"
+	"github.com/nedvisol/go-connectdots/ids"
+	"github.com/nedvisol/go-connectdots/model"
+)
+
+const memberContextKey key = 5
+
+// congressYears gives the first and last year of congress, the 1st sat in 1789 and 1790
+func congressYears(congress int) (int, int) {
+	first := 1789 + 2*(congress-1)
+	return first, first + 1
+}
+
+// partyOf gives the party the member belonged to during term: the affiliation covering the most years
+// of the term's congress, from the term start for members who joined during the congress. On a tie the
+// affiliation started last wins, the party the member ended the term with.
+func partyOf(partyHistory []*model.CongressApiPartyHistory, term *model.CongressApiMemberTerm) *model.CongressApiPartyHistory {
+	first, last := congressYears(term.Congress)
+	first = max(first, term.StartYear)
+	if term.EndYear != nil {
+		last = min(last, *term.EndYear)
+	}
+	last = max(last, first)
+
+	var party *model.CongressApiPartyHistory
+	covered := 0
+	for _, affiliation := range partyHistory {
+		end := last
+		if affiliation.EndYear != nil {
+			end = min(end, *affiliation.EndYear)
+		}
+		years := end - max(first, affiliation.StartYear) + 1
+		if years <= 0 {
+			continue
+		}
+		if years > covered || (years == covered && affiliation.StartYear >= party.StartYear) {
+			party, covered = affiliation, years
+		}
+	}
+	return party
+}
+
+// leadershipOf lists the leadership roles held during congress, e.g. Assistant Democratic Leader
+func leadershipOf(leadership []*model.CongressApiLeadership, congress int) []string {
+	roles := make([]string, 0)
+	for _, role := range leadership {
+		if role.Congress == congress && role.Type != nil {
+			roles = append(roles, *role.Type)
+		}
+	}
+	return roles
+}
+
+// createStateNode makes sure the State exists and returns its id
+func (c *CongressGovProcessor) createStateNode(ctx context.Context, code string, name *string) string {
+	stateNode := &graphdb.NodeInfo{
+		Id:    ids.State(code),
+		Label: "State",
+		Attrs: &map[string]interface{}{
+			"code": strings.ToUpper(code),
+			"name": name,
+		},
+		Provenance: c.provenance(ctx),
+	}
+	err := c.graphdbsvc.UpdateNode(ctx, stateNode, true)
+	if err != nil {
+		panic(err)
+	}
+	return stateNode.Id
+}
+
+// createDistrictNode makes sure the District, 0 for at-large, and its IN_STATE edge exist and returns its id
+func (c *CongressGovProcessor) createDistrictNode(ctx context.Context, stateId string, stateCode string, number int) string {
+	districtNode := &graphdb.NodeInfo{
+		Id:    ids.District(stateCode, number),
+		Label: "District",
+		Attrs: &map[string]interface{}{
+			"state":  strings.ToUpper(stateCode),
+			"number": number,
+		},
+		Provenance: c.provenance(ctx),
+	}
+	err := c.graphdbsvc.UpdateNode(ctx, districtNode, true)
+	if err != nil {
+		panic(err)
+	}
+
+	inState := &graphdb.EdgeInfo{
+		Label: "IN_STATE",
+		Id:    districtNode.Id,
+		Left:  districtNode,
+		Right: &graphdb.NodeInfo{
+			Id:    stateId,
+			Label: "State",
+		},
+		Attrs:      &map[string]interface{}{},
+		Provenance: c.provenance(ctx),
+	}
+	err = c.graphdbsvc.UpdateEdge(ctx, inState, true)
+	if err != nil {
+		panic(err)
+	}
+	return districtNode.Id
+}
+
+// createTermEdge creates the REPRESENTED edge from the Person to the District of a House term,
+// or to the State of a Senate term
+func (c *CongressGovProcessor) createTermEdge(ctx context.Context, personId string, member *model.CongressApiMemberDetail, term *model.CongressApiMemberTerm) {
+	if term.Chamber == nil || term.StateCode == nil {
+		return
+	}
+	chamber := config.ChamberOf(*term.Chamber)
+	termId, err := ids.Term(personId, term.Congress, chamber)
+	if err != nil {
+		panic(err)
+	}
+
+	represented := &graphdb.NodeInfo{
+		Id:    c.createStateNode(ctx, *term.StateCode, term.StateName),
+		Label: "State",
+	}
+	if chamber == config.CHAMBER_HOUSE {
+		district := 0
+		if term.District != nil {
+			district = *term.District
+		}
+		represented = &graphdb.NodeInfo{
+			Id:    c.createDistrictNode(ctx, represented.Id, *term.StateCode, district),
+			Label: "District",
+		}
+	}
+
+	attrs := map[string]interface{}{
+		"chamber":    chamber,
+		"congress":   term.Congress,
+		"start":      term.StartYear,
+		"memberType": term.MemberType,
+	}
+	if term.EndYear != nil {
+		attrs["end"] = *term.EndYear
+	}
+	if party := partyOf(member.PartyHistory, term); party != nil {
+		attrs["party"] = party.PartyName
+	}
+	if roles := leadershipOf(member.Leadership, term.Congress); len(roles) > 0 {
+		attrs["leadership"] = roles
+	}
+
+	edge := &graphdb.EdgeInfo{
+		Label: "REPRESENTED",
+		Id:    termId,
+		Left: &graphdb.NodeInfo{
+			Id:    personId,
+			Label: "Person",
+		},
+		Right:      represented,
+		Attrs:      &attrs,
+		Provenance: c.provenance(ctx),
+	}
+	err = c.graphdbsvc.UpdateEdge(ctx, edge, true)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// createPartyMembershipEdge creates the Party and the MEMBER_OF edge from the Person to it,
+// one per affiliation so that party changes are kept
+func (c *CongressGovProcessor) createPartyMembershipEdge(ctx context.Context, personId string, affiliation *model.CongressApiPartyHistory) {
+	if affiliation.PartyName == nil {
+		return
+	}
+	partyNode := &graphdb.NodeInfo{
+		Id:    ids.Party(*affiliation.PartyName),
+		Label: "Party",
+		Attrs: &map[string]interface{}{
+			"name":         affiliation.PartyName,
+			"abbreviation": affiliation.PartyAbbreviation,
+		},
+		Provenance: c.provenance(ctx),
+	}
+	err := c.graphdbsvc.UpdateNode(ctx, partyNode, true)
+	if err != nil {
+		panic(err)
+	}
+
+	edgeId, err := ids.PartyMembership(personId, partyNode.Id, affiliation.StartYear)
+	if err != nil {
+		panic(err)
+	}
+	attrs := map[string]interface{}{
+		"start": affiliation.StartYear,
+	}
+	if affiliation.EndYear != nil {
+		attrs["end"] = *affiliation.EndYear
+	}
+	memberOf := &graphdb.EdgeInfo{
+		Label: "MEMBER_OF",
+		Id:    edgeId,
+		Left: &graphdb.NodeInfo{
+			Id:    personId,
+			Label: "Person",
+		},
+		Right:      partyNode,
+		Attrs:      &attrs,
+		Provenance: c.provenance(ctx),
+	}
+	err = c.graphdbsvc.UpdateEdge(ctx, memberOf, true)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// processMemberDetail completes the Person of the member in ctx with its profile, terms and party history
+func (c *CongressGovProcessor) processMemberDetail(ctx context.Context, data []byte) {
+	fmt.Printf("processing member detail %d bytes\n", len(data))
+
+	var result model.CongressApiMemberDetailResponse
+
+	// Parse (unmarshal) the JSON into the map
+	err := json.Unmarshal(data, &result)
+	if err != nil {
+		log.Fatalf("Error parsing JSON: %s", err)
+	}
+	if result.Member == nil {
+		return
+	}
+
+	member := ctx.Value(memberContextKey).(*model.CongressApiMember)
+	personId := ids.Member(member.BioguideID)
+	detail := result.Member
+
+	attrs := map[string]interface{}{
+		"currentMember": detail.CurrentMember,
+	}
+	if detail.BirthYear != nil {
+		attrs["birthYear"] = *detail.BirthYear
+	}
+	if detail.Depiction != nil && detail.Depiction.ImageUrl != "" {
+		attrs["imageUrl"] = detail.Depiction.ImageUrl
+	}
+	if detail.OfficialWebsiteUrl != nil {
+		attrs["website"] = *detail.OfficialWebsiteUrl
+	}
+	err = c.graphdbsvc.UpdateNode(ctx, &graphdb.NodeInfo{
+		Id:         personId,
+		Label:      "Person",
+		Attrs:      &attrs,
+		Provenance: c.provenance(ctx),
+	}, false)
+	if errors.Is(err, graphdb.ErrNodeNotFound) {
+		fmt.Printf("skipped detail of member %s, not in the graph\n", personId)
+		return
+	}
+	if err != nil {
+		panic(err)
+	}
+
+	for _, term := range detail.Terms {
+		c.createTermEdge(ctx, personId, detail, term)
+	}
+	for _, affiliation := range detail.PartyHistory {
+		c.createPartyMembershipEdge(ctx, personId, affiliation)
+	}
+	fmt.Printf("updated %d terms and %d party affiliations of member %s\n", len(detail.Terms), len(detail.PartyHistory), personId)
+}
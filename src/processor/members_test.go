@@ -0,0 +1,70 @@
+package processor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nedvisol/go-connectdots/graphdb"
+	"github.com/nedvisol/go-connectdots/model"
+)
+
+func affiliation(name string, start int, end int) *model.CongressApiPartyHistory {
+	party := &model.CongressApiPartyHistory{PartyName: &name, StartYear: start}
+	if end != 0 {
+		party.EndYear = &end
+	}
+	return party
+}
+
+func TestPartyOf(t *testing.T) {
+	switched := []*model.CongressApiPartyHistory{affiliation("Democratic", 2013, 2022), affiliation("Independent", 2022, 0)}
+	tests := []struct {
+		name    string
+		history []*model.CongressApiPartyHistory
+		term    *model.CongressApiMemberTerm
+		want    string
+	}{
+		{"single affiliation", []*model.CongressApiPartyHistory{affiliation("Republican", 2011, 0)},
+			&model.CongressApiMemberTerm{Congress: 118, StartYear: 2023}, "Republican"},
+		{"switch in the last year of the term", switched,
+			&model.CongressApiMemberTerm{Congress: 117, StartYear: 2021, EndYear: intPtr(2023)}, "Democratic"},
+		{"term after the switch", switched,
+			&model.CongressApiMemberTerm{Congress: 118, StartYear: 2023}, "Independent"},
+		{"switch in the first year of the term", []*model.CongressApiPartyHistory{affiliation("Republican", 1995, 2001), affiliation("Independent", 2001, 0)},
+			&model.CongressApiMemberTerm{Congress: 107, StartYear: 2001, EndYear: intPtr(2003)}, "Independent"},
+		{"tie goes to the later affiliation", []*model.CongressApiPartyHistory{affiliation("Democratic", 2015, 2021), affiliation("Independent", 2022, 0)},
+			&model.CongressApiMemberTerm{Congress: 117, StartYear: 2021, EndYear: intPtr(2023)}, "Independent"},
+		{"joined during the congress", []*model.CongressApiPartyHistory{affiliation("Democratic", 2010, 2023), affiliation("Republican", 2024, 0)},
+			&model.CongressApiMemberTerm{Congress: 118, StartYear: 2024}, "Republican"},
+		{"no affiliation during the term", []*model.CongressApiPartyHistory{affiliation("Whig", 1840, 1850)},
+			&model.CongressApiMemberTerm{Congress: 118, StartYear: 2023}, ""},
+	}
+	for _, test := range tests {
+		got := ""
+		if party := partyOf(test.history, test.term); party != nil {
+			got = *party.PartyName
+		}
+		if got != test.want {
+			t.Errorf("%s: partyOf = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func intPtr(value int) *int {
+	return &value
+}
+
+func TestProcessMemberDetailSkipsMissingPerson(t *testing.T) {
+	svc := graphdb.NewMemoryGraphService()
+	c := &CongressGovProcessor{graphdbsvc: svc}
+	ctx := context.WithValue(context.Background(), memberContextKey, &model.CongressApiMember{BioguideID: "S000033"})
+	c.processMemberDetail(ctx, []byte(`{"member": {"bioguideId": "S000033", "terms": [{"chamber": "Senate", "congress": 118, "startYear": 2023, "stateCode": "VT"}]}}`))
+
+	edges, err := svc.FindEdges(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(edges) != 0 {
+		t.Fatalf("%d edges written for a member not in the graph", len(edges))
+	}
+}
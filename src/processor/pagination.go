@@ -0,0 +1,140 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/nedvisol/go-connectdots/downloadmgr"
+	"github.com/nedvisol/go-connectdots/model"
+)
+
+// list endpoints of congress.gov, the keys of config.CongressGov.MaxPages
+const ENDPOINT_MEMBER = "member"
+const ENDPOINT_CONGRESS = "congress"
+const ENDPOINT_BILL = "bill"
+const ENDPOINT_BILL_ACTIONS = "actions"
+const ENDPOINT_COSPONSORS = "cosponsors"
+const ENDPOINT_COMMITTEE = "committee"
+const ENDPOINT_COMMITTEE_REPORTS = "reports"
+const ENDPOINT_BILL_COMMITTEES = "committees"
+const ENDPOINT_BILL_SUBJECTS = "subjects"
+const ENDPOINT_BILL_SUMMARIES = "summaries"
+const ENDPOINT_AMENDMENT = "amendment"
+const ENDPOINT_AMENDMENT_ACTIONS = "amendment-actions"
+
+// maxPages returns the number of pages followed for endpoint, 0 for every page
+func (c *CongressGovProcessor) maxPages(endpoint string) int {
+	if maxPages, found := c.config.CongressGov.MaxPages[endpoint]; found {
+		return maxPages
+	}
+	return c.config.CongressGov.DefaultMaxPages
+}
+
+// pendingContextKey holds the *sync.WaitGroup counting the downloads started for a list with downloadPagesThen
+const pendingContextKey key = 6
+
+// download is dmgr.Download counting the download in the pending downloads of ctx, if any, until handler
+// returned. Handlers start their downloads before returning, so the count only drops to zero once the
+// downloads started from the pages of a list, and theirs, are all handled.
+func (c *CongressGovProcessor) download(
+	ctx context.Context,
+	request *http.Request,
+	handler downloadmgr.DownloadCallback,
+	opts ...interface{},
+) {
+	pending, _ := ctx.Value(pendingContextKey).(*sync.WaitGroup)
+	if pending != nil {
+		pending.Add(1)
+	}
+	c.dmgr.Download(ctx, request, func(downloadCtx context.Context, data []byte) {
+		if pending != nil {
+			defer pending.Done()
+		}
+		handler(downloadCtx, data)
+	}, opts...)
+}
+
+// downloadPages downloads the congress.gov list at listUrl and every page following it through
+// pagination.next links, up to the max pages of endpoint, and passes each page to handler
+func (c *CongressGovProcessor) downloadPages(
+	ctx context.Context,
+	endpoint string,
+	listUrl string,
+	handler downloadmgr.DownloadCallback,
+	opts ...interface{},
+) {
+	c.downloadPagesThen(ctx, endpoint, listUrl, handler, nil, opts...)
+}
+
+// downloadPagesThen is downloadPages calling done once every page and every download started while
+// handling them were handled, truncated tells whether pages were left out because of the max pages of endpoint.
+// The list is counted in the pending downloads of ctx until done returned.
+func (c *CongressGovProcessor) downloadPagesThen(
+	ctx context.Context,
+	endpoint string,
+	listUrl string,
+	handler downloadmgr.DownloadCallback,
+	done func(truncated bool),
+	opts ...interface{},
+) {
+	truncated := &atomic.Bool{}
+	if done == nil {
+		c.downloadPage(ctx, endpoint, listUrl, 1, handler, truncated, opts...)
+		return
+	}
+
+	outer, _ := ctx.Value(pendingContextKey).(*sync.WaitGroup)
+	if outer != nil {
+		outer.Add(1)
+	}
+	pending := &sync.WaitGroup{}
+	c.downloadPage(context.WithValue(ctx, pendingContextKey, pending), endpoint, listUrl, 1, handler, truncated, opts...)
+	go func() {
+		pending.Wait()
+		done(truncated.Load())
+		if outer != nil {
+			outer.Done()
+		}
+	}()
+}
+
+// downloadPage downloads a page of a list and follows its next page, unless the max pages of endpoint
+// is reached which sets truncated
+func (c *CongressGovProcessor) downloadPage(
+	ctx context.Context,
+	endpoint string,
+	pageUrl string,
+	page int,
+	handler downloadmgr.DownloadCallback,
+	truncated *atomic.Bool,
+	opts ...interface{},
+) {
+	c.download(
+		ctx,
+		downloadmgr.NewHttpGetRequest(c.applyApiToken(pageUrl)),
+		func(pageCtx context.Context, data []byte) {
+			var result model.CongressApiPage
+
+			err := json.Unmarshal(data, &result)
+			if err != nil {
+				log.Fatalf("Error parsing JSON: %s", err)
+			}
+
+			if result.Pagination != nil && result.Pagination.Next != nil {
+				if maxPages := c.maxPages(endpoint); maxPages > 0 && page >= maxPages {
+					fmt.Printf("reached max pages %d of %s, not following %s\n", maxPages, endpoint, *result.Pagination.Next)
+					truncated.Store(true)
+				} else {
+					c.downloadPage(ctx, endpoint, *result.Pagination.Next, page+1, handler, truncated, opts...)
+				}
+			}
+			handler(pageCtx, data)
+		},
+		opts...,
+	)
+}
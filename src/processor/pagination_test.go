@@ -0,0 +1,167 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nedvisol/go-connectdots/cacheditem"
+	"github.com/nedvisol/go-connectdots/config"
+	"github.com/nedvisol/go-connectdots/downloadmgr"
+)
+
+// noCachedItems is a cache which never holds anything, every download goes to the server
+type noCachedItems struct{}
+
+func (noCachedItems) Create(ctx context.Context, item *cacheditem.CachedItem) error { return nil }
+func (noCachedItems) FindByKey(ctx context.Context, key string) (*cacheditem.CachedItem, error) {
+	return nil, nil
+}
+func (noCachedItems) Update(ctx context.Context, item *cacheditem.CachedItem) error { return nil }
+func (noCachedItems) DeleteByKey(ctx context.Context, key string) error             { return nil }
+func (noCachedItems) FindAll(ctx context.Context) ([]*cacheditem.CachedItem, error) {
+	return nil, nil
+}
+
+// pageServer serves a member list of the given number of pages, counting the requests, and a slow detail
+func pageServer(t *testing.T, pages int, requests *atomic.Int32) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/detail" {
+			time.Sleep(20 * time.Millisecond)
+			fmt.Fprint(w, `{}`)
+			return
+		}
+		requests.Add(1)
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page < pages {
+			fmt.Fprintf(w, `{"page": %d, "pagination": {"next": "http://%s/member?format=json&page=%d"}}`, page, r.Host, page+1)
+			return
+		}
+		fmt.Fprintf(w, `{"page": %d, "pagination": {}}`, page)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func testDownloadManager(t *testing.T) *downloadmgr.DownloadManager {
+	return downloadmgr.NewDownloadManager(&downloadmgr.DownloadManagerOptions{
+		CacheDir:       t.TempDir(),
+		CachedItemRepo: noCachedItems{},
+		Config:         &config.Config{},
+	})
+}
+
+func TestDownloadPages(t *testing.T) {
+	tests := []struct {
+		name      string
+		congress  *config.CongressGovConfig
+		wantPages int
+	}{
+		{"every page", &config.CongressGovConfig{}, 3},
+		{"default max pages", &config.CongressGovConfig{DefaultMaxPages: 2}, 2},
+		{"max pages of the endpoint", &config.CongressGovConfig{MaxPages: map[string]int{ENDPOINT_MEMBER: 1}, DefaultMaxPages: 2}, 1},
+		{"max pages beyond the list", &config.CongressGovConfig{DefaultMaxPages: 5}, 3},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var requests atomic.Int32
+			server := pageServer(t, 3, &requests)
+			c := &CongressGovProcessor{
+				dmgr:   testDownloadManager(t),
+				config: &config.Config{CongressGov: test.congress},
+			}
+
+			handled := make(chan int, 5)
+			c.downloadPages(context.Background(), ENDPOINT_MEMBER, server.URL+"/member?format=json&page=1", func(ctx context.Context, data []byte) {
+				var page struct{ Page int }
+				json.Unmarshal(data, &page)
+				handled <- page.Page
+			})
+
+			for want := 1; want <= test.wantPages; want++ {
+				select {
+				case page := <-handled:
+					if page != want {
+						t.Fatalf("handled page %d, want %d", page, want)
+					}
+				case <-time.After(5 * time.Second):
+					t.Fatalf("page %d not handled", want)
+				}
+			}
+			// a next page is requested before the page is handled
+			c.dmgr.Wait()
+			if got := requests.Load(); got != int32(test.wantPages) {
+				t.Fatalf("%d pages requested, want %d", got, test.wantPages)
+			}
+		})
+	}
+}
+
+func TestDownloadPagesThen(t *testing.T) {
+	tests := []struct {
+		name          string
+		maxPages      int
+		wantPages     int32
+		wantTruncated bool
+	}{
+		{"every page", 0, 3, false},
+		{"cut off by max pages", 2, 2, true},
+		{"max pages reached on the last page", 3, 3, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var requests atomic.Int32
+			server := pageServer(t, 3, &requests)
+			c := &CongressGovProcessor{
+				dmgr:   testDownloadManager(t),
+				config: &config.Config{CongressGov: &config.CongressGovConfig{DefaultMaxPages: test.maxPages}},
+			}
+
+			// the list is pending in the downloads of an enclosing list until done returned
+			outer := &sync.WaitGroup{}
+			ctx := context.WithValue(context.Background(), pendingContextKey, outer)
+			var pages, details atomic.Int32
+			var doneCalls atomic.Int32
+			var truncated atomic.Bool
+			c.downloadPagesThen(ctx, ENDPOINT_MEMBER, server.URL+"/member?format=json&page=1", func(ctx context.Context, data []byte) {
+				pages.Add(1)
+				c.download(ctx, downloadmgr.NewHttpGetRequest(server.URL+"/detail?format=json"), func(ctx context.Context, data []byte) {
+					details.Add(1)
+				}, downloadmgr.NewDownloadNoCacheOption())
+			}, func(listTruncated bool) {
+				if pages.Load() != details.Load() {
+					t.Errorf("done after %d details of %d pages", details.Load(), pages.Load())
+				}
+				truncated.Store(listTruncated)
+				doneCalls.Add(1)
+			})
+
+			finished := make(chan struct{})
+			go func() {
+				outer.Wait()
+				close(finished)
+			}()
+			select {
+			case <-finished:
+			case <-time.After(5 * time.Second):
+				t.Fatal("the list is still pending")
+			}
+			if doneCalls.Load() != 1 {
+				t.Fatalf("done called %d times, want once", doneCalls.Load())
+			}
+			if pages.Load() != test.wantPages || requests.Load() != test.wantPages {
+				t.Errorf("%d pages handled of %d requested, want %d", pages.Load(), requests.Load(), test.wantPages)
+			}
+			if truncated.Load() != test.wantTruncated {
+				t.Errorf("truncated %t, want %t", truncated.Load(), test.wantTruncated)
+			}
+		})
+	}
+}
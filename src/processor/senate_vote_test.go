@@ -0,0 +1,104 @@
+package processor
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/nedvisol/go-connectdots/config"
+	"github.com/nedvisol/go-connectdots/entityres"
+	"github.com/nedvisol/go-connectdots/graphdb"
+	"github.com/nedvisol/go-connectdots/ids"
+	"github.com/nedvisol/go-connectdots/model"
+)
+
+// senators are the Person nodes of the graph, only Sanders is known to the crosswalk by LIS id
+var senators = []struct {
+	bioguideId string
+	lisId      string
+	attrs      map[string]interface{}
+}{
+	{"S000033", "S313", map[string]interface{}{"first": "Bernard", "last": "Sanders", "state": "VT", "party": "I"}},
+	{"W000779", "", map[string]interface{}{"first": "Ron", "last": "Wyden", "state": "OR", "party": "D"}},
+	{"M001176", "", map[string]interface{}{"first": "Jeff", "last": "Merkley", "state": "OR"}},
+	{"S001203", "S311", map[string]interface{}{"first": "Tina", "last": "Smith", "state": "MN", "party": "D"}},
+}
+
+func TestProcessSenateRollCallVote(t *testing.T) {
+	ctx := context.Background()
+	svc := graphdb.NewMemoryGraphService()
+	crosswalk := entityres.OpenCrosswalk()
+	for _, senator := range senators {
+		attrs := senator.attrs
+		if err := svc.UpdateNode(ctx, &graphdb.NodeInfo{Label: "Person", Id: ids.Member(senator.bioguideId), Attrs: &attrs}, true); err != nil {
+			t.Fatal(err)
+		}
+		crosswalk.Add("Person", ids.Member(senator.bioguideId), entityres.SYSTEM_BIOGUIDE, senator.bioguideId)
+		crosswalk.Add("Person", ids.Member(senator.bioguideId), entityres.SYSTEM_LIS, senator.lisId)
+	}
+
+	congress, billType, number := 118, "S", "5"
+	bill := &model.CongressApiBill{Congress: congress, Type: &billType, Number: &number}
+	if err := svc.UpdateNode(ctx, &graphdb.NodeInfo{Label: "Bill", Id: ids.Bill(congress, billType, number), Attrs: &map[string]interface{}{}}, true); err != nil {
+		t.Fatal(err)
+	}
+	chamber, session, roll := "Senate", 1, 5
+	recordedVote := &model.CongressApiRecordedVote{Chamber: &chamber, Congress: &congress, SessionNumber: &session, RollNumber: &roll, Date: time.Date(2023, 1, 26, 14, 21, 0, 0, time.UTC)}
+	ctx = context.WithValue(context.WithValue(ctx, billContextKey, bill), recordedVoteContextKey, recordedVote)
+
+	cfg := &config.Config{EntityRes: &config.EntityResConfig{AutoLinkThreshold: 0.92, ReviewThreshold: 0.75}}
+	c := &CongressGovProcessor{
+		config:     cfg,
+		graphdbsvc: svc,
+		crosswalk:  crosswalk,
+		resolver:   entityres.NewResolver(svc, crosswalk, cfg),
+	}
+	data, err := os.ReadFile("testdata/vote_118_1_00005.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.processSenateRollCallVote(ctx, data)
+
+	voteId := ids.Vote(congress, chamber, session, roll)
+	vote, _ := svc.GetNode(ctx, &graphdb.NodeInfo{Label: "Vote", Id: voteId})
+	if vote == nil {
+		t.Fatal("vote not created")
+	}
+	if yea := (*vote.Attrs)["yea"]; yea != 3 {
+		t.Errorf("%v yeas counted, want 3", yea)
+	}
+
+	edges, err := svc.FindEdges(ctx, "CAST_VOTE")
+	if err != nil {
+		t.Fatal(err)
+	}
+	positions := make(map[string]interface{})
+	for _, edge := range edges {
+		positions[edge.Left.Id] = (*edge.Attrs)["position"]
+	}
+	want := map[string]interface{}{
+		ids.Member("S000033"): "Yea", // by LIS id in the crosswalk
+		ids.Member("W000779"): "Nay", // by the exact name, state and party
+	}
+	if len(positions) != len(want) {
+		t.Errorf("votes cast by %v, want %v", positions, want)
+	}
+	for personId, position := range want {
+		if positions[personId] != position {
+			t.Errorf("%s voted %v, want %v", personId, positions[personId], position)
+		}
+	}
+	if entry := crosswalk.Lookup(entityres.SYSTEM_LIS, "S247"); entry == nil || entry.Id != ids.Member("W000779") {
+		t.Errorf("LIS id of the linked senator not recorded: %v", entry)
+	}
+
+	// Merkley is a fuzzy match without a party, queued for review
+	reviews, err := c.resolver.PendingReviews(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reviews) != 1 || reviews[0].Id != "lis:S322" {
+		t.Fatalf("pending reviews %v, want lis:S322", reviews)
+	}
+}
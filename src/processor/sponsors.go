@@ -0,0 +1,189 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/nedvisol/go-connectdots/graphdb"
+	"github.com/nedvisol/go-connectdots/ids"
+	"github.com/nedvisol/go-connectdots/model"
+)
+
+const BILL_COSPONSORS_URL_QUERY = "/cosponsors?format=json&limit=250"
+
+// SPONSOR_TITLES prefix the fullName of sponsors
+var SPONSOR_TITLES = []string{"Rep. ", "Sen. ", "Del. ", "Resident Commissioner "}
+
+// createSponsorNode makes sure the Person sponsoring a bill exists, sponsors are not always among the
+// members crawled. Party and state are left to the member crawl, which names them in full, and so are
+// the names of a Person the member crawl created: the sponsor names are only set on a new node.
+// Missing names are taken from fullName, a sponsor that still has none is only linked when the member
+// crawl created it, ok is false otherwise as a Person requires both names.
+func (c *CongressGovProcessor) createSponsorNode(ctx context.Context, bioguideId string, firstName *string, lastName *string, fullName *string) (personId string, ok bool) {
+	if (firstName == nil || lastName == nil) && fullName != nil {
+		if first, last, parsed := parseSponsorName(*fullName); parsed {
+			firstName, lastName = &first, &last
+		}
+	}
+	personNode := &graphdb.NodeInfo{
+		Id:         ids.Member(bioguideId),
+		Label:      "Person",
+		Attrs:      &map[string]interface{}{"subtype": "CongressMember"},
+		Provenance: c.provenance(ctx),
+	}
+	if firstName != nil && lastName != nil {
+		newNode := &graphdb.NodeInfo{
+			Id:    personNode.Id,
+			Label: personNode.Label,
+			Attrs: &map[string]interface{}{
+				"subtype": "CongressMember",
+				"first":   firstName,
+				"last":    lastName,
+			},
+			Provenance: personNode.Provenance,
+		}
+		err := c.graphdbsvc.CreateNode(ctx, newNode)
+		if err == nil {
+			return personNode.Id, true
+		}
+		if !errors.Is(err, graphdb.ErrNodeExists) {
+			panic(err)
+		}
+	}
+	err := c.graphdbsvc.UpdateNode(ctx, personNode, false)
+	if errors.Is(err, graphdb.ErrNodeNotFound) {
+		fmt.Printf("skipped sponsor %s, no names and not in the graph\n", bioguideId)
+		return personNode.Id, false
+	}
+	if err != nil {
+		panic(err)
+	}
+	return personNode.Id, true
+}
+
+// parseSponsorName splits the fullName of a sponsor, like "Sen. Sanders, Bernard [I-VT]", into its first
+// and last names
+func parseSponsorName(fullName string) (first string, last string, ok bool) {
+	name := fullName
+	if i := strings.LastIndex(name, " ["); i >= 0 {
+		name = name[:i]
+	}
+	for _, title := range SPONSOR_TITLES {
+		if trimmed, found := strings.CutPrefix(name, title); found {
+			name = trimmed
+			break
+		}
+	}
+	last, first, ok = strings.Cut(name, ",")
+	first, last = strings.TrimSpace(first), strings.TrimSpace(last)
+	if !ok || first == "" || last == "" {
+		return "", "", false
+	}
+	return first, last, true
+}
+
+// createSponsorshipEdge creates the label edge from the Person to the sponsored Bill or Amendment,
+// identified by sponsorshipId
+func (c *CongressGovProcessor) createSponsorshipEdge(
+	ctx context.Context,
+	label string,
+	sponsorshipId func(id string, memberId string) (string, error),
+	sponsored *graphdb.NodeInfo,
+	personId string,
+	attrs map[string]interface{},
+) {
+	edgeId, err := sponsorshipId(sponsored.Id, personId)
+	if err != nil {
+		panic(err)
+	}
+	sponsorship := &graphdb.EdgeInfo{
+		Label: label,
+		Id:    edgeId,
+		Left: &graphdb.NodeInfo{
+			Id:    personId,
+			Label: "Person",
+		},
+		Right:      sponsored,
+		Attrs:      &attrs,
+		Provenance: c.provenance(ctx),
+	}
+	err = c.graphdbsvc.UpdateEdge(ctx, sponsorship, true)
+	if errors.Is(err, graphdb.ErrNodeNotFound) {
+		fmt.Printf("skipped %s edge of %s to %s, not in the graph\n", label, personId, sponsored.Id)
+		return
+	}
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (c *CongressGovProcessor) processBillDetail(ctx context.Context, data []byte) {
+	fmt.Printf("processing bill detail %d bytes\n", len(data))
+
+	var result model.CongressApiBillDetailResponse
+
+	// Parse (unmarshal) the JSON into the map
+	err := json.Unmarshal(data, &result)
+	if err != nil {
+		log.Fatalf("Error parsing JSON: %s", err)
+	}
+	if result.Bill == nil {
+		return
+	}
+
+	bill := ctx.Value(billContextKey).(*model.CongressApiBill)
+	billId := ids.Bill(bill.Congress, *bill.Type, *bill.Number)
+
+	for _, sponsor := range result.Bill.Sponsors {
+		if sponsor.BioguideID == nil {
+			continue
+		}
+		personId, ok := c.createSponsorNode(ctx, *sponsor.BioguideID, sponsor.FirstName, sponsor.LastName, sponsor.FullName)
+		if !ok {
+			continue
+		}
+		c.createSponsorshipEdge(ctx, "SPONSORED", ids.Sponsorship, &graphdb.NodeInfo{Id: billId, Label: "Bill"}, personId, map[string]interface{}{
+			"date":        result.Bill.IntroducedDate,
+			"isByRequest": sponsor.IsByRequest != nil && *sponsor.IsByRequest == "Y",
+		})
+	}
+	fmt.Printf("updated %d sponsors of bill %s\n", len(result.Bill.Sponsors), billId)
+}
+
+// processCosponsors keeps the withdrawn cosponsors, their edge carries the withdrawnDate
+func (c *CongressGovProcessor) processCosponsors(ctx context.Context, data []byte) {
+	fmt.Printf("processing cosponsors %d bytes\n", len(data))
+
+	var result model.CongressApiCosponsorsResponse
+
+	// Parse (unmarshal) the JSON into the map
+	err := json.Unmarshal(data, &result)
+	if err != nil {
+		log.Fatalf("Error parsing JSON: %s", err)
+	}
+
+	bill := ctx.Value(billContextKey).(*model.CongressApiBill)
+	billId := ids.Bill(bill.Congress, *bill.Type, *bill.Number)
+
+	var cnt = 0
+	for _, cosponsor := range result.Cosponsors {
+		if cosponsor.BioguideID == nil {
+			continue
+		}
+		personId, ok := c.createSponsorNode(ctx, *cosponsor.BioguideID, cosponsor.FirstName, cosponsor.LastName, cosponsor.FullName)
+		if !ok {
+			continue
+		}
+		c.createSponsorshipEdge(ctx, "COSPONSORED", ids.Cosponsorship, &graphdb.NodeInfo{Id: billId, Label: "Bill"}, personId, map[string]interface{}{
+			"date":          cosponsor.SponsorshipDate,
+			"isOriginal":    cosponsor.IsOriginalCosponsor,
+			"withdrawnDate": cosponsor.SponsorshipWithdrawnDate,
+		})
+		cnt++
+	}
+	fmt.Printf("updated %d cosponsors of bill %s\n", cnt, billId)
+}
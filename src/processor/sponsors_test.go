@@ -0,0 +1,134 @@
+package processor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nedvisol/go-connectdots/graphdb"
+	"github.com/nedvisol/go-connectdots/ids"
+	"github.com/nedvisol/go-connectdots/model"
+)
+
+func TestCreateSponsorNode(t *testing.T) {
+	bernie, sanders, fullName := "Bernie", "Sanders", "Sen. Sanders, Bernard [I-VT]"
+	tests := []struct {
+		name      string
+		member    map[string]interface{} // attrs written by the member crawl, nil if it did not crawl the sponsor
+		first     *string
+		last      *string
+		fullName  *string
+		wantOk    bool
+		wantFirst interface{}
+		wantLast  interface{}
+	}{
+		{"new sponsor", nil, &bernie, &sanders, &fullName, true, "Bernie", "Sanders"},
+		{"new sponsor named by fullName", nil, nil, nil, &fullName, true, "Bernard", "Sanders"},
+		{"new sponsor without first name", nil, nil, &sanders, nil, false, nil, nil},
+		{"crawled member", map[string]interface{}{"first": "Bernard", "last": "Sanders"}, &bernie, &sanders, nil, true, "Bernard", "Sanders"},
+		{"crawled member without names", map[string]interface{}{"state": "Vermont"}, &bernie, &sanders, nil, true, nil, nil},
+		{"crawled member, sponsor without names", map[string]interface{}{"first": "Bernard", "last": "Sanders"}, nil, nil, nil, true, "Bernard", "Sanders"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			svc := graphdb.NewMemoryGraphService()
+			if test.member != nil {
+				if err := svc.UpdateNode(ctx, &graphdb.NodeInfo{Label: "Person", Id: ids.Member("S000033"), Attrs: &test.member}, true); err != nil {
+					t.Fatal(err)
+				}
+			}
+			c := &CongressGovProcessor{graphdbsvc: svc}
+			personId, ok := c.createSponsorNode(ctx, "S000033", test.first, test.last, test.fullName)
+			if ok != test.wantOk {
+				t.Fatalf("ok is %v, want %v", ok, test.wantOk)
+			}
+
+			person, err := svc.GetNode(ctx, &graphdb.NodeInfo{Label: "Person", Id: personId})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !test.wantOk {
+				if person != nil {
+					t.Errorf("created %v", *person.Attrs)
+				}
+				return
+			}
+			attrs := *person.Attrs
+			if derefAttr(attrs["first"]) != test.wantFirst || derefAttr(attrs["last"]) != test.wantLast {
+				t.Errorf("named %v %v, want %v %v", derefAttr(attrs["first"]), derefAttr(attrs["last"]), test.wantFirst, test.wantLast)
+			}
+			if attrs["subtype"] != "CongressMember" {
+				t.Errorf("subtype is %v", attrs["subtype"])
+			}
+		})
+	}
+}
+
+func TestParseSponsorName(t *testing.T) {
+	tests := []struct {
+		fullName  string
+		wantFirst string
+		wantLast  string
+		wantOk    bool
+	}{
+		{"Sen. Sanders, Bernard [I-VT]", "Bernard", "Sanders", true},
+		{"Rep. Ocasio-Cortez, Alexandria [D-NY-14]", "Alexandria", "Ocasio-Cortez", true},
+		{"Resident Commissioner González-Colón, Jenniffer [R-PR-At Large]", "Jenniffer", "González-Colón", true},
+		{"Del. Norton, Eleanor Holmes [D-DC-At Large]", "Eleanor Holmes", "Norton", true},
+		{"Sanders [I-VT]", "", "", false},
+		{"", "", "", false},
+	}
+	for _, test := range tests {
+		first, last, ok := parseSponsorName(test.fullName)
+		if first != test.wantFirst || last != test.wantLast || ok != test.wantOk {
+			t.Errorf("parseSponsorName(%q) = %q, %q, %v, want %q, %q, %v", test.fullName, first, last, ok, test.wantFirst, test.wantLast, test.wantOk)
+		}
+	}
+}
+
+// TestProcessSponsorsWithoutNames ingests sponsors the member crawl did not see, the graph must still
+// hold to the DefaultSchema
+func TestProcessSponsorsWithoutNames(t *testing.T) {
+	ctx := context.Background()
+	svc := graphdb.NewMemoryGraphService()
+	congress, billType, number := 118, "S", "1"
+	bill := &model.CongressApiBill{Congress: congress, Type: &billType, Number: &number}
+	billId := ids.Bill(congress, billType, number)
+	if err := svc.UpdateNode(ctx, &graphdb.NodeInfo{Label: "Bill", Id: billId, Attrs: &map[string]interface{}{"congress": congress, "billType": billType}}, true); err != nil {
+		t.Fatal(err)
+	}
+	ctx = context.WithValue(ctx, billContextKey, bill)
+	c := &CongressGovProcessor{graphdbsvc: svc}
+
+	c.processBillDetail(ctx, []byte(`{"bill": {"introducedDate": "2023-01-23", "sponsors": [
+		{"bioguideId": "S000033", "fullName": "Sen. Sanders, Bernard [I-VT]"}
+	]}}`))
+	c.processCosponsors(ctx, []byte(`{"cosponsors": [
+		{"bioguideId": "M001176", "sponsorshipDate": "2023-01-24"},
+		{"bioguideId": "W000817", "firstName": "Elizabeth", "sponsorshipDate": "2023-01-24", "fullName": "Sen. Warren, Elizabeth [D-MA]"}
+	]}`))
+
+	if err := svc.ApplySchema(ctx, graphdb.DefaultSchema); err != nil {
+		t.Fatalf("sponsors break the schema: %v", err)
+	}
+	for _, test := range []struct {
+		memberId string
+		want     bool
+	}{{"S000033", true}, {"M001176", false}, {"W000817", true}} {
+		person, err := svc.GetNode(ctx, &graphdb.NodeInfo{Label: "Person", Id: ids.Member(test.memberId)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (person != nil) != test.want {
+			t.Errorf("person %s created is %v, want %v", test.memberId, person != nil, test.want)
+		}
+	}
+}
+
+// derefAttr dereferences the *string attrs the processors write
+func derefAttr(attr interface{}) interface{} {
+	if s, ok := attr.(*string); ok {
+		return *s
+	}
+	return attr
+}
@@ -0,0 +1,157 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/nedvisol/go-connectdots/graphdb"
+	"github.com/nedvisol/go-connectdots/ids"
+	"github.com/nedvisol/go-connectdots/model"
+)
+
+const BILL_SUBJECTS_URL_QUERY = "/subjects?format=json&limit=250"
+const BILL_SUMMARIES_URL_QUERY = "/summaries?format=json&limit=250"
+
+// createTopicEdge creates the Subject or PolicyArea named name, shared by every bill about it,
+// and the ABOUT edge from the bill to it
+func (c *CongressGovProcessor) createTopicEdge(
+	ctx context.Context,
+	billId string,
+	label string,
+	topicId string,
+	aboutId func(billId string, topicId string) (string, error),
+	name string,
+) {
+	topicNode := &graphdb.NodeInfo{
+		Id:    topicId,
+		Label: label,
+		Attrs: &map[string]interface{}{
+			"name": name,
+		},
+		Provenance: c.provenance(ctx),
+	}
+	err := c.graphdbsvc.UpdateNode(ctx, topicNode, true)
+	if err != nil {
+		panic(err)
+	}
+
+	edgeId, err := aboutId(billId, topicId)
+	if err != nil {
+		panic(err)
+	}
+	about := &graphdb.EdgeInfo{
+		Label: "ABOUT",
+		Id:    edgeId,
+		Left: &graphdb.NodeInfo{
+			Id:    billId,
+			Label: "Bill",
+		},
+		Right: &graphdb.NodeInfo{
+			Id:    topicId,
+			Label: label,
+		},
+		Attrs:      &map[string]interface{}{},
+		Provenance: c.provenance(ctx),
+	}
+	err = c.graphdbsvc.UpdateEdge(ctx, about, true)
+	if errors.Is(err, graphdb.ErrNodeNotFound) {
+		fmt.Printf("skipped %s %s of %s, not in the graph\n", label, name, billId)
+		return
+	}
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (c *CongressGovProcessor) processBillSubjects(ctx context.Context, data []byte) {
+	fmt.Printf("processing bill subjects %d bytes\n", len(data))
+
+	var result model.CongressApiBillSubjectsResponse
+
+	// Parse (unmarshal) the JSON into the map
+	err := json.Unmarshal(data, &result)
+	if err != nil {
+		log.Fatalf("Error parsing JSON: %s", err)
+	}
+	if result.Subjects == nil {
+		return
+	}
+
+	bill := ctx.Value(billContextKey).(*model.CongressApiBill)
+	billId := ids.Bill(bill.Congress, *bill.Type, *bill.Number)
+
+	var cnt = 0
+	for _, subject := range result.Subjects.LegislativeSubjects {
+		if subject.Name == nil {
+			continue
+		}
+		c.createTopicEdge(ctx, billId, "Subject", ids.Subject(*subject.Name), ids.BillSubject, *subject.Name)
+		cnt++
+	}
+	// every page repeats the policy area
+	if policyArea := result.Subjects.PolicyArea; policyArea != nil && policyArea.Name != nil {
+		c.createTopicEdge(ctx, billId, "PolicyArea", ids.PolicyArea(*policyArea.Name), ids.BillPolicyArea, *policyArea.Name)
+	}
+	fmt.Printf("updated %d subjects of bill %s\n", cnt, billId)
+}
+
+// latestSummary returns the summary of the latest version of a bill, by action date then update date
+func latestSummary(summaries []*model.CongressApiBillSummary) *model.CongressApiBillSummary {
+	value := func(field *string) string {
+		if field == nil {
+			return ""
+		}
+		return *field
+	}
+	var latest *model.CongressApiBillSummary
+	for _, summary := range summaries {
+		if summary.Text == nil {
+			continue
+		}
+		if latest == nil || value(summary.ActionDate) > value(latest.ActionDate) ||
+			(value(summary.ActionDate) == value(latest.ActionDate) && value(summary.UpdateDate) > value(latest.UpdateDate)) {
+			latest = summary
+		}
+	}
+	return latest
+}
+
+// processBillSummaries stores the text of the latest CRS summary on the bill, the few summaries of a bill
+// fit the first page
+func (c *CongressGovProcessor) processBillSummaries(ctx context.Context, data []byte) {
+	fmt.Printf("processing bill summaries %d bytes\n", len(data))
+
+	var result model.CongressApiBillSummariesResponse
+
+	// Parse (unmarshal) the JSON into the map
+	err := json.Unmarshal(data, &result)
+	if err != nil {
+		log.Fatalf("Error parsing JSON: %s", err)
+	}
+
+	summary := latestSummary(result.Summaries)
+	if summary == nil {
+		return
+	}
+
+	bill := ctx.Value(billContextKey).(*model.CongressApiBill)
+	billNode := &graphdb.NodeInfo{
+		Id:    ids.Bill(bill.Congress, *bill.Type, *bill.Number),
+		Label: "Bill",
+		Attrs: &map[string]interface{}{
+			"summary":        summary.Text,
+			"summaryDate":    summary.ActionDate,
+			"summaryAction":  summary.ActionDesc,
+			"summaryVersion": summary.VersionCode,
+		},
+		Provenance: c.provenance(ctx),
+	}
+	err = c.graphdbsvc.UpdateNode(ctx, billNode, false)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("updated summary of bill %s\n", billNode.Id)
+}
@@ -0,0 +1,59 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/nedvisol/go-connectdots/model"
+)
+
+func summary(versionCode string, actionDate string, updateDate string, text bool) *model.CongressApiBillSummary {
+	summary := &model.CongressApiBillSummary{VersionCode: &versionCode}
+	if actionDate != "" {
+		summary.ActionDate = &actionDate
+	}
+	if updateDate != "" {
+		summary.UpdateDate = &updateDate
+	}
+	if text {
+		content := "<p>" + versionCode + "</p>"
+		summary.Text = &content
+	}
+	return summary
+}
+
+func TestLatestSummary(t *testing.T) {
+	tests := []struct {
+		name      string
+		summaries []*model.CongressApiBillSummary
+		want      string
+	}{
+		{"none", nil, ""},
+		{"single", []*model.CongressApiBillSummary{summary("00", "2023-01-09", "2023-02-01T10:00:00Z", true)}, "00"},
+		{"latest action", []*model.CongressApiBillSummary{
+			summary("36", "2023-05-01", "2023-05-10T10:00:00Z", true),
+			summary("00", "2023-01-09", "2023-06-01T10:00:00Z", true),
+		}, "36"},
+		{"same action, latest update", []*model.CongressApiBillSummary{
+			summary("00", "2023-01-09", "2023-02-01T10:00:00Z", true),
+			summary("01", "2023-01-09", "2023-03-01T10:00:00Z", true),
+		}, "01"},
+		{"without text", []*model.CongressApiBillSummary{
+			summary("00", "2023-01-09", "2023-02-01T10:00:00Z", true),
+			summary("36", "2023-05-01", "2023-05-10T10:00:00Z", false),
+		}, "00"},
+		{"without action date", []*model.CongressApiBillSummary{
+			summary("00", "", "2023-08-01T10:00:00Z", true),
+			summary("36", "2023-05-01", "2023-05-10T10:00:00Z", true),
+		}, "36"},
+		{"all without text", []*model.CongressApiBillSummary{summary("00", "2023-01-09", "", false)}, ""},
+	}
+	for _, test := range tests {
+		got := ""
+		if latest := latestSummary(test.summaries); latest != nil {
+			got = *latest.VersionCode
+		}
+		if got != test.want {
+			t.Errorf("%s: latestSummary = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
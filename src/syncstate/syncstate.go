@@ -0,0 +1,22 @@
+package syncstate
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SyncState is the high-water mark of an incrementally synced endpoint, the records updated
+// before HighWaterMark are in the graph
+type SyncState struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty"`
+	Key           string             `bson:"key"`             // the endpoint, e.g. https://api.congress.gov/v3/bill/118
+	HighWaterMark time.Time          `bson:"high_water_mark"` // latest update date synced by the last complete sync
+	RunId         string             `bson:"run_id"`          // run of the last complete sync
+}
+
+type SyncStateRepository interface {
+	FindByKey(ctx context.Context, key string) (*SyncState, error)
+	Save(ctx context.Context, state *SyncState) error
+}
@@ -0,0 +1,47 @@
+package syncstate
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type syncStateMongoRepository struct {
+	collection *mongo.Collection
+}
+
+// NewSyncStateMongoRepository creates a new SyncState repository instance.
+func NewSyncStateMongoRepository(db *mongo.Database) SyncStateRepository {
+	return &syncStateMongoRepository{
+		collection: db.Collection("sync_states"), // MongoDB collection name
+	}
+}
+
+// FindByKey finds the SyncState of an endpoint, nil if it was never synced.
+func (r *syncStateMongoRepository) FindByKey(ctx context.Context, key string) (*SyncState, error) {
+	var state SyncState
+	err := r.collection.FindOne(ctx, bson.M{"key": key}).Decode(&state)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil // No state found
+		}
+		return nil, err
+	}
+	return &state, nil
+}
+
+// Save inserts or replaces the SyncState with the key of state.
+func (r *syncStateMongoRepository) Save(ctx context.Context, state *SyncState) error {
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"key": state.Key},
+		bson.M{"$set": bson.M{
+			"high_water_mark": state.HighWaterMark,
+			"run_id":          state.RunId,
+		}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
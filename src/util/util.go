@@ -1,8 +1,12 @@
 package util
 
 import (
+	"crypto/rand"
 	"crypto/sha512"
 	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
 )
 
 func GetSHA512(val string) string {
@@ -23,3 +27,28 @@ func Ternary(cond bool, trueval any, falseval any) any {
 		return falseval
 	}
 }
+
+// NewRunId returns an identifier for a run of the application, sortable by start time
+func NewRunId() string {
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return fmt.Sprintf("%s-%s", time.Now().UTC().Format("20060102T150405Z"), hex.EncodeToString(suffix))
+}
+
+// Retry calls fn until it succeeds, at most attempts times, doubling delay after every failure.
+// fn is called at least once. The last error is returned once the attempts are used up.
+func Retry(name string, attempts int, delay time.Duration, fn func() error) error {
+	attempts = max(attempts, 1)
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = fn(); err == nil {
+			return nil
+		}
+		fmt.Printf("%s failed, attempt %d of %d: %s\n", name, attempt, attempts, err)
+		if attempt < attempts {
+			time.Sleep(delay)
+			delay *= 2
+		}
+	}
+	return err
+}
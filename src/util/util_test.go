@@ -0,0 +1,36 @@
+package util
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRetry(t *testing.T) {
+	failing := errors.New("unreachable")
+	tests := []struct {
+		name      string
+		attempts  int
+		failures  int
+		wantCalls int
+		wantErr   error
+	}{
+		{"first call succeeds", 3, 0, 1, nil},
+		{"succeeds on the last attempt", 3, 2, 3, nil},
+		{"attempts used up", 3, 5, 3, failing},
+		{"no attempts still calls once", 0, 5, 1, failing},
+		{"negative attempts still call once", -1, 0, 1, nil},
+	}
+	for _, test := range tests {
+		calls := 0
+		err := Retry(test.name, test.attempts, 0, func() error {
+			calls++
+			if calls <= test.failures {
+				return failing
+			}
+			return nil
+		})
+		if calls != test.wantCalls || !errors.Is(err, test.wantErr) {
+			t.Errorf("%s: %d calls returning %v, want %d returning %v", test.name, calls, err, test.wantCalls, test.wantErr)
+		}
+	}
+}